@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+
+	"DevStarByte/internal/backup"
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+)
+
+// passphraseEnv can hold the backup passphrase, for scripts.
+const passphraseEnv = "WHATSAPP_TUI_BACKUP_PASSPHRASE"
+
+// readPassphrase reads a passphrase from file, the environment variable env,
+// or the terminal, where it asks twice if confirm is set.
+func readPassphrase(file, env string, confirm bool) ([]byte, error) {
+	var pass []byte
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pass = []byte(strings.TrimRight(string(data), "\r\n"))
+	case os.Getenv(env) != "":
+		pass = []byte(os.Getenv(env))
+	case term.IsTerminal(os.Stdin.Fd()):
+		fmt.Fprint(os.Stderr, "Passphrase: ")
+		p, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if confirm {
+			fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+			again, err := term.ReadPassword(os.Stdin.Fd())
+			fmt.Fprintln(os.Stderr)
+			if err != nil {
+				return nil, err
+			}
+			if string(again) != string(p) {
+				return nil, errors.New("the passphrases differ")
+			}
+		}
+		pass = p
+	default:
+		return nil, fmt.Errorf("no passphrase: not a terminal and %s is unset", env)
+	}
+	if len(pass) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	return pass, nil
+}
+
+// ── backup ────────────────────────────────────────────────────────────────────
+
+// runBackup implements "whatsapp-tui backup": it writes messages.db, the
+// media cache and optionally the session to an encrypted archive.
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whatsapp-tui backup [FILE] [--session] [--passphrase-file FILE]")
+		fs.PrintDefaults()
+	}
+	session := fs.Bool("session", false, "include the WhatsApp session (whatsapp.db), so a restore needs no pairing; keep such backups safe")
+	passFile := fs.String("passphrase-file", "", "read the passphrase from this `file` (default: $"+passphraseEnv+" or a prompt)")
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if len(pos) > 1 {
+		fs.Usage()
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	out := "whatsapp-tui-" + time.Now().Format("20060102-150405") + ".backup"
+	if len(pos) == 1 {
+		out = pos[0]
+	}
+	logger := cliLogger()
+	if _, err := os.Stat(backup.MessagesDB); err != nil {
+		fail(logger, "backup", err)
+		return state.ExitCodes["DB_INIT_ERROR"]
+	}
+	pass, err := readPassphrase(*passFile, passphraseEnv, true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "backup:", err)
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		fail(logger, "backup", err)
+		return state.ExitCodes["BACKUP_ERROR"]
+	}
+	man, err := backup.Create(f, ".", backup.Options{Passphrase: pass, Session: *session})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(out)
+		fail(logger, "backup", err)
+		return state.ExitCodes["BACKUP_ERROR"]
+	}
+	logger.Info(fmt.Sprintf("Backed up %d files to %s", len(man.Files), out))
+	fmt.Printf("Backed up %d files (%.1f MB) to %s\n", len(man.Files), float64(man.Size())/1e6, out)
+	return state.ExitCodes["SUCCESS"]
+}
+
+// ── restore ───────────────────────────────────────────────────────────────────
+
+// runRestore implements "whatsapp-tui restore": it checks a backup and puts
+// its files back in place.
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whatsapp-tui restore FILE [--check] [--passphrase-file FILE]")
+		fs.PrintDefaults()
+	}
+	check := fs.Bool("check", false, "only check the backup and list what it holds")
+	passFile := fs.String("passphrase-file", "", "read the passphrase from this `file` (default: $"+passphraseEnv+" or a prompt)")
+	socket := fs.String("socket", rpc.DefaultSocket, "refuse to restore while a daemon listens on this `socket`")
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if len(pos) != 1 {
+		fs.Usage()
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	logger := cliLogger()
+	if !*check {
+		if conn, err := rpc.Dial(*socket); err == nil {
+			conn.Close()
+			fmt.Fprintln(os.Stderr, "restore: the daemon is running; stop it, and any TUI, before restoring")
+			return state.ExitCodes["USAGE_ERROR"]
+		}
+	}
+	pass, err := readPassphrase(*passFile, passphraseEnv, false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "restore:", err)
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	f, err := os.Open(pos[0])
+	if err != nil {
+		fail(logger, "restore", err)
+		return state.ExitCodes["BACKUP_ERROR"]
+	}
+	defer f.Close()
+
+	var man backup.Manifest
+	aside := ""
+	if *check {
+		man, err = backup.Verify(f, pass)
+	} else {
+		man, aside, err = backup.Restore(f, pass, ".")
+	}
+	if err != nil {
+		fail(logger, "restore", err)
+		return state.ExitCodes["BACKUP_ERROR"]
+	}
+	what := "messages and media"
+	if man.Session {
+		what += " and the WhatsApp session"
+	}
+	created := man.Created.Local().Format("2006-01-02 15:04")
+	if *check {
+		fmt.Printf("The backup is intact: %d files (%.1f MB) with %s from %s\n", len(man.Files), float64(man.Size())/1e6, what, created)
+		return state.ExitCodes["SUCCESS"]
+	}
+	logger.Info(fmt.Sprintf("Restored %d files from %s", len(man.Files), pos[0]))
+	fmt.Printf("Restored %s from %s\n", what, created)
+	if aside != "" {
+		fmt.Printf("The replaced files are in %s\n", aside)
+	}
+	return state.ExitCodes["SUCCESS"]
+}
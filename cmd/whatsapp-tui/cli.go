@@ -0,0 +1,609 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/client"
+	"DevStarByte/internal/config"
+	"DevStarByte/internal/daemon"
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/export"
+	"DevStarByte/internal/importer"
+	"DevStarByte/internal/notify"
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// The scripting subcommands print results to stdout and errors to stderr,
+// and exit with a code from state.ExitCodes.
+
+// ── Sessions ──────────────────────────────────────────────────────────────────
+
+// openSession attaches to the daemon on socket if one runs and otherwise
+// connects to WhatsApp with the saved session; it never pairs. The chats
+// are loaded so names resolve. closeFn ends the session.
+func openSession(ctx context.Context, logger *Logger.Logger, socket string) (s *state.AppState, chats []apptypes.ChatItem, closeFn func(), code int) {
+	cfg, err := config.Load(logger, config.DefaultPath)
+	if err != nil {
+		logger.Warning("Config load failed, using defaults: " + err.Error())
+	}
+	cfg.Notify = notify.BackendOff
+
+	if conn, err := rpc.Dial(socket); err == nil {
+		s = state.New(nil, nil, logger, cfg)
+		if chats, err = daemon.Bind(s, conn); err != nil {
+			conn.Close()
+			fail(logger, "attaching to daemon", err)
+			return nil, nil, nil, state.ExitCodes["DAEMON_ERROR"]
+		}
+		return s, chats, func() { conn.Close() }, 0
+	}
+
+	s, waClient, code := connectWhatsApp(ctx, logger, cfg, "", false, false)
+	if s == nil {
+		if code == state.ExitCodes["NOT_LOGGED_IN"] {
+			fmt.Fprintln(os.Stderr, "not logged in: run whatsapp-tui once to pair with your phone")
+		} else {
+			fmt.Fprintln(os.Stderr, "connecting to WhatsApp failed, see .log")
+		}
+		return nil, nil, nil, code
+	}
+	chats = loadChats(ctx, s)
+	return s, chats, func() {
+		s.Hooks.Close()
+		waClient.Disconnect()
+		s.Notifier.Close()
+		s.Webhooks.Close()
+		s.DB.Close()
+	}, 0
+}
+
+// openStore opens messages.db for the commands that only read it. The
+// chats are put in the state so names resolve.
+func openStore(logger *Logger.Logger) (*state.AppState, int) {
+	store, err := db.NewStore(logger)
+	if err != nil {
+		fail(logger, "opening messages.db", err)
+		return nil, state.ExitCodes["DB_INIT_ERROR"]
+	}
+	cfg, err := config.Load(logger, config.DefaultPath)
+	if err != nil {
+		logger.Warning("Config load failed, using defaults: " + err.Error())
+	}
+	cfg.Notify = notify.BackendOff
+	if err := unlockStore(logger, store, cfg, false); err != nil {
+		fail(logger, "unlocking messages.db", err)
+		store.Close()
+		return nil, state.ExitCodes["DB_KEY_ERROR"]
+	}
+	s := state.New(nil, store, logger, cfg)
+	for _, c := range store.LoadChats() {
+		c := c
+		s.ChatsMap[c.JID.String()] = &c
+	}
+	return s, 0
+}
+
+func cliLogger() *Logger.Logger {
+	logger, _ := Logger.NewLogger(Logger.DEBUG, "./.log", false)
+	return logger
+}
+
+// fail reports err on stderr and in the log.
+func fail(logger *Logger.Logger, what string, err error) {
+	logger.Error(what + ": " + err.Error())
+	fmt.Fprintf(os.Stderr, "%s: %v\n", what, err)
+}
+
+// parseArgs parses flags that may come before or after the positional
+// arguments, which it returns.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return pos, nil
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func printJSON(v interface{}) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, "encoding output:", err)
+		return state.ExitCodes["DATA_MARSHAL_ERROR"]
+	}
+	return state.ExitCodes["SUCCESS"]
+}
+
+// parseSince accepts a duration back from now ("90m", "24h", "7d"), a date
+// or a date and time in local time, or an RFC 3339 timestamp.
+func parseSince(v string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use e.g. 24h, 7d, 2026-10-01 or 2026-10-01 09:00", v)
+}
+
+// ── send ──────────────────────────────────────────────────────────────────────
+
+// runSend implements "whatsapp-tui send": a text, read from stdin unless
+// given with -text, or a file with -text as its caption.
+func runSend(args []string) int {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whatsapp-tui send --to <jid|number|name> [--text TEXT | --file PATH [--text CAPTION]]")
+		fs.PrintDefaults()
+	}
+	to := fs.String("to", "", "send to this `chat`: a JID, a phone number or a chat name")
+	text := fs.String("text", "", "message `text`; read from stdin if neither this nor -file is given")
+	file := fs.String("file", "", "send this `file`: images, MP4 videos and audio as such, anything else as a document")
+	socket := fs.String("socket", rpc.DefaultSocket, "use the daemon on this `socket` if one is running")
+	if err := fs.Parse(args); err != nil {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if *to == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if *text == "" && *file == "" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "reading stdin:", err)
+			return state.ExitCodes["ERROR"]
+		}
+		*text = strings.TrimRight(string(b), "\r\n")
+		if strings.TrimSpace(*text) == "" {
+			fmt.Fprintln(os.Stderr, "send: empty message")
+			return state.ExitCodes["USAGE_ERROR"]
+		}
+	}
+	if *file != "" {
+		if _, err := os.Stat(*file); err != nil {
+			fmt.Fprintln(os.Stderr, "send:", err)
+			return state.ExitCodes["USAGE_ERROR"]
+		}
+	}
+
+	logger := cliLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, _, closeSession, code := openSession(ctx, logger, *socket)
+	if s == nil {
+		return code
+	}
+	defer closeSession()
+
+	jid, err := client.ResolveJID(s, *to)
+	if err != nil {
+		fail(logger, "send", err)
+		return state.ExitCodes["CHAT_NOT_FOUND"]
+	}
+	if *file != "" {
+		err = client.SendFile(s, jid, *file, *text)
+	} else {
+		err = client.SendMessage(s, jid, *text)
+	}
+	if err != nil {
+		fail(logger, "send", err)
+		return state.ExitCodes["SEND_ERROR"]
+	}
+	return state.ExitCodes["SUCCESS"]
+}
+
+// ── chats ─────────────────────────────────────────────────────────────────────
+
+// runChats implements "whatsapp-tui chats": the chat list in sidebar order.
+func runChats(args []string) int {
+	fs := flag.NewFlagSet("chats", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	socket := fs.String("socket", rpc.DefaultSocket, "use the daemon on this `socket` if one is running")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+
+	logger := cliLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, chats, closeSession, code := openSession(ctx, logger, *socket)
+	if s == nil {
+		return code
+	}
+	defer closeSession()
+
+	if *asJSON {
+		if chats == nil {
+			chats = []apptypes.ChatItem{}
+		}
+		return printJSON(chats)
+	}
+	for _, c := range chats {
+		unread := ""
+		if c.Unread > 0 {
+			unread = fmt.Sprintf(" (%d)", c.Unread)
+		}
+		fmt.Printf("%-40s %s%s\n", c.JID, c.Name, unread)
+	}
+	return state.ExitCodes["SUCCESS"]
+}
+
+// ── history ───────────────────────────────────────────────────────────────────
+
+// runHistory implements "whatsapp-tui history <chat>". It reads
+// messages.db, so it needs no connection.
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whatsapp-tui history <jid|number|name> [--since WHEN] [--limit N] [--json]")
+		fs.PrintDefaults()
+	}
+	since := fs.String("since", "", "only messages since `WHEN`: a duration like 24h or 7d, a date, or a date and time")
+	limit := fs.Int("limit", 0, "print at most the newest `N` messages")
+	asJSON := fs.Bool("json", false, "print JSON")
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if len(pos) != 1 {
+		fs.Usage()
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	var from time.Time
+	if *since != "" {
+		if from, err = parseSince(*since, time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, "history:", err)
+			return state.ExitCodes["USAGE_ERROR"]
+		}
+	}
+
+	logger := cliLogger()
+	s, code := openStore(logger)
+	if s == nil {
+		return code
+	}
+	defer s.DB.Close()
+
+	jid, err := client.ResolveJID(s, pos[0])
+	if err != nil {
+		fail(logger, "history", err)
+		return state.ExitCodes["CHAT_NOT_FOUND"]
+	}
+	msgs := s.DB.LoadMessagesBetween(jid.String(), from, time.Time{}, *limit)
+	if *asJSON {
+		if msgs == nil {
+			msgs = []apptypes.Message{}
+		}
+		return printJSON(msgs)
+	}
+	for _, m := range msgs {
+		fmt.Printf("%s  %s: %s\n", m.Timestamp.Local().Format("2006-01-02 15:04"), m.Sender, m.Content)
+	}
+	return state.ExitCodes["SUCCESS"]
+}
+
+// ── search ────────────────────────────────────────────────────────────────────
+
+// runSearch implements "whatsapp-tui search <text>". Like history, it only
+// reads messages.db.
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whatsapp-tui search <text> [--chat CHAT] [--limit N] [--json]")
+		fs.PrintDefaults()
+	}
+	chat := fs.String("chat", "", "only search the chat with this JID, number or `name`")
+	limit := fs.Int("limit", 50, "print at most the newest `N` matches")
+	asJSON := fs.Bool("json", false, "print JSON")
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if len(pos) == 0 || *limit <= 0 {
+		fs.Usage()
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+
+	logger := cliLogger()
+	s, code := openStore(logger)
+	if s == nil {
+		return code
+	}
+	defer s.DB.Close()
+
+	var chatJID string
+	if *chat != "" {
+		jid, err := client.ResolveJID(s, *chat)
+		if err != nil {
+			fail(logger, "search", err)
+			return state.ExitCodes["CHAT_NOT_FOUND"]
+		}
+		chatJID = jid.String()
+	}
+	results, err := s.DB.SearchMessages(strings.Join(pos, " "), chatJID, *limit)
+	if err != nil {
+		fail(logger, "search", err)
+		return state.ExitCodes["ERROR"]
+	}
+	if *asJSON {
+		if results == nil {
+			results = []db.SearchResult{}
+		}
+		return printJSON(results)
+	}
+	for _, r := range results {
+		name := r.Chat
+		if c := s.ChatsMap[r.Chat]; c != nil && c.Name != "" {
+			name = c.Name
+		}
+		m := r.Message
+		fmt.Printf("%s  %s  %s: %s\n", m.Timestamp.Local().Format("2006-01-02 15:04"), name, m.Sender, m.Content)
+	}
+	return state.ExitCodes["SUCCESS"]
+}
+
+// ── export ────────────────────────────────────────────────────────────────────
+
+// runExport implements "whatsapp-tui export [chat]": one chat, or all of
+// them, from messages.db to files. It needs no connection.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whatsapp-tui export [<jid|number|name>] [--format txt|json|md|html] [--since WHEN] [--until WHEN] [--out DIR]")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "txt", "file `format`: txt (as WhatsApp exports chats), json, md or html")
+	since := fs.String("since", "", "only messages since `WHEN`: a duration like 24h or 7d, a date, or a date and time")
+	until := fs.String("until", "", "only messages before `WHEN`, given like --since")
+	out := fs.String("out", "", "write to this `directory` (default export-<date>-<time>)")
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if len(pos) > 1 {
+		fs.Usage()
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	opts := export.Options{}
+	if opts.Format, err = export.ParseFormat(*format); err != nil {
+		fmt.Fprintln(os.Stderr, "export:", err)
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	now := time.Now()
+	for _, f := range []struct {
+		v string
+		t *time.Time
+	}{{*since, &opts.From}, {*until, &opts.To}} {
+		if f.v == "" {
+			continue
+		}
+		if *f.t, err = parseSince(f.v, now); err != nil {
+			fmt.Fprintln(os.Stderr, "export:", err)
+			return state.ExitCodes["USAGE_ERROR"]
+		}
+	}
+	if *out == "" {
+		*out = "export-" + now.Format("20060102-150405")
+	}
+
+	logger := cliLogger()
+	s, code := openStore(logger)
+	if s == nil {
+		return code
+	}
+	defer s.DB.Close()
+
+	chats := s.DB.LoadChats()
+	if len(pos) == 1 {
+		jid, err := client.ResolveJID(s, pos[0])
+		if err != nil {
+			fail(logger, "export", err)
+			return state.ExitCodes["CHAT_NOT_FOUND"]
+		}
+		c, ok := s.ChatsMap[jid.String()]
+		if !ok {
+			c = &apptypes.ChatItem{JID: jid, Name: jid.User}
+		}
+		chats = []apptypes.ChatItem{*c}
+	}
+	res, err := export.Export(s.DB, chats, *out, opts)
+	if err != nil {
+		fail(logger, "export", err)
+		return state.ExitCodes["ERROR"]
+	}
+	logger.Info(fmt.Sprintf("Exported %d messages from %d chats to %s", res.Messages, res.Chats, *out))
+	fmt.Printf("Exported %d messages from %d chats to %s\n", res.Messages, res.Chats, *out)
+	if res.Media > 0 || res.MissingMedia > 0 {
+		fmt.Printf("Copied %d images; %d were no longer in media_cache\n", res.Media, res.MissingMedia)
+	}
+	return state.ExitCodes["SUCCESS"]
+}
+
+// ── import ────────────────────────────────────────────────────────────────────
+
+// runImport implements "whatsapp-tui import": it adds a chat exported on the
+// phone to messages.db.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whatsapp-tui import <archive.zip|chat.txt|dir> [--chat jid|number|name] [--me NAME] [--date-order dmy|mdy|ymd] [--dry-run]")
+		fs.PrintDefaults()
+	}
+	chatArg := fs.String("chat", "", "the `chat` the export belongs to (default: from the file name)")
+	meArg := fs.String("me", "", "comma-separated `names` you have in the export (default: guessed in direct chats)")
+	order := fs.String("date-order", "", "`order` of day, month and year in the dates: dmy, mdy or ymd (default: guessed)")
+	dryRun := fs.Bool("dry-run", false, "parse the export and print what would be imported")
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if len(pos) != 1 {
+		fs.Usage()
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	opts := importer.Options{DateOrder: importer.DateOrder(strings.ToLower(*order)), DryRun: *dryRun}
+	switch opts.DateOrder {
+	case "", importer.DMY, importer.MDY, importer.YMD:
+	default:
+		fmt.Fprintln(os.Stderr, "import: --date-order must be dmy, mdy or ymd")
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	for _, n := range strings.Split(*meArg, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			opts.Me = append(opts.Me, n)
+		}
+	}
+
+	logger := cliLogger()
+	a, err := importer.Open(pos[0])
+	if err != nil {
+		fail(logger, "import", err)
+		return state.ExitCodes["ERROR"]
+	}
+	defer a.Close()
+	s, code := openStore(logger)
+	if s == nil {
+		return code
+	}
+	defer s.DB.Close()
+
+	query := *chatArg
+	if query == "" {
+		query = a.Name
+	}
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "import: can't tell the chat from the file name, use --chat")
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	if opts.Chat, err = client.ResolveJID(s, query); err != nil {
+		fail(logger, "import", fmt.Errorf("%w; use --chat with a JID or number", err))
+		return state.ExitCodes["CHAT_NOT_FOUND"]
+	}
+	names := s.DB.SenderJIDs()
+	if names == nil {
+		names = make(map[string]types.JID)
+	}
+	for _, c := range s.ChatsMap {
+		if !c.IsGroup && c.Name != "" {
+			names[strings.ToLower(c.Name)] = c.JID
+		}
+	}
+	opts.Resolve = func(name string) (types.JID, bool) {
+		jid, ok := names[strings.ToLower(name)]
+		return jid, ok
+	}
+
+	res, err := importer.Import(s.DB, a, opts)
+	if err != nil {
+		fail(logger, "import", err)
+		return state.ExitCodes["ERROR"]
+	}
+	span := res.First.Format("2006-01-02") + " to " + res.Last.Format("2006-01-02")
+	if *dryRun {
+		fmt.Printf("Would import %d messages (%s) into %s\n", res.Messages, span, opts.Chat)
+	} else {
+		logger.Info(fmt.Sprintf("Imported %d of %d messages into %s", res.Added, res.Messages, opts.Chat))
+		fmt.Printf("Imported %d new messages (%s) into %s; %d were already there\n", res.Added, span, opts.Chat, res.Messages-res.Added)
+		if res.Media > 0 {
+			fmt.Printf("Copied %d images to media_cache\n", res.Media)
+		}
+	}
+	switch {
+	case res.Me != "":
+		fmt.Printf("Messages from %s are shown as yours\n", res.Me)
+	case len(opts.Me) == 0:
+		fmt.Fprintln(os.Stderr, "import: couldn't tell which sender is you, so no message is shown as yours; use --me")
+	}
+	return state.ExitCodes["SUCCESS"]
+}
+
+// ── contacts ──────────────────────────────────────────────────────────────────
+
+// contact is how contacts are printed.
+type contact struct {
+	JID          string `json:"jid"`
+	Name         string `json:"name"`
+	FullName     string `json:"full_name,omitempty"`
+	PushName     string `json:"push_name,omitempty"`
+	BusinessName string `json:"business_name,omitempty"`
+}
+
+// runContacts implements "whatsapp-tui contacts": the contact store, sorted
+// by name.
+func runContacts(args []string) int {
+	fs := flag.NewFlagSet("contacts", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	socket := fs.String("socket", rpc.DefaultSocket, "use the daemon on this `socket` if one is running")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+
+	logger := cliLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, _, closeSession, code := openSession(ctx, logger, *socket)
+	if s == nil {
+		return code
+	}
+	defer closeSession()
+
+	all, err := s.Client.GetAllContacts(ctx)
+	if err != nil {
+		fail(logger, "loading contacts", err)
+		return state.ExitCodes["CONTACT_FETCH_ERROR"]
+	}
+	list := make([]contact, 0, len(all))
+	for jid, info := range all {
+		list = append(list, contact{
+			JID:          jid.String(),
+			Name:         client.DisplayName(s, jid),
+			FullName:     info.FullName,
+			PushName:     info.PushName,
+			BusinessName: info.BusinessName,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if a, b := strings.ToLower(list[i].Name), strings.ToLower(list[j].Name); a != b {
+			return a < b
+		}
+		return list[i].JID < list[j].JID
+	})
+
+	if *asJSON {
+		return printJSON(list)
+	}
+	for _, c := range list {
+		fmt.Printf("%-40s %s\n", c.JID, c.Name)
+	}
+	return state.ExitCodes["SUCCESS"]
+}
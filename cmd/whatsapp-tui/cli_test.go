@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"90m", now.Add(-90 * time.Minute)},
+		{"24h", now.Add(-24 * time.Hour)},
+		{"7d", time.Date(2026, 10, 12, 12, 0, 0, 0, time.Local)},
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-10-01 09:30", time.Date(2026, 10, 1, 9, 30, 0, 0, time.Local)},
+		{"2026-10-01T09:30:00Z", time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "-2d", "2026-13-01"} {
+		if _, err := parseSince(bad, now); err == nil {
+			t.Errorf("parseSince(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestParseArgsInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "")
+	since := fs.String("since", "", "")
+	pos, err := parseArgs(fs, []string{"--since", "7d", "Team Chat", "--json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pos, []string{"Team Chat"}) || !*asJSON || *since != "7d" {
+		t.Errorf("pos = %q, json = %v, since = %q", pos, *asJSON, *since)
+	}
+}
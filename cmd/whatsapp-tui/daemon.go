@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/StarGames2025/Logger"
+
+	"DevStarByte/internal/config"
+	"DevStarByte/internal/daemon"
+	"DevStarByte/internal/notify"
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+)
+
+// runDaemon implements "whatsapp-tui daemon": it keeps the WhatsApp
+// connection and serves it on a Unix socket until it gets a signal. TUIs
+// started in the same directory attach to it.
+func runDaemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whatsapp-tui daemon [flags]")
+		fs.PrintDefaults()
+	}
+	socket := fs.String("socket", rpc.DefaultSocket, "listen on this Unix `socket`")
+	recordPath := fs.String("record", "", "record incoming WhatsApp events to this `file`")
+	redact := fs.Bool("redact", false, "with -record, redact the recording")
+	httpAddr := fs.String("http", "", "also serve the HTTP API on this loopback `address`")
+	if err := fs.Parse(args); err != nil {
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+
+	logger, _ := Logger.NewLogger(Logger.DEBUG, "./.daemon.log", false)
+	logger.Info("Starting WhatsApp daemon...")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg, err := config.Load(logger, config.DefaultPath)
+	if err != nil {
+		logger.Warning("Config load failed, using defaults: " + err.Error())
+	}
+
+	// Claim the socket before connecting, so a second daemon doesn't log in
+	// next to the first.
+	srv, err := daemon.Listen(*socket)
+	if err != nil {
+		logger.Error("Daemon listen failed: " + err.Error())
+		if errors.Is(err, daemon.ErrRunning) {
+			fmt.Fprintln(os.Stderr, "daemon: already running on", *socket)
+		} else {
+			fmt.Fprintln(os.Stderr, "daemon:", err)
+		}
+		return state.ExitCodes["DAEMON_ERROR"]
+	}
+
+	appState, waClient, code := connectWhatsApp(ctx, logger, cfg, *recordPath, *redact, true)
+	if appState == nil {
+		srv.Close()
+		return code
+	}
+	// Escape sequences would go to the daemon's own output, so those are
+	// left to the attached TUIs.
+	if appState.Notifier.Backend() != notify.BackendDBus {
+		appState.Notifier.Close()
+		appState.Notifier = nil
+	}
+	appState.Scheduler = startScheduler(appState)
+	chats := loadChats(ctx, appState)
+	logger.Info(fmt.Sprintf("Daemon: serving %d chats on %s", len(chats), *socket))
+	fmt.Println("Listening on " + *socket)
+	startAPI(ctx, appState, *httpAddr)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+	if err := srv.Serve(ctx, appState); err != nil {
+		logger.Error("Daemon serve failed: " + err.Error())
+		code = appState.ExitCodes["DAEMON_ERROR"]
+	}
+
+	appState.Hooks.Close()
+	appState.Scheduler.Close()
+	waClient.Disconnect()
+	appState.Notifier.Close()
+	appState.Webhooks.Close()
+	if err := appState.Recorder.Close(); err != nil {
+		logger.Warning("Closing recording failed: " + err.Error())
+	}
+	if appState.DB != nil {
+		appState.DB.Close()
+	}
+	logger.Info("WhatsApp daemon shutdown complete")
+	return code
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/StarGames2025/Logger"
+
+	"DevStarByte/internal/backup"
+	"DevStarByte/internal/config"
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/keyring"
+)
+
+// dbPassphraseEnv can hold the messages.db passphrase, for the daemon and
+// scripts.
+const dbPassphraseEnv = "WHATSAPP_TUI_PASSPHRASE"
+
+// unlockStore unlocks an encrypted messages.db, or encrypts a plain one if
+// the config asks for encryption and migrate is set. An encrypted database
+// is always opened with its own mode, whatever the config says.
+//
+// Only the process holding the WhatsApp connection migrates: a store opened
+// elsewhere, like the daemon's, would keep writing plain text into it.
+func unlockStore(logger *Logger.Logger, store *db.Store, cfg *config.Config, migrate bool) error {
+	if store == nil {
+		return nil
+	}
+	mode := store.Encryption()
+	if mode == db.EncryptionOff {
+		mode = db.EncryptionMode(cfg.Encryption)
+		if mode == db.EncryptionOff {
+			return nil
+		}
+		if !migrate {
+			logger.Info("messages.db isn't encrypted yet; the TUI or daemon encrypts it when it next connects")
+			return nil
+		}
+		logger.Info("Encrypting messages.db with a " + string(mode) + " key")
+		secret, err := storeSecret(logger, mode, true)
+		if err != nil {
+			return err
+		}
+		return store.EnableEncryption(mode, secret)
+	}
+	secret, err := storeSecret(logger, mode, false)
+	if err != nil {
+		return err
+	}
+	if err := store.Unlock(secret); err != nil {
+		return err
+	}
+	logger.Info("Unlocked messages.db")
+	return nil
+}
+
+// storeSecret returns the passphrase or keyring key for messages.db. With
+// create set, a missing keyring key is generated and saved.
+func storeSecret(logger *Logger.Logger, mode db.EncryptionMode, create bool) ([]byte, error) {
+	if mode == db.EncryptionPassphrase {
+		return readPassphrase("", dbPassphraseEnv, create)
+	}
+	// The key is per database, so several working directories can coexist.
+	path, err := filepath.Abs(backup.MessagesDB)
+	if err != nil {
+		return nil, err
+	}
+	attrs := map[string]string{"application": "whatsapp-tui", "database": path}
+	key, err := keyring.Get(attrs)
+	switch {
+	case err == nil:
+		return key, nil
+	case !errors.Is(err, keyring.ErrNotFound) || !create:
+		return nil, fmt.Errorf("reading the key from the keyring: %w", err)
+	}
+	key = make([]byte, db.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := keyring.Set("WhatsApp TUI messages ("+path+")", attrs, key); err != nil {
+		return nil, fmt.Errorf("saving the key in the keyring: %w", err)
+	}
+	logger.Info("Saved a new messages.db key in the keyring")
+	return key, nil
+}
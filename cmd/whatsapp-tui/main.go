@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
@@ -12,33 +13,195 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
 	waLog "go.mau.fi/whatsmeow/util/log"
 
 	"github.com/StarGames2025/Logger"
 
+	"DevStarByte/internal/api"
 	"DevStarByte/internal/client"
+	"DevStarByte/internal/config"
+	"DevStarByte/internal/daemon"
 	"DevStarByte/internal/db"
+	"DevStarByte/internal/demo"
+	"DevStarByte/internal/hooks"
+	"DevStarByte/internal/notify"
+	"DevStarByte/internal/record"
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/rules"
+	"DevStarByte/internal/schedule"
 	"DevStarByte/internal/state"
 	"DevStarByte/internal/tui"
+	apptypes "DevStarByte/internal/types"
+	"DevStarByte/internal/wa"
+	"DevStarByte/internal/webhook"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			os.Exit(runReplay(os.Args[2:]))
+		case "daemon":
+			os.Exit(runDaemon(os.Args[2:]))
+		case "send":
+			os.Exit(runSend(os.Args[2:]))
+		case "chats":
+			os.Exit(runChats(os.Args[2:]))
+		case "history":
+			os.Exit(runHistory(os.Args[2:]))
+		case "search":
+			os.Exit(runSearch(os.Args[2:]))
+		case "contacts":
+			os.Exit(runContacts(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "backup":
+			os.Exit(runBackup(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		}
+	}
+
+	demoMode := flag.Bool("demo", false, "run against fixture data and a scripted fake client (no phone or network needed)")
+	recordPath := flag.String("record", "", "record incoming WhatsApp events to this `file` for the replay subcommand")
+	redact := flag.Bool("redact", false, "with --record, replace names, numbers, message text and media keys in the recording")
+	socket := flag.String("socket", rpc.DefaultSocket, "attach to the daemon listening on this `socket` if one is running")
+	httpAddr := flag.String("http", "", "serve the HTTP API on this loopback `address`, e.g. 127.0.0.1:8080")
+	flag.Parse()
+
 	logger, _ := Logger.NewLogger(Logger.DEBUG, "./.log", false)
 	logger.Info("Starting WhatsApp TUI...")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	cfg, err := config.Load(logger, config.DefaultPath)
+	if err != nil {
+		logger.Warning("Config load failed, using defaults: " + err.Error())
+	}
 
+	var (
+		appState *state.AppState
+		store    *db.Store
+		waClient *whatsmeow.Client
+		fake     *wa.Fake
+		demoDir  string
+		chats    []apptypes.ChatItem
+	)
+	if *demoMode {
+		// Fixture data and a scripted fake client: no phone, no network.
+		logger.Info("Starting in demo mode...")
+		fake, store, demoDir, err = demo.Setup(logger, time.Now())
+		if err != nil {
+			logger.Error("Demo setup failed: " + err.Error())
+			os.Exit(state.ExitCodes["DB_INIT_ERROR"])
+		}
+		appState = state.New(fake, store, logger, cfg)
+		appState.Notifier = notify.New(logger, cfg.Notify)
+		appState.Recorder = startRecorder(logger, appState, *recordPath, *redact)
+		appState.Rules = newRules(logger, store, cfg)
+		appState.Scheduler = startScheduler(appState)
+		appState.Client.AddEventHandler(client.NewEventHandler(appState))
+		chats = loadChats(ctx, appState)
+	} else if conn, err := rpc.Dial(*socket); err == nil {
+		// A daemon holds the connection: mirror it and forward actions.
+		logger.Info("Attaching to daemon on " + *socket)
+		if *recordPath != "" {
+			logger.Warning("--record is ignored when attached to a daemon")
+		}
+		defer conn.Close()
+		store, err = db.NewStore(logger)
+		if err != nil {
+			logger.Warning("Message DB init failed: " + err.Error())
+		}
+		// The daemon's store would miss a migration, so leave that to it.
+		if err := unlockStore(logger, store, cfg, false); err != nil {
+			fail(logger, "unlocking messages.db", err)
+			os.Exit(state.ExitCodes["DB_KEY_ERROR"])
+		}
+		appState = state.New(nil, store, logger, cfg)
+		// Shows the notifications the daemon can't show over D-Bus.
+		appState.Notifier = notify.New(logger, cfg.Notify)
+		// The daemon applies the rules; they are here for the editor.
+		appState.Rules = newRules(logger, store, cfg)
+		// Likewise, the daemon sends scheduled messages.
+		appState.Scheduler = schedule.New(logger, store, nil)
+		if chats, err = daemon.Attach(appState, conn); err != nil {
+			logger.Error("Attaching to daemon failed: " + err.Error())
+			fmt.Fprintln(os.Stderr, "attaching to daemon:", err)
+			os.Exit(appState.ExitCodes["DAEMON_ERROR"])
+		}
+		// The TUI ends with the daemon.
+		go func() {
+			<-conn.Done()
+			logger.Warning("Daemon connection closed")
+			cancel()
+		}()
+	} else {
+		var code int
+		appState, waClient, code = connectWhatsApp(ctx, logger, cfg, *recordPath, *redact, true)
+		if appState == nil {
+			os.Exit(code)
+		}
+		store = appState.DB
+		appState.Scheduler = startScheduler(appState)
+		chats = loadChats(ctx, appState)
+	}
+
+	startAPI(ctx, appState, *httpAddr)
+
+	var start func()
+	if fake != nil {
+		start = func() { demo.Play(ctx, fake) }
+	}
+	if err := runTUI(ctx, appState, chats, start); err != nil {
+		logger.Error("TUI error: " + err.Error())
+		os.Exit(appState.ExitCodes["ERROR"])
+	}
+
+	// Let running hooks and scheduled sends finish, replies included.
+	appState.Hooks.Close()
+	appState.Scheduler.Close()
+	if waClient != nil {
+		waClient.Disconnect()
+		logger.Info("WhatsApp client disconnected")
+	}
+	appState.Notifier.Close()
+	appState.Webhooks.Close()
+	if err := appState.Recorder.Close(); err != nil {
+		logger.Warning("Closing recording failed: " + err.Error())
+	}
+	if store != nil {
+		store.Close()
+		logger.Info("Message database closed")
+	}
+	if demoDir != "" {
+		os.RemoveAll(demoDir)
+	}
+	logger.Info("WhatsApp TUI shutdown complete")
+	os.Exit(state.ExitCodes["SUCCESS"])
+}
+
+// connectWhatsApp opens the device and message stores and connects to
+// WhatsApp. Without a session it pairs via QR code if pair is set and fails
+// otherwise; pair also starts the webhooks, hooks and rules and lets a plain
+// messages.db be encrypted, which the scripting subcommands go without. On
+// failure it returns a nil state and the exit code.
+func connectWhatsApp(ctx context.Context, logger *Logger.Logger, cfg *config.Config, recordPath string, redact, pair bool) (*state.AppState, *whatsmeow.Client, int) {
 	// Initialise SQLite-backed device store.
 	logger.Info("Initialising device store...")
-	dbLog := waLog.Stdout("Database", "ERROR", true)
+	dbLog, clientLog := waLog.Stdout("Database", "ERROR", true), waLog.Stdout("Client", "ERROR", true)
+	if !pair {
+		// The scripting subcommands own stdout.
+		dbLog, clientLog = waLog.Noop, waLog.Noop
+	}
 	container, err := sqlstore.New(ctx, "sqlite3", "file:whatsapp.db?_foreign_keys=on", dbLog)
 	if err != nil {
 		logger.Error("DB init failed: " + err.Error())
-		os.Exit(10) // DB_INIT_ERROR
+		return nil, nil, state.ExitCodes["DB_INIT_ERROR"]
 	}
 
 	// Initialise message database.
@@ -46,32 +209,63 @@ func main() {
 	if err != nil {
 		logger.Warning("Message DB init failed: " + err.Error())
 	}
+	if err := unlockStore(logger, store, cfg, pair); err != nil {
+		fail(logger, "unlocking messages.db", err)
+		store.Close()
+		return nil, nil, state.ExitCodes["DB_KEY_ERROR"]
+	}
 
 	deviceStore, err := container.GetFirstDevice(ctx)
 	if err != nil {
 		logger.Error("Device store error: " + err.Error())
-		os.Exit(11) // DEVICE_STORE_ERROR
+		store.Close()
+		return nil, nil, state.ExitCodes["DEVICE_STORE_ERROR"]
 	}
 
 	// Create whatsmeow client.
 	logger.Info("Creating WhatsApp client...")
-	clientLog := waLog.Stdout("Client", "ERROR", true)
 	waClient := whatsmeow.NewClient(deviceStore, clientLog)
 
 	// Create shared application state.
-	appState := state.New(waClient, store, logger)
-
-	waClient.AddEventHandler(client.NewEventHandler(appState))
+	appState := state.New(wa.Wrap(waClient), store, logger, cfg)
+	appState.Notifier = notify.New(logger, cfg.Notify)
+	appState.Recorder = startRecorder(logger, appState, recordPath, redact)
+	appState.Client.AddEventHandler(client.NewEventHandler(appState))
+	// abort undoes the above after a failure.
+	abort := func(code int) (*state.AppState, *whatsmeow.Client, int) {
+		appState.Hooks.Close()
+		waClient.Disconnect()
+		appState.Notifier.Close()
+		appState.Webhooks.Close()
+		appState.Recorder.Close()
+		store.Close()
+		return nil, nil, code
+	}
 
 	// Connect – pair via QR code if not yet registered.
+	if waClient.Store.ID == nil && !pair {
+		logger.Error("Not logged in")
+		return abort(appState.ExitCodes["NOT_LOGGED_IN"])
+	}
+	if pair {
+		// Only the TUI and the daemon react to messages. A one-shot
+		// subcommand would otherwise run them on the backlog it receives.
+		appState.Webhooks = webhook.New(logger, store, cfg.Webhooks)
+		appState.Hooks = hooks.New(logger, cfg.HooksDir, hooks.Options{
+			Timeout:    time.Duration(cfg.HooksTimeout) * time.Second,
+			MaxRunning: cfg.HooksMaxRunning,
+			Reply:      cfg.HooksReply,
+		})
+		appState.Rules = newRules(logger, store, cfg)
+	}
 	if waClient.Store.ID == nil {
 		logger.Info("No existing session, starting QR code pairing...")
 		qrCh, _ := waClient.GetQRChannel(ctx)
 		if err = waClient.Connect(); err != nil {
 			logger.Error("Connect failed: " + err.Error())
-			os.Exit(appState.ExitCodes["ERROR"])
+			return abort(appState.ExitCodes["ERROR"])
 		}
-		fmt.Println("\nScan the QR code below with WhatsApp on your phone:\n")
+		fmt.Print("\nScan the QR code below with WhatsApp on your phone:\n\n")
 		for evt := range qrCh {
 			switch evt.Event {
 			case "code":
@@ -81,20 +275,78 @@ func main() {
 				fmt.Println("\n✓ Logged in successfully!")
 			case "timeout", "error":
 				logger.Error("QR login failed: " + evt.Event)
-				os.Exit(appState.ExitCodes["ERROR"])
+				return abort(appState.ExitCodes["ERROR"])
 			}
 		}
 	} else {
 		logger.Info("Existing session found, reconnecting...")
 		if err = waClient.Connect(); err != nil {
 			logger.Error("Connect failed: " + err.Error())
-			os.Exit(appState.ExitCodes["ERROR"])
+			return abort(appState.ExitCodes["ERROR"])
 		}
 	}
 
 	// Let the connection settle before loading chats.
 	logger.Debug("Waiting for connection to settle...")
 	time.Sleep(2 * time.Second)
+	return appState, waClient, 0
+}
+
+// startRecorder opens the --record file, if one was given. The app runs
+// fine without it, so failures are only logged.
+func startRecorder(logger *Logger.Logger, appState *state.AppState, path string, redact bool) *record.Recorder {
+	if path == "" {
+		return nil
+	}
+	rec, err := record.Create(path, appState.Client, redact, logger)
+	if err != nil {
+		logger.Warning("Recording disabled: " + err.Error())
+		return nil
+	}
+	logger.Info("Recording events to " + path)
+	return rec
+}
+
+// startAPI serves the HTTP API on addr in the background until ctx is done.
+// The token is read from, or created in, api.token.
+func startAPI(ctx context.Context, appState *state.AppState, addr string) {
+	if addr == "" {
+		return
+	}
+	logger := appState.Logger
+	token, err := api.LoadToken(api.DefaultTokenFile)
+	if err != nil {
+		logger.Warning("HTTP API disabled: " + err.Error())
+		return
+	}
+	go func() {
+		if err := api.ListenAndServe(ctx, appState, addr, token); err != nil {
+			logger.Error("HTTP API failed: " + err.Error())
+		}
+	}()
+}
+
+// startScheduler returns the scheduler that sends scheduled messages
+// through appState.
+func startScheduler(appState *state.AppState) *schedule.Scheduler {
+	return schedule.New(appState.Logger, appState.DB, func(chat types.JID, text string) error {
+		return client.SendMessage(appState, chat, text)
+	})
+}
+
+// newRules returns the auto-reply rules engine for the config.
+func newRules(logger *Logger.Logger, store *db.Store, cfg *config.Config) *rules.Engine {
+	return rules.New(logger, store, rules.Options{
+		DryRun:      cfg.RulesDryRun,
+		MinInterval: time.Duration(cfg.RulesMinInterval) * time.Second,
+	})
+}
+
+// loadChats loads the chat list and the persisted messages into the shared
+// state and returns the chats in sidebar order.
+func loadChats(ctx context.Context, appState *state.AppState) []apptypes.ChatItem {
+	logger := appState.Logger
+	store := appState.DB
 
 	chats, err := client.LoadChats(appState, ctx)
 	if err != nil {
@@ -121,31 +373,42 @@ func main() {
 		}
 		appState.MessagesMu.Unlock()
 	}
+	return chats
+}
+
+// runTUI runs the TUI until the user quits, a signal arrives or ctx ends.
+// start, if set, is called just before the TUI takes over.
+func runTUI(ctx context.Context, appState *state.AppState, chats []apptypes.ChatItem, start func()) error {
+	logger := appState.Logger
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
 
 	// Start the bubbletea TUI.
 	logger.Info("Starting TUI...")
 	model := tui.NewModel(appState, chats)
-	prog := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	prog := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithReportFocus())
 
 	go func() {
 		select {
 		case <-sigCh:
-			prog.Quit()
 		case <-ctx.Done():
 		}
+		prog.Quit()
 	}()
 
-	if _, err = prog.Run(); err != nil {
-		logger.Error("TUI error: " + err.Error())
-		os.Exit(appState.ExitCodes["ERROR"])
+	if start != nil {
+		start()
 	}
 
-	waClient.Disconnect()
-	logger.Info("WhatsApp client disconnected")
-	if store != nil {
-		store.Close()
-		logger.Info("Message database closed")
+	final, err := prog.Run()
+	if err != nil {
+		return err
 	}
-	logger.Info("WhatsApp TUI shutdown complete")
-	os.Exit(0)
+	// Persist the draft that was still in the input bar.
+	if fm, ok := final.(tui.Model); ok {
+		fm.SaveDraft()
+	}
+	return nil
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+
+	"DevStarByte/internal/client"
+	"DevStarByte/internal/config"
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/record"
+	"DevStarByte/internal/state"
+	"DevStarByte/internal/wa"
+)
+
+const replayUsage = `Usage: whatsapp-tui replay [flags] FILE
+
+Feeds a recording made with --record through the event handlers into a
+scratch database, then opens the TUI on the result. Nothing is sent over
+the network and the real message database is not touched.
+
+Flags:
+`
+
+// runReplay implements the replay subcommand and returns the exit code.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), replayUsage)
+		fs.PrintDefaults()
+	}
+	speed := fs.Float64("speed", 0, "replay with the recorded timing scaled by this `factor` (1 = real time); 0 applies every event before the TUI starts")
+	printOnly := fs.Bool("print", false, "print the resulting chats and messages instead of opening the TUI")
+	redactTo := fs.String("redact", "", "write a redacted copy of the recording to this `file` and exit")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return state.ExitCodes["USAGE_ERROR"]
+	}
+	path := fs.Arg(0)
+
+	if *redactTo != "" {
+		if err := redactFile(path, *redactTo); err != nil {
+			fmt.Fprintln(os.Stderr, "redact:", err)
+			return state.ExitCodes["RECORDING_ERROR"]
+		}
+		return 0
+	}
+
+	rec, err := record.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "replay:", err)
+		return state.ExitCodes["RECORDING_ERROR"]
+	}
+
+	logger, _ := Logger.NewLogger(Logger.DEBUG, "./.log", false)
+	logger.Info(fmt.Sprintf("Replaying %d events from %s", len(rec.Steps), path))
+
+	// Work in a scratch directory so the real messages.db is never touched.
+	dir, err := os.MkdirTemp("", "whatsapp-tui-replay-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "replay:", err)
+		return state.ExitCodes["DB_INIT_ERROR"]
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintln(os.Stderr, "replay:", err)
+		return state.ExitCodes["DB_INIT_ERROR"]
+	}
+	store, err := db.NewStore(logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "replay:", err)
+		return state.ExitCodes["DB_INIT_ERROR"]
+	}
+	defer store.Close()
+
+	fake := wa.NewFake(rec.Own)
+	fake.LID = rec.OwnLID
+	for jid, c := range rec.Contacts {
+		fake.Contacts[jid] = c
+	}
+	appState := state.New(fake, store, logger, config.Default())
+	appState.Client.AddEventHandler(client.NewEventHandler(appState))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *speed <= 0 || *printOnly {
+		fake.Emit(stepEvents(rec.Steps)...)
+	}
+	if *printOnly {
+		printState(os.Stdout, appState)
+		return 0
+	}
+
+	var start func()
+	if *speed > 0 {
+		start = func() { fake.Play(ctx, record.Scale(rec.Steps, 1 / *speed)) }
+	}
+	if err := runTUI(ctx, appState, loadChats(ctx, appState), start); err != nil {
+		logger.Error("TUI error: " + err.Error())
+		return appState.ExitCodes["ERROR"]
+	}
+	return 0
+}
+
+func stepEvents(steps []wa.Step) []interface{} {
+	evts := make([]interface{}, len(steps))
+	for i, st := range steps {
+		evts[i] = st.Event
+	}
+	return evts
+}
+
+func redactFile(in, out string) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := record.Redact(src, dst); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// printState writes the chats and messages the handlers built, newest chat
+// first, in a stable plain-text form that can be pasted into a bug report.
+func printState(w io.Writer, s *state.AppState) {
+	s.ChatsMu.RLock()
+	chats := make([]string, 0, len(s.ChatsMap))
+	for key := range s.ChatsMap {
+		chats = append(chats, key)
+	}
+	sort.Slice(chats, func(i, j int) bool {
+		a, b := s.ChatsMap[chats[i]], s.ChatsMap[chats[j]]
+		if !a.LastTime.Equal(b.LastTime) {
+			return a.LastTime.After(b.LastTime)
+		}
+		return chats[i] < chats[j]
+	})
+	s.MessagesMu.RLock()
+	defer s.MessagesMu.RUnlock()
+	defer s.ChatsMu.RUnlock()
+
+	for _, key := range chats {
+		c := s.ChatsMap[key]
+		var flags []string
+		if c.Unread > 0 {
+			flags = append(flags, fmt.Sprintf("unread=%d", c.Unread))
+		}
+		if c.Pinned {
+			flags = append(flags, "pinned")
+		}
+		if c.Archived {
+			flags = append(flags, "archived")
+		}
+		if c.Muted() {
+			flags = append(flags, "muted")
+		}
+		fmt.Fprintf(w, "== %s (%s) %s\n", c.Name, key, strings.Join(flags, " "))
+		for _, m := range s.MessagesMap[key] {
+			sender := m.Sender
+			if m.System {
+				sender = "*"
+			}
+			fmt.Fprintf(w, "  %s  %-12s %s: %s\n", m.Timestamp.UTC().Format(time.DateTime), m.ID, sender, m.Content)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Package api serves the shared state over HTTP on localhost, for
+// dashboards and scripts: REST endpoints for chats and messages, a stream of
+// server-sent events, and an OpenAPI description of both (openapi.json).
+//
+// Every endpoint but the description needs the token, as a bearer token or,
+// for EventSource clients that can't set headers, a "token" query parameter.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"DevStarByte/internal/state"
+)
+
+// DefaultTokenFile holds the token when none is configured.
+const DefaultTokenFile = "api.token"
+
+//go:embed openapi.json
+var openAPI []byte
+
+// Server is the HTTP API on top of the shared state.
+type Server struct {
+	s     *state.AppState
+	token string
+	mux   *http.ServeMux
+}
+
+// New returns the API for s, accepting requests that carry token.
+func New(s *state.AppState, token string) *Server {
+	srv := &Server{s: s, token: token, mux: http.NewServeMux()}
+	srv.mux.HandleFunc("GET /api/v1/openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	srv.handle("GET /api/v1/chats", srv.listChats)
+	srv.handle("GET /api/v1/chats/{chat}", srv.getChat)
+	srv.handle("GET /api/v1/chats/{chat}/messages", srv.listMessages)
+	srv.handle("POST /api/v1/chats/{chat}/messages", srv.sendText)
+	srv.handle("POST /api/v1/chats/{chat}/media", srv.sendMedia)
+	srv.handle("POST /api/v1/contacts/{jid}/presence", srv.subscribePresence)
+	srv.handle("GET /api/v1/events", srv.streamEvents)
+	return srv
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+// handle registers h behind the token check.
+func (srv *Server) handle(pattern string, h http.HandlerFunc) {
+	srv.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			got = r.URL.Query().Get("token")
+		}
+		if srv.token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(srv.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="whatsapp-tui"`)
+			writeError(w, http.StatusUnauthorized, "missing or wrong token")
+			return
+		}
+		h(w, r)
+	})
+}
+
+// ListenAndServe serves the API on addr until ctx is done. addr must be a
+// loopback address: the API can read and send every message.
+func ListenAndServe(ctx context.Context, s *state.AppState, addr, token string) error {
+	if err := checkLoopback(addr); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	hs := &http.Server{Handler: New(s, token), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		hs.Shutdown(shutdown)
+	}()
+	s.Logger.Info("API listening on http://" + ln.Addr().String())
+	if err := hs.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%s is not a loopback address; use e.g. 127.0.0.1:8080", addr)
+}
+
+// LoadToken reads the token from path, creating the file with a random
+// token if it doesn't exist.
+func LoadToken(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(b)); token != "" {
+			return token, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ── Responses ─────────────────────────────────────────────────────────────────
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// apiError is the body of every error response.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, apiError{Error: msg})
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"DevStarByte/internal/client"
+	"DevStarByte/internal/db/dbtest"
+	"DevStarByte/internal/state"
+	"DevStarByte/internal/wa"
+)
+
+const token = "secret"
+
+var (
+	me    = types.NewJID("491700000000", types.DefaultUserServer)
+	alice = types.NewJID("491701111111", types.DefaultUserServer)
+	t0    = time.Unix(1_760_000_000, 0)
+)
+
+// newServer serves a fake session with five messages from Alice.
+func newServer(t *testing.T) (*httptest.Server, *wa.Fake) {
+	t.Helper()
+	logger, store := dbtest.New(t)
+	fake := wa.NewFake(me)
+	fake.Now = func() time.Time { return t0.Add(time.Hour) }
+	fake.Contacts[alice] = types.ContactInfo{Found: true, FullName: "Alice Smith"}
+	s := state.New(fake, store, logger, nil)
+	fake.AddEventHandler(client.NewEventHandler(s))
+	for i := range 5 {
+		fake.Emit(wa.TextEvent(alice, alice, "Ali", types.MessageID(fmt.Sprintf("m%d", i)), fmt.Sprintf("hello %d", i), t0.Add(time.Duration(i)*time.Minute)))
+	}
+	ts := httptest.NewServer(New(s, token))
+	t.Cleanup(ts.Close)
+	return ts, fake
+}
+
+func do(t *testing.T, method, url, contentType string, body []byte) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decode(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	ts, _ := newServer(t)
+	for _, url := range []string{"/api/v1/chats", "/api/v1/chats?token=wrong"} {
+		resp, err := http.Get(ts.URL + url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("GET %s = %d, want 401", url, resp.StatusCode)
+		}
+	}
+	resp, err := http.Get(ts.URL + "/api/v1/chats?token=" + token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET with token parameter = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestChats(t *testing.T) {
+	ts, _ := newServer(t)
+	var chats []struct {
+		JID     types.JID `json:"jid"`
+		Name    string    `json:"name"`
+		LastMsg string    `json:"last_msg"`
+	}
+	decode(t, do(t, "GET", ts.URL+"/api/v1/chats", "", nil), &chats)
+	if len(chats) != 1 || chats[0].JID != alice || chats[0].Name != "Alice Smith" || chats[0].LastMsg != "hello 4" {
+		t.Errorf("chats = %+v", chats)
+	}
+	if resp := do(t, "GET", ts.URL+"/api/v1/chats/nobody", "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown chat = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestMessagesPaging(t *testing.T) {
+	ts, _ := newServer(t)
+	url := ts.URL + "/api/v1/chats/" + alice.String() + "/messages?limit=2"
+	var ids []string
+	before := ""
+	for range 5 {
+		var page messagePage
+		decode(t, do(t, "GET", url+"&before="+before, "", nil), &page)
+		for i := len(page.Messages) - 1; i >= 0; i-- {
+			ids = append(ids, page.Messages[i].ID)
+		}
+		if page.NextBefore == "" {
+			break
+		}
+		before = page.NextBefore
+	}
+	if got := strings.Join(ids, ","); got != "m4,m3,m2,m1,m0" {
+		t.Errorf("paged IDs = %s", got)
+	}
+	if resp := do(t, "GET", url+"&before=nope", "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown cursor = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestSendText(t *testing.T) {
+	ts, fake := newServer(t)
+	url := ts.URL + "/api/v1/chats/" + alice.User + "/messages"
+	resp := do(t, "POST", url, "application/json", []byte(`{"text":"hi there"}`))
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", resp.StatusCode)
+	}
+	sent := fake.SentMessages()
+	if len(sent) != 1 || sent[0].To != alice || sent[0].Message.GetConversation() != "hi there" {
+		t.Errorf("sent = %+v", sent)
+	}
+	if resp := do(t, "POST", url, "application/json", []byte(`{"text":"  "}`)); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("empty text = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestSendMedia(t *testing.T) {
+	ts, fake := newServer(t)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "../notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte("some notes"))
+	mw.WriteField("caption", "read this")
+	mw.Close()
+
+	resp := do(t, "POST", ts.URL+"/api/v1/chats/"+alice.String()+"/media", mw.FormDataContentType(), body.Bytes())
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", resp.StatusCode)
+	}
+	sent := fake.SentMessages()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	doc := sent[0].Message.GetDocumentMessage()
+	if doc.GetFileName() != "notes.txt" || doc.GetCaption() != "read this" {
+		t.Errorf("document = %q, caption %q", doc.GetFileName(), doc.GetCaption())
+	}
+	if string(fake.Media[doc.GetDirectPath()]) != "some notes" {
+		t.Errorf("uploaded %q", fake.Media[doc.GetDirectPath()])
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ts, fake := newServer(t)
+	req, _ := http.NewRequest("GET", ts.URL+"/api/v1/events?types=message,presence&token="+token, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	lines.Scan() // ": connected", sent once subscribed
+
+	fake.Emit(
+		wa.TextEvent(alice, alice, "Ali", "m5", "new", t0.Add(time.Hour)),
+		&events.Receipt{}, // filtered out by types
+		&events.ChatPresence{MessageSource: types.MessageSource{Chat: alice, Sender: alice}, State: types.ChatPresenceComposing},
+	)
+
+	var names, got []string
+	for len(got) < 2 && lines.Scan() {
+		if name, ok := strings.CutPrefix(lines.Text(), "event: "); ok {
+			names = append(names, name)
+		} else if data, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+			got = append(got, data)
+		}
+	}
+	if strings.Join(names, ",") != "message,presence" {
+		t.Fatalf("events = %v, want message,presence", names)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	var msg messageEvent
+	if err := json.Unmarshal([]byte(got[0]), &msg); err != nil || msg.Chat != alice || msg.Message.Content != "new" {
+		t.Errorf("message event = %s", got[0])
+	}
+	var p presenceEvent
+	if err := json.Unmarshal([]byte(got[1]), &p); err != nil || p.JID != alice || p.State != state.PresenceTyping {
+		t.Errorf("presence event = %s", got[1])
+	}
+}
+
+func TestOpenAPIListsRoutes(t *testing.T) {
+	ts, _ := newServer(t)
+	resp, err := http.Get(ts.URL + "/api/v1/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	decode(t, resp, &doc)
+	for _, route := range []string{
+		"GET /chats", "GET /chats/{chat}", "GET /chats/{chat}/messages", "POST /chats/{chat}/messages",
+		"POST /chats/{chat}/media", "POST /contacts/{jid}/presence", "GET /events",
+	} {
+		method, path, _ := strings.Cut(route, " ")
+		if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("openapi.json lacks %s", route)
+		}
+	}
+}
+
+func TestCheckLoopback(t *testing.T) {
+	for addr, ok := range map[string]bool{
+		"127.0.0.1:8080": true,
+		"localhost:8080": true,
+		"[::1]:8080":     true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"192.168.1.2:80": false,
+	} {
+		if err := checkLoopback(addr); (err == nil) != ok {
+			t.Errorf("checkLoopback(%q) = %v", addr, err)
+		}
+	}
+}
+
+func TestLoadToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultTokenFile)
+	first, err := LoadToken(path)
+	if err != nil || len(first) != 48 {
+		t.Fatalf("LoadToken = %q, %v", first, err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v", fi.Mode())
+	}
+	if again, _ := LoadToken(path); again != first {
+		t.Errorf("second LoadToken = %q, want %q", again, first)
+	}
+}
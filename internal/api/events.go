@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// keepAlive is how often an idle stream gets a comment line, so proxies and
+// clients don't time it out.
+const keepAlive = 25 * time.Second
+
+// Event names on the stream.
+const (
+	eventMessage  = "message"
+	eventReceipt  = "receipt"
+	eventPresence = "presence"
+)
+
+var eventNames = []string{eventMessage, eventReceipt, eventPresence}
+
+// messageEvent is a new message in Chat.
+type messageEvent struct {
+	Chat    types.JID        `json:"chat"`
+	Message apptypes.Message `json:"message"`
+}
+
+// receiptEvent reports that messages I sent reached Status.
+type receiptEvent struct {
+	Chat       types.JID              `json:"chat"`
+	MessageIDs []string               `json:"message_ids"`
+	Status     apptypes.MessageStatus `json:"status"`
+	Timestamp  time.Time              `json:"timestamp,omitzero"`
+}
+
+// presenceEvent reports a contact's online status, or typing in Chat.
+type presenceEvent struct {
+	Chat     types.JID `json:"chat,omitzero"`
+	JID      types.JID `json:"jid"`
+	State    string    `json:"state"`
+	LastSeen time.Time `json:"last_seen,omitzero"`
+}
+
+// sseEvent converts a bus event for the stream.
+func sseEvent(evt state.Event) (string, interface{}, bool) {
+	switch e := evt.(type) {
+	case state.MessageAdded:
+		return eventMessage, messageEvent{Chat: e.Chat, Message: e.Message}, true
+	case state.Receipt:
+		return eventReceipt, receiptEvent{Chat: e.Chat, MessageIDs: e.MessageIDs, Status: e.Status, Timestamp: e.Timestamp}, true
+	case state.Presence:
+		return eventPresence, presenceEvent{Chat: e.Chat, JID: e.JID, State: e.State, LastSeen: e.LastSeen}, true
+	}
+	return "", nil, false
+}
+
+// streamEvents sends events as server-sent events until the client goes
+// away. The "types" parameter, a comma-separated list, selects which.
+func (srv *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	want := eventNames
+	if v := r.URL.Query().Get("types"); v != "" {
+		want = strings.Split(v, ",")
+		for _, name := range want {
+			if !slices.Contains(eventNames, name) {
+				writeError(w, http.StatusBadRequest, "unknown event type "+name)
+				return
+			}
+		}
+	}
+	rc := http.NewResponseController(w)
+
+	sub := srv.s.Events.Subscribe()
+	defer sub.Close()
+	evts := make(chan []state.Event)
+	go func() {
+		defer close(evts)
+		for {
+			batch, ok := sub.Next(0)
+			if !ok {
+				return
+			}
+			select {
+			case evts <- batch:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case batch, ok := <-evts:
+			if !ok {
+				return
+			}
+			for _, evt := range batch {
+				name, data, ok := sseEvent(evt)
+				if !ok || !slices.Contains(want, name) {
+					continue
+				}
+				b, err := json.Marshal(data)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b)
+			}
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/client"
+	apptypes "DevStarByte/internal/types"
+)
+
+// Page sizes for GET /chats/{chat}/messages.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// maxUpload caps media uploads, in line with WhatsApp's own limit.
+const maxUpload = 100 << 20
+
+// ── Chats ─────────────────────────────────────────────────────────────────────
+
+func (srv *Server) listChats(w http.ResponseWriter, r *http.Request) {
+	s := srv.s
+	archived := r.URL.Query().Get("archived") // "true", "false" or "" for all
+	if archived != "" && archived != "true" && archived != "false" {
+		writeError(w, http.StatusBadRequest, "archived must be true or false")
+		return
+	}
+	s.ChatsMu.RLock()
+	chats := make([]apptypes.ChatItem, 0, len(s.ChatsMap))
+	for _, c := range s.ChatsMap {
+		if archived == "" || c.Archived == (archived == "true") {
+			chats = append(chats, *c)
+		}
+	}
+	s.ChatsMu.RUnlock()
+	client.SortChats(chats)
+	writeJSON(w, http.StatusOK, chats)
+}
+
+func (srv *Server) getChat(w http.ResponseWriter, r *http.Request) {
+	jid, ok := srv.chat(w, r)
+	if !ok {
+		return
+	}
+	s := srv.s
+	s.ChatsMu.RLock()
+	c, found := s.ChatsMap[jid.String()]
+	var chat apptypes.ChatItem
+	if found {
+		chat = *c
+	}
+	s.ChatsMu.RUnlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "unknown chat "+jid.String())
+		return
+	}
+	writeJSON(w, http.StatusOK, chat)
+}
+
+// chat resolves the {chat} path value: a JID, a phone number or a chat name.
+func (srv *Server) chat(w http.ResponseWriter, r *http.Request) (types.JID, bool) {
+	jid, err := client.ResolveJID(srv.s, r.PathValue("chat"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return jid, false
+	}
+	return jid, true
+}
+
+// ── Messages ──────────────────────────────────────────────────────────────────
+
+// messagePage is one page of a chat's history, oldest first. NextBefore
+// fetches the page before it and is empty on the first page of the chat.
+type messagePage struct {
+	Messages   []apptypes.Message `json:"messages"`
+	NextBefore string             `json:"next_before,omitempty"`
+}
+
+// listMessages pages backwards through a chat: the newest limit messages,
+// or those before the message with ID before.
+func (srv *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	jid, ok := srv.chat(w, r)
+	if !ok {
+		return
+	}
+	limit := defaultPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+	before := r.URL.Query().Get("before")
+
+	s := srv.s
+	s.MessagesMu.RLock()
+	msgs := s.MessagesMap[jid.String()]
+	end := len(msgs)
+	if before != "" {
+		end = -1
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].ID == before {
+				end = i
+				break
+			}
+		}
+	}
+	var page messagePage
+	if end >= 0 {
+		start := max(0, end-limit)
+		page.Messages = append([]apptypes.Message{}, msgs[start:end]...)
+		if start > 0 {
+			page.NextBefore = msgs[start].ID
+		}
+	}
+	s.MessagesMu.RUnlock()
+	if end < 0 {
+		writeError(w, http.StatusNotFound, "unknown message "+before)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// textMessage is the body of POST /chats/{chat}/messages.
+type textMessage struct {
+	Text     string      `json:"text"`
+	Mentions []types.JID `json:"mentions,omitempty"`
+}
+
+func (srv *Server) sendText(w http.ResponseWriter, r *http.Request) {
+	jid, ok := srv.chat(w, r)
+	if !ok {
+		return
+	}
+	var body textMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	if strings.TrimSpace(body.Text) == "" {
+		writeError(w, http.StatusBadRequest, "text is empty")
+		return
+	}
+	if err := client.SendMessage(srv.s, jid, body.Text, body.Mentions...); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sendMedia sends the "file" part of a multipart form, with the optional
+// "caption" field.
+func (srv *Server) sendMedia(w http.ResponseWriter, r *http.Request) {
+	jid, ok := srv.chat(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+	f, hdr, err := r.FormFile("file")
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			writeError(w, http.StatusRequestEntityTooLarge, "file is larger than 100 MB")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "missing file: "+err.Error())
+		return
+	}
+	defer f.Close()
+
+	// SendFile takes a path and names documents after it, so the upload is
+	// saved under its own name.
+	dir, err := os.MkdirTemp("", "whatsapp-tui-upload-")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Base(filepath.Clean("/" + hdr.Filename))
+	if name == "/" || name == "." {
+		name = "file"
+	}
+	path := filepath.Join(dir, name)
+	out, err := os.Create(path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	_, err = io.Copy(out, f)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := client.SendFile(srv.s, jid, path, r.FormValue("caption")); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ── Presence ──────────────────────────────────────────────────────────────────
+
+func (srv *Server) subscribePresence(w http.ResponseWriter, r *http.Request) {
+	jid, err := types.ParseJID(r.PathValue("jid"))
+	if err != nil || jid.User == "" {
+		writeError(w, http.StatusBadRequest, "invalid JID "+r.PathValue("jid"))
+		return
+	}
+	if err := client.SubscribePresence(srv.s, jid); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
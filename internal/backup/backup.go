@@ -0,0 +1,338 @@
+// Package backup writes messages.db, media_cache/ and optionally the
+// WhatsApp session to one archive encrypted with a passphrase, and restores
+// it after checking every file against the archive's manifest.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"DevStarByte/internal/db"
+)
+
+// The files a backup holds, relative to the working directory.
+const (
+	MessagesDB = "messages.db"
+	SessionDB  = "whatsapp.db" // with Options.Session only
+	MediaDir   = "media_cache"
+)
+
+// manifestName is the archive's last entry.
+const manifestName = "manifest.json"
+
+// Manifest lists what an archive holds.
+type Manifest struct {
+	Created time.Time `json:"created"`
+	Session bool      `json:"session"`
+	Files   []File    `json:"files"`
+}
+
+// File is a file in the archive, with its path relative to the working
+// directory.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Size is the total size of the files.
+func (m Manifest) Size() int64 {
+	var n int64
+	for _, f := range m.Files {
+		n += f.Size
+	}
+	return n
+}
+
+// Options control a backup.
+type Options struct {
+	Passphrase []byte
+	Session    bool // include whatsapp.db, so restoring needs no pairing
+}
+
+// Create writes a backup of the files in dir to w. The databases are
+// snapshots taken with SQLite's backup API, so the app may keep running.
+func Create(w io.Writer, dir string, opts Options) (Manifest, error) {
+	man := Manifest{Created: time.Now().UTC().Truncate(time.Second), Session: opts.Session}
+	if len(opts.Passphrase) == 0 {
+		return man, errors.New("empty passphrase")
+	}
+	tmp, err := os.MkdirTemp(dir, ".backup-")
+	if err != nil {
+		return man, err
+	}
+	defer os.RemoveAll(tmp)
+	dbs := []string{MessagesDB}
+	if opts.Session {
+		dbs = append(dbs, SessionDB)
+	}
+	for _, name := range dbs {
+		if err := db.Snapshot(filepath.Join(dir, name), filepath.Join(tmp, name)); err != nil {
+			return man, fmt.Errorf("snapshot of %s: %w", name, err)
+		}
+	}
+
+	enc, err := newSealer(w, opts.Passphrase)
+	if err != nil {
+		return man, err
+	}
+	zw := gzip.NewWriter(enc)
+	tw := tar.NewWriter(zw)
+	for _, name := range dbs {
+		if err := addFile(tw, &man, name, filepath.Join(tmp, name)); err != nil {
+			return man, err
+		}
+	}
+	err = filepath.WalkDir(filepath.Join(dir, MediaDir), func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		return addFile(tw, &man, filepath.ToSlash(rel), p)
+	})
+	if err != nil {
+		return man, err
+	}
+
+	data, err := json.MarshalIndent(man, "", "  ")
+	if err != nil {
+		return man, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0o600, Size: int64(len(data)), ModTime: man.Created}); err != nil {
+		return man, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return man, err
+	}
+	for _, c := range []io.Closer{tw, zw, enc} {
+		if err := c.Close(); err != nil {
+			return man, err
+		}
+	}
+	return man, nil
+}
+
+// addFile adds the file at src to the archive as name and to the manifest.
+func addFile(tw *tar.Writer, man *Manifest, name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tw, h), f); err != nil {
+		return err
+	}
+	man.Files = append(man.Files, File{Name: name, Size: info.Size(), SHA256: hex.EncodeToString(h.Sum(nil))})
+	return nil
+}
+
+// Verify reads a whole archive and checks it without writing anything.
+func Verify(r io.Reader, passphrase []byte) (Manifest, error) {
+	return extract(r, passphrase, "")
+}
+
+// Restore checks an archive and then puts its files into dir. Files it
+// replaces are moved to a pre-restore-<date>-<time> directory in dir,
+// whose path is returned ("" if nothing was replaced). Nothing in dir
+// changes if the archive doesn't check out, and moves already made are
+// undone if one fails.
+func Restore(r io.Reader, passphrase []byte, dir string) (Manifest, string, error) {
+	staging, err := os.MkdirTemp(dir, ".restore-")
+	if err != nil {
+		return Manifest{}, "", err
+	}
+	defer os.RemoveAll(staging)
+	man, err := extract(r, passphrase, staging)
+	if err != nil {
+		return man, "", err
+	}
+
+	replace := []string{MessagesDB, MediaDir}
+	if man.Session {
+		replace = append(replace, SessionDB)
+	}
+	aside := filepath.Join(dir, "pre-restore-"+time.Now().Format("20060102-150405"))
+	var moves [][2]string // from, to
+	rename := func(from, to string) error {
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+		moves = append(moves, [2]string{from, to})
+		return nil
+	}
+	if err := swap(dir, staging, aside, replace, rename); err != nil {
+		var undoErrs []error
+		for i := len(moves) - 1; i >= 0; i-- {
+			if uerr := os.Rename(moves[i][1], moves[i][0]); uerr != nil {
+				undoErrs = append(undoErrs, uerr)
+			}
+		}
+		if uerr := errors.Join(undoErrs...); uerr != nil {
+			return man, aside, fmt.Errorf("%w; undoing the restore failed too (%v), the replaced files are in %s", err, uerr, aside)
+		}
+		os.Remove(aside) // only if empty
+		return man, "", err
+	}
+	if !slices.ContainsFunc(moves, func(m [2]string) bool { return filepath.Dir(m[1]) == aside }) {
+		aside = ""
+	}
+	return man, aside, nil
+}
+
+// swap moves the files named in replace from dir to aside and their
+// restored copies from staging to dir.
+func swap(dir, staging, aside string, replace []string, rename func(from, to string) error) error {
+	for _, name := range replace {
+		// A stale WAL would be applied to the restored database.
+		for _, old := range []string{name, name + "-wal", name + "-shm"} {
+			if _, err := os.Lstat(filepath.Join(dir, old)); err != nil {
+				continue
+			}
+			if err := os.MkdirAll(aside, 0o700); err != nil {
+				return err
+			}
+			if err := rename(filepath.Join(dir, old), filepath.Join(aside, old)); err != nil {
+				return err
+			}
+		}
+		src := filepath.Join(staging, name)
+		if _, err := os.Lstat(src); errors.Is(err, fs.ErrNotExist) && name == MediaDir {
+			continue // no cached media
+		}
+		if err := rename(src, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extract decrypts and unpacks an archive into dir, or only reads it if
+// dir is "", and checks the files against the manifest.
+func extract(r io.Reader, passphrase []byte, dir string) (Manifest, error) {
+	var man Manifest
+	dec, err := newOpener(r, passphrase)
+	if err != nil {
+		return man, err
+	}
+	zr, err := gzip.NewReader(dec)
+	if err != nil {
+		return man, readErr(err)
+	}
+	tr := tar.NewReader(zr)
+	var got []File
+	haveManifest := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return man, readErr(err)
+		}
+		if hdr.Name == manifestName {
+			if err := json.NewDecoder(io.LimitReader(tr, 64<<20)).Decode(&man); err != nil {
+				return man, readErr(err)
+			}
+			haveManifest = true
+			continue
+		}
+		if !validName(hdr.Name) || hdr.Typeflag != tar.TypeReg {
+			return man, fmt.Errorf("unexpected entry %q in the archive", hdr.Name)
+		}
+		f, err := extractFile(tr, hdr.Name, dir)
+		if err != nil {
+			return man, readErr(err)
+		}
+		got = append(got, f)
+	}
+	if !haveManifest {
+		return man, errors.New("the archive has no manifest")
+	}
+	if !slices.Equal(got, man.Files) {
+		return man, errors.New("the archive's files don't match its manifest")
+	}
+	if len(got) == 0 || got[0].Name != MessagesDB {
+		return man, errors.New("the archive has no " + MessagesDB)
+	}
+	return man, nil
+}
+
+// extractFile writes one file below dir, or discards it if dir is "", and
+// returns its size and checksum.
+func extractFile(r io.Reader, name, dir string) (File, error) {
+	w := io.Discard
+	if dir != "" {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return File{}, err
+		}
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return File{}, err
+		}
+		defer f.Close()
+		w = f
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return File{}, err
+	}
+	if c, ok := w.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return File{}, err
+		}
+	}
+	return File{Name: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// validName reports whether an archive entry is one a backup can hold, so
+// a crafted archive can't write elsewhere.
+func validName(name string) bool {
+	if name != path.Clean(name) || path.IsAbs(name) || strings.Contains(name, `\`) {
+		return false
+	}
+	if name == MessagesDB || name == SessionDB {
+		return true
+	}
+	rest, ok := strings.CutPrefix(name, MediaDir+"/")
+	return ok && rest != "" && !strings.HasPrefix(rest, "../") && !slices.Contains(strings.Split(rest, "/"), "..")
+}
+
+// readErr keeps ErrDecrypt recognisable through the gzip and tar readers.
+func readErr(err error) error {
+	if errors.Is(err, ErrDecrypt) {
+		return ErrDecrypt
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, gzip.ErrChecksum) || errors.Is(err, gzip.ErrHeader) {
+		return fmt.Errorf("the archive is damaged: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/db/dbtest"
+	"DevStarByte/internal/types"
+)
+
+var pass = []byte("correct horse battery staple")
+
+// newDir returns a working directory with messages.db holding one message,
+// a session database and two cached files, one larger than a chunk.
+func newDir(t *testing.T) (string, *db.Store) {
+	t.Helper()
+	_, store := dbtest.New(t)
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.PersistMessage("a@s.whatsapp.net", types.Message{ID: "1", Content: "before", Timestamp: time.Unix(1_760_000_000, 0)})
+
+	session, err := sql.Open("sqlite3", "file:"+SessionDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Exec(`CREATE TABLE device (jid TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	session.Close()
+
+	big := make([]byte, 3*chunkSize/2)
+	rand.Read(big)
+	os.WriteFile(filepath.Join(MediaDir, "big.jpg"), big, 0o644)
+	os.WriteFile(filepath.Join(MediaDir, "small.jpg"), []byte("jpeg"), 0o644)
+	return dir, store
+}
+
+func create(t *testing.T, dir string, opts Options) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := Create(&buf, dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	dir, store := newDir(t)
+	archive := create(t, dir, Options{Passphrase: pass})
+
+	man, err := Verify(bytes.NewReader(archive), pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(man.Files) != 3 || man.Files[0].Name != MessagesDB || man.Session {
+		t.Errorf("manifest = %+v", man)
+	}
+
+	// Changes after the backup are undone by restoring it.
+	store.PersistMessage("a@s.whatsapp.net", types.Message{ID: "2", Content: "after", Timestamp: time.Unix(1_760_000_100, 0)})
+	store.Close()
+	os.Remove(filepath.Join(MediaDir, "small.jpg"))
+	man, aside, err := Restore(bytes.NewReader(archive), pass, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aside == "" {
+		t.Fatal("replaced files weren't kept")
+	}
+	if _, err := os.Stat(filepath.Join(aside, MessagesDB)); err != nil {
+		t.Errorf("old messages.db: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SessionDB)); err != nil {
+		t.Errorf("session not in the backup was touched: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(MediaDir, "small.jpg")); string(data) != "jpeg" {
+		t.Errorf("small.jpg = %q, %v", data, err)
+	}
+
+	logger, _ := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	restored, err := db.NewStore(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	if msgs := restored.LoadMessages("a@s.whatsapp.net", 10); len(msgs) != 1 || msgs[0].Content != "before" {
+		t.Errorf("restored messages = %+v", msgs)
+	}
+}
+
+func TestBackupSession(t *testing.T) {
+	dir, _ := newDir(t)
+	archive := create(t, dir, Options{Passphrase: pass, Session: true})
+	man, err := Verify(bytes.NewReader(archive), pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !man.Session || man.Files[1].Name != SessionDB {
+		t.Errorf("manifest = %+v", man)
+	}
+}
+
+func TestRestoreRejectsBadArchives(t *testing.T) {
+	dir, _ := newDir(t)
+	archive := create(t, dir, Options{Passphrase: pass})
+
+	tampered := bytes.Clone(archive)
+	tampered[len(tampered)/2] ^= 1
+	// Cut after the first chunk, which then isn't the last one as sealed.
+	truncated := archive[:headerSize+chunkSize+16]
+	// withKDF returns the archive with its header asking for another cost.
+	withKDF := func(t, mem uint32) []byte {
+		b := bytes.Clone(archive)
+		binary.BigEndian.PutUint32(b[9:], t)
+		binary.BigEndian.PutUint32(b[13:], mem)
+		return b
+	}
+
+	for name, c := range map[string]struct {
+		data []byte
+		pass string
+		want error
+	}{
+		"wrong passphrase":  {archive, "wrong", ErrDecrypt},
+		"tampered":          {tampered, string(pass), ErrDecrypt},
+		"truncated":         {truncated, string(pass), ErrDecrypt},
+		"not a backup":      {[]byte("SQLite format 3\x00 and more"), string(pass), ErrNotBackup},
+		"no KDF time":       {withKDF(0, kdfMemory), string(pass), ErrNotBackup},
+		"huge KDF time":     {withKDF(1<<31, kdfMemory), string(pass), ErrNotBackup},
+		"too little memory": {withKDF(kdfTime, 8), string(pass), ErrNotBackup},
+	} {
+		_, _, err := Restore(bytes.NewReader(c.data), []byte(c.pass), dir)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, c.want)
+		}
+	}
+	// Nothing was replaced.
+	if entries, _ := filepath.Glob(filepath.Join(dir, "pre-restore-*")); len(entries) != 0 {
+		t.Errorf("files moved aside: %v", entries)
+	}
+}
+
+func TestRestoreUndoesPartialSwap(t *testing.T) {
+	dir, store := newDir(t)
+	archive := create(t, dir, Options{Passphrase: pass})
+	store.PersistMessage("a@s.whatsapp.net", types.Message{ID: "2", Content: "after", Timestamp: time.Unix(1_760_000_100, 0)})
+	store.Close()
+	old, err := os.ReadFile(filepath.Join(dir, MessagesDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// media_cache can't be moved aside: messages.db is swapped in by then.
+	now := time.Now()
+	for _, ts := range []time.Time{now, now.Add(time.Second), now.Add(2 * time.Second)} {
+		blocker := filepath.Join(dir, "pre-restore-"+ts.Format("20060102-150405"), MediaDir)
+		os.MkdirAll(blocker, 0o700)
+		os.WriteFile(filepath.Join(blocker, "x"), nil, 0o600)
+	}
+
+	if _, aside, err := Restore(bytes.NewReader(archive), pass, dir); err == nil || aside != "" {
+		t.Fatalf("Restore = %q, %v; want an error", aside, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, MessagesDB)); !bytes.Equal(data, old) {
+		t.Error("messages.db wasn't put back")
+	}
+	if _, err := os.Stat(filepath.Join(MediaDir, "small.jpg")); err != nil {
+		t.Errorf("media_cache: %v", err)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"messages.db":           true,
+		"whatsapp.db":           true,
+		"media_cache/ab.jpg":    true,
+		"media_cache/sub/x.png": true,
+		"media_cache/":          false,
+		"media_cache/../x":      false,
+		"../messages.db":        false,
+		"/etc/passwd":           false,
+		".log":                  false,
+	} {
+		if got := validName(name); got != want {
+			t.Errorf("validName(%q) = %v", name, got)
+		}
+	}
+}
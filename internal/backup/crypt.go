@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ── Encryption ────────────────────────────────────────────────────────────────
+
+// An archive is a header followed by the payload in chunks of chunkSize
+// bytes, each sealed with AES-256-GCM. The key comes from the passphrase
+// with Argon2id. A chunk's nonce is the header's nonce prefix and the
+// chunk's number; the header and whether the chunk is the last one are
+// authenticated with it, so chunks can't be reordered, dropped or cut off.
+//
+//	magic (8) | version (1) | Argon2 time (4) | memory KiB (4) | threads (1) | salt (16) | nonce prefix (8)
+
+const (
+	magic      = "WATUIBAK"
+	version    = 1
+	headerSize = 8 + 1 + 4 + 4 + 1 + 16 + 8
+	chunkSize  = 64 << 10
+)
+
+// Argon2id cost of new archives; restoring reads it from the header.
+var (
+	kdfTime    uint32 = 3
+	kdfMemory  uint32 = 64 << 10 // KiB
+	kdfThreads uint8  = 4
+)
+
+// Limits on the Argon2id cost an archive's header may ask for, so a
+// crafted header can't crash a restore or keep it busy for hours.
+const (
+	maxKDFTime   = 16
+	maxKDFMemory = 4 << 20 // KiB, 4 GiB
+)
+
+var (
+	// ErrNotBackup means the file isn't a backup archive.
+	ErrNotBackup = errors.New("not a backup archive")
+	// ErrDecrypt means the passphrase is wrong or the archive was changed
+	// or damaged.
+	ErrDecrypt = errors.New("wrong passphrase, or the archive is damaged")
+)
+
+// sealer encrypts the payload into w.
+type sealer struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	buf    []byte
+	n      uint32
+}
+
+func newSealer(w io.Writer, passphrase []byte) (*sealer, error) {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	header[8] = version
+	binary.BigEndian.PutUint32(header[9:], kdfTime)
+	binary.BigEndian.PutUint32(header[13:], kdfMemory)
+	header[17] = kdfThreads
+	if _, err := rand.Read(header[18:]); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &sealer{w: w, aead: aead, header: header}, nil
+}
+
+func newAEAD(passphrase, header []byte) (cipher.AEAD, error) {
+	t := binary.BigEndian.Uint32(header[9:])
+	mem := binary.BigEndian.Uint32(header[13:])
+	key := argon2.IDKey(passphrase, header[18:34], t, mem, header[17], 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *sealer) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	// Keep the last chunk back: only Close knows it is the last.
+	for len(s.buf) > chunkSize {
+		if err := s.seal(s.buf[:chunkSize], false); err != nil {
+			return 0, err
+		}
+		s.buf = s.buf[chunkSize:]
+	}
+	return len(p), nil
+}
+
+// Close seals the last chunk, which may be empty.
+func (s *sealer) Close() error {
+	return s.seal(s.buf, true)
+}
+
+func (s *sealer) seal(chunk []byte, last bool) error {
+	out := s.aead.Seal(nil, nonce(s.header, s.n), chunk, chunkAD(s.header, last))
+	s.n++
+	_, err := s.w.Write(out)
+	return err
+}
+
+func nonce(header []byte, n uint32) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, header[34:42])
+	binary.BigEndian.PutUint32(nonce[8:], n)
+	return nonce
+}
+
+func chunkAD(header []byte, last bool) []byte {
+	ad := append([]byte(nil), header...)
+	if last {
+		return append(ad, 1)
+	}
+	return append(ad, 0)
+}
+
+// opener decrypts an archive. Read fails with ErrDecrypt on the first chunk
+// that doesn't authenticate.
+type opener struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	plain  bytes.Reader
+	n      uint32
+	done   bool
+}
+
+func newOpener(r io.Reader, passphrase []byte) (*opener, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:8]) != magic {
+		return nil, ErrNotBackup
+	}
+	if header[8] != version {
+		return nil, errors.New("the archive is from a newer version")
+	}
+	t, mem, threads := binary.BigEndian.Uint32(header[9:]), binary.BigEndian.Uint32(header[13:]), uint32(header[17])
+	if t < 1 || t > maxKDFTime || threads == 0 || mem < 8*threads || mem > maxKDFMemory {
+		return nil, ErrNotBackup
+	}
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+	return &opener{r: bufio.NewReaderSize(r, chunkSize+64), aead: aead, header: header}, nil
+}
+
+func (o *opener) Read(p []byte) (int, error) {
+	for o.plain.Len() == 0 {
+		if o.done {
+			return 0, io.EOF
+		}
+		if err := o.next(); err != nil {
+			return 0, err
+		}
+	}
+	return o.plain.Read(p)
+}
+
+// next decrypts the next chunk. It is the last one if the archive ends
+// after it.
+func (o *opener) next() error {
+	chunk := make([]byte, chunkSize+o.aead.Overhead())
+	n, err := io.ReadFull(o.r, chunk)
+	switch {
+	case err == io.ErrUnexpectedEOF || err == io.EOF:
+		o.done = true
+	case err != nil:
+		return err
+	default:
+		if _, err := o.r.Peek(1); err == io.EOF {
+			o.done = true
+		}
+	}
+	plain, err := o.aead.Open(nil, nonce(o.header, o.n), chunk[:n], chunkAD(o.header, o.done))
+	if err != nil {
+		return ErrDecrypt
+	}
+	o.n++
+	o.plain.Reset(plain)
+	return nil
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Chat settings (pin / archive / mute) ──────────────────────────────────────
+
+// MuteForever is the duration passed to SetMuted to mute without end.
+const MuteForever = time.Duration(-1)
+
+// SortChats orders the chat list: pinned chats on top, archived chats last,
+// most recent message first within each section, then by name.
+func SortChats(chats []apptypes.ChatItem) {
+	rank := func(c apptypes.ChatItem) int {
+		switch {
+		case c.Archived:
+			return 2
+		case c.Pinned:
+			return 0
+		}
+		return 1
+	}
+	sort.SliceStable(chats, func(i, j int) bool {
+		if ri, rj := rank(chats[i]), rank(chats[j]); ri != rj {
+			return ri < rj
+		}
+		if !chats[i].LastTime.Equal(chats[j].LastTime) {
+			return chats[i].LastTime.After(chats[j].LastTime)
+		}
+		return chats[i].Name < chats[j].Name
+	})
+}
+
+func handlePin(s *state.AppState, evt *events.Pin) {
+	updateChatSettings(s, evt.JID, func(c *apptypes.ChatItem) {
+		c.Pinned = evt.Action.GetPinned()
+	})
+}
+
+func handleArchive(s *state.AppState, evt *events.Archive) {
+	updateChatSettings(s, evt.JID, func(c *apptypes.ChatItem) {
+		c.Archived = evt.Action.GetArchived()
+	})
+}
+
+func handleMute(s *state.AppState, evt *events.Mute) {
+	var until time.Time
+	if evt.Action.GetMuted() {
+		if end := evt.Action.GetMuteEndTimestamp(); end < 0 {
+			until = store.MutedForever
+		} else {
+			until = time.UnixMilli(end)
+		}
+	}
+	updateChatSettings(s, evt.JID, func(c *apptypes.ChatItem) {
+		c.MutedUntil = until
+	})
+}
+
+// updateChatSettings applies a settings change to a chat, persists it and
+// publishes the updated chat.
+func updateChatSettings(s *state.AppState, jid types.JID, apply func(c *apptypes.ChatItem)) {
+	key := jid.String()
+	s.ChatsMu.Lock()
+	chat, ok := s.ChatsMap[key]
+	if !ok {
+		chat = &apptypes.ChatItem{JID: jid, Name: jid.User, IsGroup: jid.Server == types.GroupServer}
+		s.ChatsMap[key] = chat
+	}
+	apply(chat)
+	c := *chat
+	s.ChatsMu.Unlock()
+
+	s.Logger.Debug("Chat settings for " + key + ": pinned=" + strconv.FormatBool(c.Pinned) +
+		" archived=" + strconv.FormatBool(c.Archived) + " muted=" + strconv.FormatBool(c.Muted()))
+	s.DB.SaveChatSettings(key, c.Pinned, c.Archived, c.MutedUntil)
+	s.Events.Publish(state.ChatUpdated{Chat: c})
+}
+
+// seedChatSettings copies the pin / archive / mute state whatsmeow keeps from
+// earlier app state syncs into a chat. Chats synced before this was tracked
+// would otherwise never get their settings.
+func seedChatSettings(s *state.AppState, ctx context.Context, c *apptypes.ChatItem) {
+	settings, err := s.Client.GetChatSettings(ctx, c.JID)
+	if err != nil || !settings.Found {
+		return
+	}
+	c.Pinned = settings.Pinned
+	c.Archived = settings.Archived
+	c.MutedUntil = settings.MutedUntil
+	s.DB.SaveChatSettings(c.JID.String(), c.Pinned, c.Archived, c.MutedUntil)
+}
+
+// SetPinned pins or unpins a chat on all devices.
+func SetPinned(s *state.AppState, jid types.JID, pinned bool) error {
+	if ok, err := forward(s, rpc.MethodChatsPin, rpc.FlagParams{Chat: jid, On: pinned}, nil); ok {
+		return err
+	}
+	s.Logger.Info("Setting pinned=" + strconv.FormatBool(pinned) + " for " + jid.String())
+	if err := s.Client.SendAppState(context.Background(), appstate.BuildPin(jid, pinned)); err != nil {
+		s.Logger.Error("Failed to pin chat: " + err.Error())
+		return err
+	}
+	updateChatSettings(s, jid, func(c *apptypes.ChatItem) { c.Pinned = pinned })
+	return nil
+}
+
+// SetArchived archives or unarchives a chat on all devices. Archiving also
+// unpins the chat, as on the phone.
+func SetArchived(s *state.AppState, jid types.JID, archived bool) error {
+	if ok, err := forward(s, rpc.MethodChatsArchive, rpc.FlagParams{Chat: jid, On: archived}, nil); ok {
+		return err
+	}
+	s.Logger.Info("Setting archived=" + strconv.FormatBool(archived) + " for " + jid.String())
+	lastTime, lastKey := lastMessageKey(s, jid)
+	patch := appstate.BuildArchive(jid, archived, lastTime, lastKey)
+	if err := s.Client.SendAppState(context.Background(), patch); err != nil {
+		s.Logger.Error("Failed to archive chat: " + err.Error())
+		return err
+	}
+	updateChatSettings(s, jid, func(c *apptypes.ChatItem) {
+		c.Archived = archived
+		if archived {
+			c.Pinned = false
+		}
+	})
+	return nil
+}
+
+// SetMuted mutes a chat for the given duration on all devices. A duration of
+// MuteForever mutes without end; zero unmutes.
+func SetMuted(s *state.AppState, jid types.JID, d time.Duration) error {
+	if ok, err := forward(s, rpc.MethodChatsMute, rpc.MuteParams{Chat: jid, Seconds: muteSeconds(d)}, nil); ok {
+		return err
+	}
+	s.Logger.Info("Setting mute " + d.String() + " for " + jid.String())
+	var until time.Time
+	var patch appstate.PatchInfo
+	switch {
+	case d == 0:
+		patch = appstate.BuildMute(jid, false, 0)
+	case d < 0:
+		until = store.MutedForever
+		patch = appstate.BuildMuteAbs(jid, true, nil)
+	default:
+		until = time.Now().Add(d)
+		patch = appstate.BuildMuteAbs(jid, true, proto.Int64(until.UnixMilli()))
+	}
+	if err := s.Client.SendAppState(context.Background(), patch); err != nil {
+		s.Logger.Error("Failed to mute chat: " + err.Error())
+		return err
+	}
+	updateChatSettings(s, jid, func(c *apptypes.ChatItem) { c.MutedUntil = until })
+	return nil
+}
+
+// muteSeconds converts a SetMuted duration for the daemon protocol.
+func muteSeconds(d time.Duration) int64 {
+	if d < 0 {
+		return -1
+	}
+	return int64(d / time.Second)
+}
+
+// lastMessageKey returns the timestamp and key of the newest message in a
+// chat, which archive patches carry. Imported messages have IDs WhatsApp
+// doesn't know, so they are skipped.
+func lastMessageKey(s *state.AppState, jid types.JID) (time.Time, *waCommon.MessageKey) {
+	s.MessagesMu.RLock()
+	defer s.MessagesMu.RUnlock()
+	msgs := s.MessagesMap[jid.String()]
+	for i := len(msgs) - 1; i >= 0; i-- {
+		m := msgs[i]
+		if m.System || strings.HasPrefix(m.ID, db.ImportedIDPrefix) {
+			continue
+		}
+		key := &waCommon.MessageKey{
+			RemoteJID: proto.String(jid.String()),
+			FromMe:    proto.Bool(m.FromMe),
+			ID:        proto.String(m.ID),
+		}
+		if jid.Server == types.GroupServer && !m.FromMe {
+			key.Participant = proto.String(m.SenderJID.String())
+		}
+		return m.Timestamp, key
+	}
+	return time.Now(), nil
+}
@@ -10,6 +10,8 @@ import (
 	_ "image/gif"
 	"image/jpeg"
 	_ "image/png"
+	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -18,14 +20,18 @@ import (
 
 	"github.com/nfnt/resize"
 	"github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/proto/waHistorySync"
 	"go.mau.fi/whatsmeow/proto/waWeb"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/StarGames2025/Logger"
 
+	"DevStarByte/internal/hooks"
+	"DevStarByte/internal/rpc"
 	"DevStarByte/internal/state"
 	apptypes "DevStarByte/internal/types"
 )
@@ -35,6 +41,7 @@ import (
 // NewEventHandler returns a whatsmeow event handler wired to the given state.
 func NewEventHandler(s *state.AppState) func(interface{}) {
 	return func(rawEvt interface{}) {
+		s.Recorder.Record(rawEvt)
 		switch evt := rawEvt.(type) {
 		case *events.Message:
 			s.Logger.Debug("Received message event from " + evt.Info.Chat.String())
@@ -42,6 +49,32 @@ func NewEventHandler(s *state.AppState) func(interface{}) {
 		case *events.HistorySync:
 			s.Logger.Info(fmt.Sprintf("Received history sync with %d conversations", len(evt.Data.GetConversations())))
 			handleHistorySync(s, evt)
+		case *events.GroupInfo:
+			s.Logger.Debug("Received group info change for " + evt.JID.String())
+			handleGroupInfo(s, evt)
+		case *events.JoinedGroup:
+			s.Logger.Info("Joined group " + evt.JID.String())
+			handleJoinedGroup(s, evt)
+		case *events.Pin:
+			handlePin(s, evt)
+		case *events.Archive:
+			handleArchive(s, evt)
+		case *events.Mute:
+			handleMute(s, evt)
+		case *events.MarkChatAsRead:
+			handleMarkChatAsRead(s, evt)
+		case *events.Receipt:
+			handleReceipt(s, evt)
+		case *events.Presence:
+			handlePresence(s, evt)
+		case *events.ChatPresence:
+			handleChatPresence(s, evt)
+		case *events.Connected:
+			s.Logger.Info("Connected to WhatsApp")
+			hookConnection(s, hooks.OnConnect)
+		case *events.Disconnected:
+			s.Logger.Warning("Disconnected from WhatsApp")
+			hookConnection(s, hooks.OnDisconnect)
 		}
 	}
 }
@@ -71,6 +104,7 @@ func handleHistorySync(s *state.AppState, evt *events.HistorySync) {
 	// phone number as name and try to resolve them now that the contact
 	// store / message history may have more data.
 	ctx := context.Background()
+	var renamed []state.Event
 	s.ChatsMu.Lock()
 	for key, c := range s.ChatsMap {
 		if !c.IsGroup && looksLikeNumber(c.Name) {
@@ -81,16 +115,14 @@ func handleHistorySync(s *state.AppState, evt *events.HistorySync) {
 			if resolved != "" {
 				c.Name = resolved
 				s.DB.UpsertChat(key, c.Name, c.IsGroup, c.LastMsg, c.LastTime)
+				renamed = append(renamed, state.ChatUpdated{Chat: *c})
 			}
 		}
 	}
 	s.ChatsMu.Unlock()
 
-	// Signal the TUI to rebuild its chat list from the updated global maps.
-	select {
-	case s.HistoryCh <- struct{}{}:
-	default:
-	}
+	s.Events.Publish(renamed...)
+	s.Events.Publish(state.HistorySynced{Conversations: len(evt.Data.GetConversations())})
 }
 
 func processHistoryConversation(s *state.AppState, conv *waHistorySync.Conversation, pushNames map[string]string) {
@@ -137,6 +169,16 @@ func processHistoryConversation(s *state.AppState, conv *waHistorySync.Conversat
 	s.MessagesMap[key] = deduped
 	s.MessagesMu.Unlock()
 
+	// Seed the read state from the phone's unread count.
+	unread := -1
+	if conv.UnreadCount != nil {
+		unread = int(conv.GetUnreadCount())
+		if unread == 0 && conv.GetMarkedAsUnread() {
+			unread = 1
+		}
+	}
+	lastReadID := lastReadBefore(deduped, max(unread, 0))
+
 	name := conv.GetName()
 	if name == "" {
 		name = jid.User
@@ -173,27 +215,39 @@ func processHistoryConversation(s *state.AppState, conv *waHistorySync.Conversat
 	}
 
 	s.ChatsMu.Lock()
-	if existing, ok := s.ChatsMap[key]; ok {
-		if existing.LastTime.IsZero() {
-			existing.LastMsg = lastMsg
-			existing.LastTime = lastTime
+	chat, ok := s.ChatsMap[key]
+	if ok {
+		if chat.LastTime.IsZero() {
+			chat.LastMsg = lastMsg
+			chat.LastTime = lastTime
 		}
-		if existing.Name == jid.User && name != jid.User {
-			existing.Name = name
+		if chat.Name == jid.User && name != jid.User {
+			chat.Name = name
 		}
 	} else {
-		s.ChatsMap[key] = &apptypes.ChatItem{
+		chat = &apptypes.ChatItem{
 			JID:      jid,
 			Name:     name,
 			LastMsg:  lastMsg,
 			LastTime: lastTime,
 			IsGroup:  jid.Server == types.GroupServer,
 		}
+		s.ChatsMap[key] = chat
+	}
+	if unread >= 0 {
+		chat.Unread = unread
+		chat.LastReadID = lastReadID
 	}
+	snapshot := *chat
 	s.ChatsMu.Unlock()
 
 	// Always persist the chat record.
 	s.DB.UpsertChat(key, name, jid.Server == types.GroupServer, lastMsg, lastTime)
+	if unread >= 0 {
+		s.DB.SaveReadState(key, unread, lastReadID)
+	}
+
+	s.Events.Publish(state.MessagesMerged{Chat: jid, Messages: msgs}, state.ChatUpdated{Chat: snapshot})
 }
 
 func extractHistoryMessage(s *state.AppState, wmi *waWeb.WebMessageInfo, chatJID types.JID) *apptypes.Message {
@@ -212,9 +266,7 @@ func extractHistoryMessage(s *state.AppState, wmi *waWeb.WebMessageInfo, chatJID
 	var senderName string
 	if key.GetFromMe() {
 		senderName = "You"
-		if s.Client.Store.ID != nil {
-			senderJID = *s.Client.Store.ID
-		}
+		senderJID = s.Client.OwnID()
 	} else {
 		participant := key.GetParticipant()
 		if participant == "" {
@@ -241,6 +293,9 @@ func extractHistoryMessage(s *state.AppState, wmi *waWeb.WebMessageInfo, chatJID
 		Timestamp: time.Unix(int64(wmi.GetMessageTimestamp()), 0),
 		FromMe:    key.GetFromMe(),
 	}
+	if msg.FromMe {
+		msg.Status = historyStatus(wmi.GetStatus())
+	}
 
 	// Try to download and cache image if this is an image message.
 	if imgMsg := getImageMessage(m); imgMsg != nil {
@@ -253,6 +308,10 @@ func extractHistoryMessage(s *state.AppState, wmi *waWeb.WebMessageInfo, chatJID
 }
 
 func handleMessage(s *state.AppState, evt *events.Message) {
+	if pm := evt.Message.GetProtocolMessage(); pm.GetType() == waE2E.ProtocolMessage_MESSAGE_EDIT {
+		handleEdit(s, evt.Info.Chat, pm.GetKey().GetID(), extractMsgContent(pm.GetEditedMessage()))
+		return
+	}
 	msg := extractMessage(evt)
 	if msg == nil {
 		s.Logger.Debug("Skipping unparseable message from " + evt.Info.Chat.String())
@@ -277,14 +336,18 @@ func handleMessage(s *state.AppState, evt *events.Message) {
 
 	s.DB.PersistMessage(key, *msg)
 
+	// Messages in the chat on screen are read right away; sending a message
+	// also marks everything before it as read.
+	s.FocusMu.RLock()
+	seen := msg.FromMe || s.FocusedChat == key
+	s.FocusMu.RUnlock()
+
 	s.ChatsMu.Lock()
 	var chatName string
-	if chat, ok := s.ChatsMap[key]; ok {
+	chat, ok := s.ChatsMap[key]
+	if ok {
 		chat.LastMsg = msg.Content
 		chat.LastTime = msg.Timestamp
-		if !msg.FromMe {
-			chat.Unread++
-		}
 		// If the chat still shows a phone number, update with the push name
 		// from this message (= the name the user set in their profile).
 		if looksLikeNumber(chat.Name) && evt.Info.PushName != "" {
@@ -303,24 +366,62 @@ func handleMessage(s *state.AppState, evt *events.Message) {
 		if name == "" {
 			name = chatJID.User
 		}
-		s.ChatsMap[key] = &apptypes.ChatItem{
+		chat = &apptypes.ChatItem{
 			JID:      chatJID,
 			Name:     name,
 			LastMsg:  msg.Content,
 			LastTime: msg.Timestamp,
 			IsGroup:  chatJID.Server == types.GroupServer,
 		}
+		s.ChatsMap[key] = chat
 		chatName = name
 	}
+	// The unread count is kept here only; the TUI copies it from the map.
+	if seen {
+		chat.Unread = 0
+		chat.LastReadID = msg.ID
+	} else if countsAsUnread(*msg) {
+		chat.Unread++
+	}
+	snapshot := *chat
 	s.ChatsMu.Unlock()
 
 	s.DB.UpsertChat(key, chatName, chatJID.Server == types.GroupServer, msg.Content, msg.Timestamp)
+	s.DB.SaveReadState(key, snapshot.Unread, snapshot.LastReadID)
 
-	// Non-blocking push to TUI message loop.
-	select {
-	case s.IncomingCh <- apptypes.MsgEvent{ChatJID: chatJID, Message: *msg}:
-	default:
+	notifyMessage(s, chatJID, chatName, *msg)
+	webhookMessage(s, evt, chatName, *msg)
+	hookMessage(s, evt, chatName, *msg)
+	applyRules(s, chatJID, chatName, *msg)
+
+	s.Events.Publish(state.MessageAdded{Chat: chatJID, Message: *msg}, state.ChatUpdated{Chat: snapshot})
+}
+
+// handleEdit replaces the text of a message that was edited.
+func handleEdit(s *state.AppState, chatJID types.JID, id, content string) {
+	if id == "" || content == "" {
+		return
 	}
+	key := chatJID.String()
+	var msg apptypes.Message
+	found := false
+	s.MessagesMu.Lock()
+	msgs := s.MessagesMap[key]
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].ID == id {
+			msgs[i].Content = content
+			msg, found = msgs[i], true
+			break
+		}
+	}
+	s.MessagesMu.Unlock()
+	if !found {
+		s.Logger.Debug("Edit for unknown message " + id + " in " + key)
+		return
+	}
+	s.Logger.Info("Message " + id + " in " + key + " edited: " + truncateLog(content, 80))
+	s.DB.PersistMessage(key, msg)
+	s.Events.Publish(state.MessageUpdated{Chat: chatJID, Message: msg})
 }
 
 // ── Message extraction ────────────────────────────────────────────────────────
@@ -339,7 +440,7 @@ func extractMessage(evt *events.Message) *apptypes.Message {
 	if sender == "" {
 		sender = info.Sender.User
 	}
-	return &apptypes.Message{
+	msg := &apptypes.Message{
 		ID:        info.ID,
 		Sender:    sender,
 		SenderJID: info.Sender,
@@ -347,6 +448,11 @@ func extractMessage(evt *events.Message) *apptypes.Message {
 		Timestamp: info.Timestamp,
 		FromMe:    info.IsFromMe,
 	}
+	if info.IsFromMe {
+		// Sent from another of my devices, so the server has it.
+		msg.Status = apptypes.StatusSent
+	}
+	return msg
 }
 
 // extractMsgContent returns a plain-text representation of any message type.
@@ -440,30 +546,151 @@ func extractMsgContent(m *waE2E.Message) string {
 
 // ── Message sending ───────────────────────────────────────────────────────────
 
-// SendMessage sends a text message to a WhatsApp JID.
-func SendMessage(s *state.AppState, jid types.JID, text string) error {
+// SendMessage sends a text message to a WhatsApp JID. Any mentioned users
+// must appear in text as "@<number>" and are listed in the message's
+// ContextInfo so their clients highlight the mention.
+func SendMessage(s *state.AppState, jid types.JID, text string, mentions ...types.JID) error {
+	if ok, err := forward(s, rpc.MethodSend, rpc.SendParams{Chat: jid, Text: text, Mentions: mentions}, nil); ok {
+		return err
+	}
 	s.Logger.Info("Sending message to " + jid.String() + ": " + truncateLog(text, 80))
 	conv := text
-	resp, err := s.Client.SendMessage(context.Background(), jid, &waE2E.Message{
-		Conversation: &conv,
-	})
+	waMsg := &waE2E.Message{Conversation: &conv}
+	if len(mentions) > 0 {
+		mentioned := make([]string, len(mentions))
+		for i, m := range mentions {
+			mentioned[i] = m.String()
+		}
+		waMsg = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:        &conv,
+			ContextInfo: &waE2E.ContextInfo{MentionedJID: mentioned},
+		}}
+	}
+	resp, err := s.Client.SendMessage(context.Background(), jid, waMsg)
 	if err != nil {
 		s.Logger.Error("Failed to send message to " + jid.String() + ": " + err.Error())
 		return err
 	}
 	s.Logger.Info("Message sent successfully, ID: " + resp.ID)
+	recordSent(s, jid, resp, text, "")
+	return nil
+}
 
-	var senderJID types.JID
-	if s.Client.Store.ID != nil {
-		senderJID = *s.Client.Store.ID
+// SendFile uploads the file at path and sends it to jid with an optional
+// caption. JPEG and PNG images are sent as photos, MP4 videos and audio as
+// such, and anything else as a document.
+func SendFile(s *state.AppState, jid types.JID, path, caption string) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if ok, err := forward(s, rpc.MethodSendFile, rpc.SendFileParams{Chat: jid, Path: path, Caption: caption}, nil); ok {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	mimeType := http.DetectContentType(data)
+	if ext := mime.TypeByExtension(filepath.Ext(path)); ext != "" && (mimeType == "application/octet-stream" || strings.HasPrefix(mimeType, "text/plain")) {
+		mimeType = ext
+	}
+	mediaType := whatsmeow.MediaDocument
+	switch {
+	case mimeType == "image/jpeg" || mimeType == "image/png":
+		mediaType = whatsmeow.MediaImage
+	case mimeType == "video/mp4":
+		mediaType = whatsmeow.MediaVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		mediaType = whatsmeow.MediaAudio
+	}
+	s.Logger.Info(fmt.Sprintf("Uploading %s (%s, %d bytes) for %s", filepath.Base(path), mimeType, len(data), jid.String()))
+	up, err := s.Client.Upload(ctx, data, mediaType)
+	if err != nil {
+		s.Logger.Error("Failed to upload " + path + ": " + err.Error())
+		return err
+	}
+
+	var waMsg *waE2E.Message
+	var imgPath string
+	switch mediaType {
+	case whatsmeow.MediaImage:
+		waMsg = &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			Caption:       optString(caption),
+			Mimetype:      proto.String(mimeType),
+			URL:           proto.String(up.URL),
+			DirectPath:    proto.String(up.DirectPath),
+			MediaKey:      up.MediaKey,
+			FileEncSHA256: up.FileEncSHA256,
+			FileSHA256:    up.FileSHA256,
+			FileLength:    proto.Uint64(up.FileLength),
+		}}
+		imgPath = cacheImage(s, data)
+	case whatsmeow.MediaVideo:
+		waMsg = &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			Caption:       optString(caption),
+			Mimetype:      proto.String(mimeType),
+			URL:           proto.String(up.URL),
+			DirectPath:    proto.String(up.DirectPath),
+			MediaKey:      up.MediaKey,
+			FileEncSHA256: up.FileEncSHA256,
+			FileSHA256:    up.FileSHA256,
+			FileLength:    proto.Uint64(up.FileLength),
+		}}
+	case whatsmeow.MediaAudio:
+		waMsg = &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			Mimetype:      proto.String(mimeType),
+			URL:           proto.String(up.URL),
+			DirectPath:    proto.String(up.DirectPath),
+			MediaKey:      up.MediaKey,
+			FileEncSHA256: up.FileEncSHA256,
+			FileSHA256:    up.FileSHA256,
+			FileLength:    proto.Uint64(up.FileLength),
+		}}
+	default:
+		waMsg = &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			Caption:       optString(caption),
+			Mimetype:      proto.String(mimeType),
+			FileName:      proto.String(filepath.Base(path)),
+			Title:         proto.String(filepath.Base(path)),
+			URL:           proto.String(up.URL),
+			DirectPath:    proto.String(up.DirectPath),
+			MediaKey:      up.MediaKey,
+			FileEncSHA256: up.FileEncSHA256,
+			FileSHA256:    up.FileSHA256,
+			FileLength:    proto.Uint64(up.FileLength),
+		}}
+	}
+
+	resp, err := s.Client.SendMessage(ctx, jid, waMsg)
+	if err != nil {
+		s.Logger.Error("Failed to send file to " + jid.String() + ": " + err.Error())
+		return err
+	}
+	s.Logger.Info("File sent successfully, ID: " + resp.ID)
+	recordSent(s, jid, resp, extractMsgContent(waMsg), imgPath)
+	return nil
+}
+
+func optString(v string) *string {
+	if v == "" {
+		return nil
 	}
+	return &v
+}
+
+// recordSent adds a message I just sent to the maps and the store and
+// publishes it, so it appears in the chat view immediately.
+func recordSent(s *state.AppState, jid types.JID, resp whatsmeow.SendResponse, content, imagePath string) {
 	msg := apptypes.Message{
 		ID:        resp.ID,
 		Sender:    "You",
-		SenderJID: senderJID,
-		Content:   text,
+		SenderJID: s.Client.OwnID(),
+		Content:   content,
 		Timestamp: resp.Timestamp,
 		FromMe:    true,
+		ImagePath: imagePath,
+		Status:    apptypes.StatusSent,
 	}
 	key := jid.String()
 
@@ -472,14 +699,44 @@ func SendMessage(s *state.AppState, jid types.JID, text string) error {
 	s.MessagesMu.Unlock()
 
 	s.DB.PersistMessage(key, msg)
-	s.DB.UpsertChat(key, "", jid.Server == types.GroupServer, text, resp.Timestamp)
 
-	// Push to TUI so the message appears in the chat view immediately.
-	select {
-	case s.IncomingCh <- apptypes.MsgEvent{ChatJID: jid, Message: msg}:
-	default:
-	}
+	// Sending marks everything before the message as read.
+	s.ChatsMu.Lock()
+	chat, ok := s.ChatsMap[key]
+	if !ok {
+		chat = &apptypes.ChatItem{JID: jid, Name: jid.User, IsGroup: jid.Server == types.GroupServer}
+		s.ChatsMap[key] = chat
+	}
+	chat.LastMsg = content
+	chat.LastTime = resp.Timestamp
+	chat.Unread = 0
+	chat.LastReadID = msg.ID
+	snapshot := *chat
+	s.ChatsMu.Unlock()
+
+	s.DB.UpsertChat(key, "", jid.Server == types.GroupServer, content, resp.Timestamp)
+	s.DB.SaveReadState(key, 0, msg.ID)
+
+	s.Events.Publish(state.MessageAdded{Chat: jid, Message: msg}, state.ChatUpdated{Chat: snapshot})
+	hookSent(s, snapshot, msg)
+}
 
+// SendReaction reacts to a message with an emoji. An empty emoji removes a
+// previous reaction.
+func SendReaction(s *state.AppState, chat types.JID, target apptypes.Message, emoji string) error {
+	if ok, err := forward(s, rpc.MethodReact, rpc.ReactParams{Chat: chat, MessageID: target.ID, Emoji: emoji}, nil); ok {
+		return err
+	}
+	s.Logger.Info("Reacting to " + target.ID + " in " + chat.String() + " with " + emoji)
+	sender := target.SenderJID
+	if target.FromMe {
+		sender = types.EmptyJID
+	}
+	reaction := s.Client.BuildReaction(chat, sender, target.ID, emoji)
+	if _, err := s.Client.SendMessage(context.Background(), chat, reaction); err != nil {
+		s.Logger.Error("Failed to send reaction: " + err.Error())
+		return err
+	}
 	return nil
 }
 
@@ -496,6 +753,12 @@ func downloadAndCacheImage(s *state.AppState, imgMsg *waE2E.ImageMessage) string
 		s.Logger.Warning("Failed to download image: " + err.Error())
 		return ""
 	}
+	return cacheImage(s, data)
+}
+
+// cacheImage saves image data to media_cache/, resized for inline display.
+// Returns the file path on success, or "" on failure.
+func cacheImage(s *state.AppState, data []byte) string {
 	// Generate a deterministic filename from the content hash.
 	h := sha256.Sum256(data)
 	name := hex.EncodeToString(h[:8]) + ".jpg"
@@ -519,15 +782,14 @@ func downloadAndCacheImage(s *state.AppState, imgMsg *waE2E.ImageMessage) string
 		img = resize.Resize(maxW, 0, img, resize.Lanczos3)
 	}
 
-	f, err := os.Create(fpath)
-	if err != nil {
-		s.Logger.Warning("Failed to create cache file: " + err.Error())
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		s.Logger.Warning("Failed to encode JPEG: " + err.Error())
 		return ""
 	}
-	defer f.Close()
-	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 85}); err != nil {
-		s.Logger.Warning("Failed to encode JPEG: " + err.Error())
-		os.Remove(fpath)
+	// Encrypted if messages.db is.
+	if err := s.DB.WriteMedia(fpath, buf.Bytes()); err != nil {
+		s.Logger.Warning("Failed to write cache file: " + err.Error())
 		return ""
 	}
 	s.Logger.Info("Cached image: " + fpath)
@@ -578,7 +840,7 @@ func LoadChats(s *state.AppState, ctx context.Context) ([]apptypes.ChatItem, err
 	}
 
 	// 2. Merge with live contacts (may have better names).
-	contacts, err := s.Client.Store.Contacts.GetAllContacts(ctx)
+	contacts, err := s.Client.GetAllContacts(ctx)
 	if err != nil {
 		s.Logger.Warning("Failed to load contacts: " + err.Error())
 	}
@@ -633,18 +895,14 @@ func LoadChats(s *state.AppState, ctx context.Context) ([]apptypes.ChatItem, err
 		if !looksLikeNumber(c.Name) {
 			s.DB.UpsertChat(key, c.Name, c.IsGroup, c.LastMsg, c.LastTime)
 		}
+		seedChatSettings(s, ctx, c)
 		result = append(result, *c)
 		s.ChatsMap[key] = c
 	}
 	s.ChatsMu.Unlock()
 
-	// Sort: most recent message first, then alphabetically.
-	sort.Slice(result, func(i, j int) bool {
-		if !result[i].LastTime.Equal(result[j].LastTime) {
-			return result[i].LastTime.After(result[j].LastTime)
-		}
-		return result[i].Name < result[j].Name
-	})
+	// Sort: pinned first, archived last, most recent message first.
+	SortChats(result)
 
 	return result, nil
 }
@@ -665,15 +923,17 @@ func looksLikeNumber(s string) bool {
 // resolveContactName tries multiple sources to find a human-readable name for a JID.
 func resolveContactName(s *state.AppState, ctx context.Context, jid types.JID) string {
 	// 1. Contact store (push name, full name, business name).
-	if info, err := s.Client.Store.Contacts.GetContact(ctx, jid); err == nil {
-		if info.FullName != "" {
-			return info.FullName
-		}
-		if info.PushName != "" {
-			return info.PushName
-		}
-		if info.BusinessName != "" {
-			return info.BusinessName
+	if s.Client != nil {
+		if info, err := s.Client.GetContact(ctx, jid); err == nil {
+			if info.FullName != "" {
+				return info.FullName
+			}
+			if info.PushName != "" {
+				return info.PushName
+			}
+			if info.BusinessName != "" {
+				return info.BusinessName
+			}
 		}
 	}
 	// 2. Look at the sender_name of the most recent message we have from this chat.
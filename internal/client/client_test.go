@@ -0,0 +1,479 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	pngenc "image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/db/dbtest"
+	"DevStarByte/internal/hooks"
+	"DevStarByte/internal/rules"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+	"DevStarByte/internal/wa"
+	"DevStarByte/internal/webhook"
+)
+
+var (
+	me    = types.NewJID("491700000000", types.DefaultUserServer)
+	alice = types.NewJID("491701111111", types.DefaultUserServer)
+	bob   = types.NewJID("491702222222", types.DefaultUserServer)
+	t0    = time.Unix(1_760_000_000, 0)
+)
+
+// newTestState returns an AppState backed by a fake client and a store in a
+// fresh working directory, with the event handler registered.
+func newTestState(t *testing.T) (*state.AppState, *wa.Fake) {
+	t.Helper()
+	logger, store := dbtest.New(t)
+	fake := wa.NewFake(me)
+	fake.Now = func() time.Time { return t0.Add(time.Hour) }
+	s := state.New(fake, store, logger, nil)
+	fake.AddEventHandler(NewEventHandler(s))
+	return s, fake
+}
+
+// drain returns the events queued on sub.
+func drain(sub *state.Subscription) []state.Event {
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		sub.Close()
+	}()
+	var all []state.Event
+	for {
+		evts, ok := sub.Next(0)
+		if !ok {
+			return all
+		}
+		all = append(all, evts...)
+	}
+}
+
+// ── Message extraction ────────────────────────────────────────────────────────
+
+func TestExtractMsgContent(t *testing.T) {
+	text := &waE2E.Message{Conversation: proto.String("hello")}
+	tests := []struct {
+		name string
+		msg  *waE2E.Message
+		want string
+	}{
+		{"nil", nil, ""},
+		{"conversation", text, "hello"},
+		{"extended text", &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: proto.String("hi @49")}}, "hi @49"},
+		{"image", &waE2E.Message{ImageMessage: &waE2E.ImageMessage{}}, "[Image]"},
+		{"image caption", &waE2E.Message{ImageMessage: &waE2E.ImageMessage{Caption: proto.String("cat")}}, "[Image: cat]"},
+		{"video caption", &waE2E.Message{VideoMessage: &waE2E.VideoMessage{Caption: proto.String("dog")}}, "[Video: dog]"},
+		{"voice", &waE2E.Message{AudioMessage: &waE2E.AudioMessage{}}, "[Voice message]"},
+		{"file", &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{FileName: proto.String("a.pdf")}}, "[File: a.pdf]"},
+		{"document", &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{}}, "[Document]"},
+		{"sticker", &waE2E.Message{StickerMessage: &waE2E.StickerMessage{}}, "[Sticker]"},
+		{"contact", &waE2E.Message{ContactMessage: &waE2E.ContactMessage{DisplayName: proto.String("Bob")}}, "[Contact: Bob]"},
+		{"location", &waE2E.Message{LocationMessage: &waE2E.LocationMessage{}}, "[Location]"},
+		{"poll", &waE2E.Message{PollCreationMessageV3: &waE2E.PollCreationMessage{Name: proto.String("Lunch?")}}, "[Poll: Lunch?]"},
+		{"reaction", &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{Text: proto.String("👍")}}, "[Reaction: 👍]"},
+		{"protocol", &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{}}, ""},
+		{"ephemeral", &waE2E.Message{EphemeralMessage: &waE2E.FutureProofMessage{Message: text}}, "hello"},
+		{"view once", &waE2E.Message{ViewOnceMessageV2: &waE2E.FutureProofMessage{Message: text}}, "hello"},
+		{"device sent", &waE2E.Message{DeviceSentMessage: &waE2E.DeviceSentMessage{Message: text}}, "hello"},
+		{"edited", &waE2E.Message{EditedMessage: &waE2E.FutureProofMessage{Message: text}}, "hello"},
+		{"document with caption", &waE2E.Message{DocumentWithCaptionMessage: &waE2E.FutureProofMessage{
+			Message: &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{FileName: proto.String("b.txt")}},
+		}}, "[File: b.txt]"},
+	}
+	for _, tt := range tests {
+		if got := extractMsgContent(tt.msg); got != tt.want {
+			t.Errorf("%s: extractMsgContent = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// ── Live messages ─────────────────────────────────────────────────────────────
+
+func TestIncomingMessage(t *testing.T) {
+	s, fake := newTestState(t)
+	sub := s.Events.Subscribe()
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "hi", t0))
+
+	key := alice.String()
+	if msgs := s.MessagesMap[key]; len(msgs) != 1 || msgs[0].Content != "hi" || msgs[0].Sender != "Alice" {
+		t.Fatalf("MessagesMap = %+v", msgs)
+	}
+	chat := s.ChatsMap[key]
+	if chat == nil || chat.Name != "Alice" || chat.Unread != 1 || chat.LastMsg != "hi" {
+		t.Fatalf("chat = %+v", chat)
+	}
+	if got := s.DB.LoadMessages(key, 10); len(got) != 1 {
+		t.Errorf("persisted %d messages, want 1", len(got))
+	}
+
+	evts := drain(sub)
+	if len(evts) != 2 {
+		t.Fatalf("events = %#v", evts)
+	}
+	if added, ok := evts[0].(state.MessageAdded); !ok || added.Message.ID != "m1" {
+		t.Errorf("first event = %#v", evts[0])
+	}
+	if upd, ok := evts[1].(state.ChatUpdated); !ok || upd.Chat.Unread != 1 {
+		t.Errorf("second event = %#v", evts[1])
+	}
+}
+
+func TestFocusedChatStaysRead(t *testing.T) {
+	s, fake := newTestState(t)
+	s.FocusedChat = alice.String()
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "hi", t0))
+	if chat := s.ChatsMap[alice.String()]; chat.Unread != 0 || chat.LastReadID != "m1" {
+		t.Errorf("chat = %+v, want read up to m1", chat)
+	}
+}
+
+func TestSendMessageAndReceipts(t *testing.T) {
+	s, fake := newTestState(t)
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "hi", t0))
+
+	if err := SendMessage(s, alice, "hello @491702222222", bob); err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.SentMessages()
+	if len(sent) != 1 || sent[0].To != alice {
+		t.Fatalf("sent = %+v", sent)
+	}
+	ext := sent[0].Message.GetExtendedTextMessage()
+	if ext.GetText() != "hello @491702222222" || len(ext.GetContextInfo().GetMentionedJID()) != 1 {
+		t.Errorf("message = %v", sent[0].Message)
+	}
+
+	key := alice.String()
+	msgs := s.MessagesMap[key]
+	mine := msgs[len(msgs)-1]
+	if !mine.FromMe || mine.ID != sent[0].ID || mine.Status != apptypes.StatusSent {
+		t.Fatalf("sent message = %+v", mine)
+	}
+	if chat := s.ChatsMap[key]; chat.Unread != 0 || chat.LastReadID != mine.ID || chat.LastMsg != "hello @491702222222" {
+		t.Errorf("chat after sending = %+v", chat)
+	}
+
+	sub := s.Events.Subscribe()
+	fake.Emit(&events.Receipt{
+		MessageSource: types.MessageSource{Chat: alice, Sender: alice},
+		MessageIDs:    []types.MessageID{mine.ID},
+		Type:          types.ReceiptTypeRead,
+	})
+	// A late delivery receipt doesn't downgrade the status.
+	fake.Emit(&events.Receipt{
+		MessageSource: types.MessageSource{Chat: alice, Sender: alice},
+		MessageIDs:    []types.MessageID{mine.ID},
+		Type:          types.ReceiptTypeDelivered,
+	})
+	if st := s.MessagesMap[key][len(msgs)-1].Status; st != apptypes.StatusRead {
+		t.Errorf("status = %d, want read", st)
+	}
+	if stored := s.DB.LoadMessages(key, 10); stored[len(stored)-1].Status != apptypes.StatusRead {
+		t.Errorf("stored status = %d, want read", stored[len(stored)-1].Status)
+	}
+	if evts := drain(sub); len(evts) != 1 {
+		t.Errorf("events = %#v, want one receipt", evts)
+	}
+}
+
+func TestSendFile(t *testing.T) {
+	s, fake := newTestState(t)
+	png := filepath.Join(t.TempDir(), "dot.png")
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	var buf bytes.Buffer
+	if err := pngenc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(png, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	notes := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(notes, []byte("plain text"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SendFile(s, alice, png, "look"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SendFile(s, alice, notes, ""); err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.SentMessages()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(sent))
+	}
+	photo := sent[0].Message.GetImageMessage()
+	if photo.GetCaption() != "look" || photo.GetMimetype() != "image/png" || !bytes.Equal(fake.Media[photo.GetDirectPath()], buf.Bytes()) {
+		t.Errorf("image message = %v", photo)
+	}
+	if doc := sent[1].Message.GetDocumentMessage(); doc.GetFileName() != "notes.txt" || doc.GetFileLength() != 10 {
+		t.Errorf("document message = %v", doc)
+	}
+
+	msgs := s.MessagesMap[alice.String()]
+	if len(msgs) != 2 || msgs[0].Content != "[Image: look]" || msgs[0].ImagePath == "" || msgs[1].Content != "[File: notes.txt]" {
+		t.Errorf("messages = %+v", msgs)
+	}
+
+	if err := SendFile(s, alice, filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Error("sending a missing file succeeded")
+	}
+}
+
+func TestEditedMessage(t *testing.T) {
+	s, fake := newTestState(t)
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "helo", t0))
+	edit := wa.TextEvent(alice, alice, "Alice", "m2", "", t0.Add(time.Minute))
+	edit.Message = &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{
+		Type:          waE2E.ProtocolMessage_MESSAGE_EDIT.Enum(),
+		Key:           &waCommon.MessageKey{ID: proto.String("m1")},
+		EditedMessage: &waE2E.Message{Conversation: proto.String("hello")},
+	}}
+	fake.Emit(edit)
+
+	msgs := s.MessagesMap[alice.String()]
+	if len(msgs) != 1 || msgs[0].Content != "hello" {
+		t.Errorf("messages after edit = %+v", msgs)
+	}
+}
+
+func TestMarkReadSendsPatch(t *testing.T) {
+	s, fake := newTestState(t)
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "hi", t0))
+	if err := MarkRead(s, alice); err != nil {
+		t.Fatal(err)
+	}
+	if chat := s.ChatsMap[alice.String()]; chat.Unread != 0 || chat.LastReadID != "m1" {
+		t.Errorf("chat = %+v", chat)
+	}
+	if n := len(fake.Patches()); n != 1 {
+		t.Errorf("sent %d patches, want 1", n)
+	}
+	// Already read: nothing to sync.
+	if err := MarkRead(s, alice); err != nil || len(fake.Patches()) != 1 {
+		t.Errorf("second MarkRead synced again (err %v)", err)
+	}
+}
+
+func TestLastMessageKeySkipsImported(t *testing.T) {
+	s, fake := newTestState(t)
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "hi", t0))
+	s.MessagesMap[alice.String()] = append(s.MessagesMap[alice.String()],
+		apptypes.Message{ID: db.ImportedIDPrefix + "1", Content: "from an export", Timestamp: t0.Add(time.Minute)})
+	if _, key := lastMessageKey(s, alice); key.GetID() != "m1" {
+		t.Errorf("key = %v, want m1", key)
+	}
+}
+
+// ── History sync ──────────────────────────────────────────────────────────────
+
+func historyMsg(chat types.JID, id, text string, fromMe bool, ts time.Time) *waHistorySync.HistorySyncMsg {
+	return &waHistorySync.HistorySyncMsg{Message: &waWeb.WebMessageInfo{
+		Key: &waCommon.MessageKey{
+			RemoteJID: proto.String(chat.String()),
+			FromMe:    proto.Bool(fromMe),
+			ID:        proto.String(id),
+		},
+		Message:          &waE2E.Message{Conversation: proto.String(text)},
+		MessageTimestamp: proto.Uint64(uint64(ts.Unix())),
+		PushName:         proto.String("Alice"),
+		Status:           waWeb.WebMessageInfo_READ.Enum(),
+	}}
+}
+
+func TestHistorySyncMerge(t *testing.T) {
+	s, fake := newTestState(t)
+	// A live message that arrived before the history.
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "live", "newest", t0.Add(3*time.Minute)))
+	sub := s.Events.Subscribe()
+
+	fake.Emit(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		Conversations: []*waHistorySync.Conversation{{
+			ID:          proto.String(alice.String()),
+			UnreadCount: proto.Uint32(2),
+			Messages: []*waHistorySync.HistorySyncMsg{
+				// Newest first, as the phone sends them; "live" is a duplicate.
+				historyMsg(alice, "live", "newest", false, t0.Add(3*time.Minute)),
+				historyMsg(alice, "h3", "third", false, t0.Add(2*time.Minute)),
+				historyMsg(alice, "h2", "mine", true, t0.Add(time.Minute)),
+				historyMsg(alice, "h1", "first", false, t0),
+			},
+		}},
+	}})
+
+	key := alice.String()
+	var ids []string
+	for _, m := range s.MessagesMap[key] {
+		ids = append(ids, m.ID)
+	}
+	if want := []string{"h1", "h2", "h3", "live"}; len(ids) != len(want) || ids[0] != "h1" || ids[3] != "live" {
+		t.Fatalf("merged ids = %v, want %v", ids, want)
+	}
+	if mine := s.MessagesMap[key][1]; !mine.FromMe || mine.Status != apptypes.StatusRead {
+		t.Errorf("history message of mine = %+v", mine)
+	}
+	chat := s.ChatsMap[key]
+	if chat.Unread != 2 || chat.LastReadID != "h2" {
+		t.Errorf("read state = %d %q, want 2 after h2", chat.Unread, chat.LastReadID)
+	}
+	if n := len(s.DB.LoadMessages(key, 10)); n != 4 {
+		t.Errorf("persisted %d messages, want 4", n)
+	}
+
+	var merged, synced bool
+	for _, evt := range drain(sub) {
+		switch e := evt.(type) {
+		case state.MessagesMerged:
+			merged = len(e.Messages) == 4
+		case state.HistorySynced:
+			synced = e.Conversations == 1
+		}
+	}
+	if !merged || !synced {
+		t.Errorf("missing events: merged=%v synced=%v", merged, synced)
+	}
+}
+
+func TestHistorySyncMarkedUnread(t *testing.T) {
+	s, fake := newTestState(t)
+	fake.Emit(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		Conversations: []*waHistorySync.Conversation{{
+			ID:             proto.String(bob.String()),
+			UnreadCount:    proto.Uint32(0),
+			MarkedAsUnread: proto.Bool(true),
+			Messages:       []*waHistorySync.HistorySyncMsg{historyMsg(bob, "b1", "yo", false, t0)},
+		}},
+	}})
+	if chat := s.ChatsMap[bob.String()]; chat.Unread != 1 {
+		t.Errorf("unread = %d, want 1 for a chat marked unread", chat.Unread)
+	}
+}
+
+func TestWebhookMentionsMe(t *testing.T) {
+	s, fake := newTestState(t)
+	bodies := make(chan []byte, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer ts.Close()
+	s.Webhooks = webhook.New(s.Logger, s.DB, []webhook.Hook{{URL: ts.URL, Secret: "s", MentionsMe: true}})
+	defer s.Webhooks.Close()
+
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	mention := func(id, text string, jids ...string) *events.Message {
+		evt := wa.TextEvent(group, alice, "Alice", id, text, t0)
+		evt.Message = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:        proto.String(text),
+			ContextInfo: &waE2E.ContextInfo{MentionedJID: jids},
+		}}
+		return evt
+	}
+	fake.Emit(
+		wa.TextEvent(group, alice, "Alice", "m1", "no mention", t0),
+		mention("m2", "@491702222222 look", bob.String()),
+		mention("m3", "@491700000000 look", me.String()),
+	)
+
+	select {
+	case body := <-bodies:
+		var p webhook.Payload
+		if err := json.Unmarshal(body, &p); err != nil || p.ID != "m3" || !p.MentionsMe || p.Chat != group || p.Sender != alice {
+			t.Errorf("payload = %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+	}
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected delivery %s", body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHookReply(t *testing.T) {
+	s, fake := newTestState(t)
+	script := "#!/bin/sh\necho \"got: $WA_CONTENT\"\n"
+	if err := os.MkdirAll("hooks", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("hooks", hooks.OnMessage), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("hooks", hooks.OnSent), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s.Hooks = hooks.New(s.Logger, "hooks", hooks.Options{Timeout: 5 * time.Second, Reply: true})
+
+	// Messages from the offline backlog don't fire on-message.
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m0", "old ping", t0))
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "ping", time.Now()))
+	// The reply fires on-sent, whose output is not sent.
+	s.Hooks.Close()
+
+	sent := fake.SentMessages()
+	if len(sent) != 1 || sent[0].To != alice || sent[0].Message.GetConversation() != "got: ping" {
+		t.Fatalf("sent = %+v", sent)
+	}
+	if msgs := s.MessagesMap[alice.String()]; len(msgs) != 3 || !msgs[2].FromMe {
+		t.Errorf("messages = %+v", msgs)
+	}
+}
+
+func TestRuleReply(t *testing.T) {
+	s, fake := newTestState(t)
+	s.Rules = rules.New(s.Logger, s.DB, rules.Options{MinInterval: time.Minute})
+	if _, err := s.Rules.Save(rules.Rule{Name: "away", Enabled: true, Keyword: "(?i)urgent", Reply: "Back soon", Forward: bob.User}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "hello", now))
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m2", "urgent!", now))
+	// Rate limited.
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m3", "still urgent", now))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(fake.SentMessages()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	sent := fake.SentMessages()
+	if len(sent) != 2 || sent[0].To != alice || sent[0].Message.GetConversation() != "Back soon" ||
+		sent[1].To != bob || sent[1].Message.GetConversation() != "Alice: urgent!" {
+		t.Fatalf("sent = %+v", sent)
+	}
+}
+
+func TestRuleDryRun(t *testing.T) {
+	s, fake := newTestState(t)
+	s.Rules = rules.New(s.Logger, s.DB, rules.Options{DryRun: true})
+	if _, err := s.Rules.Save(rules.Rule{Name: "away", Enabled: true, Reply: "Back soon"}); err != nil {
+		t.Fatal(err)
+	}
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "m1", "hello", time.Now()))
+	// Old messages don't trigger rules at all.
+	fake.Emit(wa.TextEvent(bob, bob, "Bob", "m2", "hello", t0))
+	time.Sleep(50 * time.Millisecond)
+	if sent := fake.SentMessages(); len(sent) != 0 {
+		t.Errorf("sent = %+v", sent)
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+)
+
+// ── Daemon forwarding ─────────────────────────────────────────────────────────
+
+// forward runs an action on the daemon s is attached to, which owns the
+// connection and the store. It reports false when s has no daemon and the
+// action should run locally.
+func forward(s *state.AppState, method string, params, result interface{}) (bool, error) {
+	if s.Daemon == nil {
+		return false, nil
+	}
+	if err := s.Daemon.Call(method, params, result); err != nil {
+		s.Logger.Error("Daemon " + method + " failed: " + err.Error())
+		return true, err
+	}
+	return true, nil
+}
+
+// SetFocus records which chat is on screen ("" for none) so the event
+// handlers skip notifications and unread counts for it.
+func SetFocus(s *state.AppState, chat string) {
+	s.FocusMu.Lock()
+	changed := s.FocusedChat != chat
+	s.FocusedChat = chat
+	s.FocusMu.Unlock()
+	if changed && s.Daemon != nil {
+		if err := s.Daemon.Notify(rpc.MethodSessionFocus, rpc.FocusParams{Chat: chat}); err != nil {
+			s.Logger.Warning("Daemon focus update failed: " + err.Error())
+		}
+	}
+}
+
+// ScheduleChanged wakes the scheduler of the daemon s is attached to after
+// scheduled messages were edited, so it doesn't wait for its next poll.
+func ScheduleChanged(s *state.AppState) {
+	if s.Daemon == nil {
+		return
+	}
+	if err := s.Daemon.Notify(rpc.MethodScheduleWake, nil); err != nil {
+		s.Logger.Warning("Daemon schedule wake-up failed: " + err.Error())
+	}
+}
@@ -0,0 +1,384 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nfnt/resize"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Group management ──────────────────────────────────────────────────────────
+
+// Participant actions accepted by UpdateGroupParticipants.
+const (
+	GroupAdd     = "add"
+	GroupRemove  = "remove"
+	GroupPromote = "promote"
+	GroupDemote  = "demote"
+)
+
+// CreateGroup creates a new group with the given participants and registers
+// it in the chat map so it shows up in the sidebar immediately.
+func CreateGroup(s *state.AppState, name string, participants []types.JID) (*types.GroupInfo, error) {
+	var created types.GroupInfo
+	if ok, err := forward(s, rpc.MethodGroupCreate, rpc.CreateGroupParams{Name: name, Participants: participants}, &created); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	s.Logger.Info(fmt.Sprintf("Creating group %q with %d participants", name, len(participants)))
+	info, err := s.Client.CreateGroup(context.Background(), whatsmeow.ReqCreateGroup{
+		Name:         name,
+		Participants: participants,
+	})
+	if err != nil {
+		s.Logger.Error("Failed to create group: " + err.Error())
+		return nil, err
+	}
+	key := info.JID.String()
+	now := time.Now()
+	s.ChatsMu.Lock()
+	s.ChatsMap[key] = &apptypes.ChatItem{
+		JID:      info.JID,
+		Name:     info.Name,
+		LastTime: now,
+		IsGroup:  true,
+	}
+	s.ChatsMu.Unlock()
+	s.DB.UpsertChat(key, info.Name, true, "", now)
+	publishChat(s, key)
+	return info, nil
+}
+
+// SetGroupName changes the subject of a group.
+func SetGroupName(s *state.AppState, jid types.JID, name string) error {
+	if ok, err := forward(s, rpc.MethodGroupName, rpc.TextParams{Chat: jid, Text: name}, nil); ok {
+		return err
+	}
+	s.Logger.Info("Renaming group " + jid.String() + " to " + name)
+	if err := s.Client.SetGroupName(context.Background(), jid, name); err != nil {
+		s.Logger.Error("Failed to rename group: " + err.Error())
+		return err
+	}
+	key := jid.String()
+	s.ChatsMu.Lock()
+	if c, ok := s.ChatsMap[key]; ok {
+		c.Name = name
+	}
+	s.ChatsMu.Unlock()
+	s.DB.UpsertChat(key, name, true, "", time.Time{})
+	publishChat(s, key)
+	return nil
+}
+
+// SetGroupDescription changes the description (topic) of a group.
+func SetGroupDescription(s *state.AppState, jid types.JID, desc string) error {
+	if ok, err := forward(s, rpc.MethodGroupDescription, rpc.TextParams{Chat: jid, Text: desc}, nil); ok {
+		return err
+	}
+	s.Logger.Info("Updating description of group " + jid.String())
+	if err := s.Client.SetGroupDescription(context.Background(), jid, desc); err != nil {
+		s.Logger.Error("Failed to update group description: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// SetGroupPhoto loads an image from disk, converts it to a square-bounded JPEG
+// as required by WhatsApp and uploads it as the group photo.
+func SetGroupPhoto(s *state.AppState, jid types.JID, path string) error {
+	if ok, err := forward(s, rpc.MethodGroupPhoto, rpc.TextParams{Chat: jid, Text: path}, nil); ok {
+		return err
+	}
+	s.Logger.Info("Setting photo of group " + jid.String() + " from " + path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+	// WhatsApp rejects avatars larger than 640px on either side.
+	const maxSide = 640
+	if b := img.Bounds(); b.Dx() > maxSide || b.Dy() > maxSide {
+		img = resize.Thumbnail(maxSide, maxSide, img, resize.Lanczos3)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return fmt.Errorf("encode image: %w", err)
+	}
+	if _, err := s.Client.SetGroupPhoto(context.Background(), jid, buf.Bytes()); err != nil {
+		s.Logger.Error("Failed to set group photo: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// UpdateGroupParticipants adds, removes, promotes or demotes group members.
+// action is one of GroupAdd, GroupRemove, GroupPromote or GroupDemote.
+func UpdateGroupParticipants(s *state.AppState, jid types.JID, users []types.JID, action string) error {
+	if ok, err := forward(s, rpc.MethodGroupParticipants, rpc.ParticipantsParams{Chat: jid, Users: users, Action: action}, nil); ok {
+		return err
+	}
+	ctx := context.Background()
+	s.Logger.Info(fmt.Sprintf("Group %s: %s %d participants", jid.String(), action, len(users)))
+	info, err := s.Client.GetGroupInfo(ctx, jid)
+	if err != nil {
+		s.Logger.Error("Failed to fetch group info: " + err.Error())
+		return err
+	}
+	if !isSelfAdmin(s, info) {
+		return errors.New("you are not an admin of this group")
+	}
+	// Members of LID-addressed groups must be referenced by the JID the
+	// group knows them under, not by their phone-number JID.
+	if action != GroupAdd {
+		users = slices.Clone(users) // the caller's slice stays as given
+		for i, u := range users {
+			for _, p := range info.Participants {
+				if p.JID.User == u.User || p.PhoneNumber.User == u.User {
+					users[i] = p.JID
+					break
+				}
+			}
+		}
+	}
+	res, err := s.Client.UpdateGroupParticipants(ctx, jid, users, whatsmeow.ParticipantChange(action))
+	if err != nil {
+		s.Logger.Error("Failed to update group participants: " + err.Error())
+		return err
+	}
+	for _, p := range res {
+		if p.Error != 0 {
+			return fmt.Errorf("%s failed for %s (code %d)", action, p.JID.User, p.Error)
+		}
+	}
+	return nil
+}
+
+// GroupInviteLink returns the group's invite link. When reset is true the
+// current link is revoked and a new one is generated.
+func GroupInviteLink(s *state.AppState, jid types.JID, reset bool) (string, error) {
+	var link string
+	if ok, err := forward(s, rpc.MethodGroupInviteLink, rpc.FlagParams{Chat: jid, On: reset}, &link); ok {
+		return link, err
+	}
+	link, err := s.Client.GetGroupInviteLink(context.Background(), jid, reset)
+	if err != nil {
+		s.Logger.Error("Failed to get group invite link: " + err.Error())
+		return "", err
+	}
+	return link, nil
+}
+
+// isSelfAdmin reports whether the logged-in account is an admin of the group.
+func isSelfAdmin(s *state.AppState, info *types.GroupInfo) bool {
+	for _, p := range info.Participants {
+		if (p.IsAdmin || p.IsSuperAdmin) && IsSelf(s, p.JID) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSelf reports whether jid (phone number or LID) belongs to the logged-in account.
+func IsSelf(s *state.AppState, jid types.JID) bool {
+	if s.Client == nil {
+		return false
+	}
+	if id := s.Client.OwnID(); !id.IsEmpty() && id.User == jid.User {
+		return true
+	}
+	lid := s.Client.OwnLID()
+	return !lid.IsEmpty() && lid.User == jid.User
+}
+
+// ── Group events ──────────────────────────────────────────────────────────────
+
+// handleJoinedGroup registers a group we were added to (or created elsewhere).
+func handleJoinedGroup(s *state.AppState, evt *events.JoinedGroup) {
+	key := evt.JID.String()
+	s.ChatsMu.Lock()
+	c, ok := s.ChatsMap[key]
+	if !ok {
+		c = &apptypes.ChatItem{JID: evt.JID, IsGroup: true}
+		s.ChatsMap[key] = c
+	}
+	if evt.Name != "" {
+		c.Name = evt.Name
+	}
+	name := c.Name
+	s.ChatsMu.Unlock()
+	s.DB.UpsertChat(key, name, true, "", time.Time{})
+	publishChat(s, key)
+
+	ts := evt.GroupCreated
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	actor := "Someone"
+	if evt.Sender != nil {
+		actor = DisplayName(s, *evt.Sender)
+	}
+	pushSystemLine(s, evt.JID, ts, "join", actor+" added You")
+}
+
+// handleGroupInfo applies metadata changes to the chat map and records each
+// change as a system line in the group's message history.
+func handleGroupInfo(s *state.AppState, evt *events.GroupInfo) {
+	key := evt.JID.String()
+	ts := evt.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	actor := "Someone"
+	var actorJID types.JID
+	if evt.Sender != nil {
+		actorJID = *evt.Sender
+		actor = DisplayName(s, actorJID)
+	}
+
+	if evt.Name != nil {
+		s.ChatsMu.Lock()
+		if c, ok := s.ChatsMap[key]; ok {
+			c.Name = evt.Name.Name
+		} else {
+			s.ChatsMap[key] = &apptypes.ChatItem{JID: evt.JID, Name: evt.Name.Name, IsGroup: true}
+		}
+		s.ChatsMu.Unlock()
+		s.DB.UpsertChat(key, evt.Name.Name, true, "", time.Time{})
+		publishChat(s, key)
+		pushSystemLine(s, evt.JID, ts, "name", fmt.Sprintf("%s changed the subject to %q", actor, evt.Name.Name))
+	}
+	if evt.Topic != nil {
+		if evt.Topic.TopicDeleted {
+			pushSystemLine(s, evt.JID, ts, "topic", actor+" deleted the group description")
+		} else {
+			pushSystemLine(s, evt.JID, ts, "topic", actor+" changed the group description")
+		}
+	}
+	if evt.NewInviteLink != nil {
+		pushSystemLine(s, evt.JID, ts, "link", actor+" reset the invite link")
+	}
+	if evt.Delete != nil {
+		pushSystemLine(s, evt.JID, ts, "delete", actor+" deleted the group")
+	}
+
+	pushMembers := func(kind string, jids []types.JID, self, other string) {
+		for _, j := range jids {
+			target := DisplayName(s, j)
+			if evt.Sender == nil || actorJID.User == j.User {
+				pushSystemLine(s, evt.JID, ts, kind+j.User, target+" "+self)
+			} else {
+				pushSystemLine(s, evt.JID, ts, kind+j.User, fmt.Sprintf(other, actor, target))
+			}
+		}
+	}
+	pushMembers("join-", evt.Join, "joined", "%s added %s")
+	pushMembers("leave-", evt.Leave, "left", "%s removed %s")
+	pushMembers("promote-", evt.Promote, "is now an admin", "%s made %s an admin")
+	pushMembers("demote-", evt.Demote, "is no longer an admin", "%s dismissed %s as admin")
+}
+
+// pushSystemLine stores a group notice as a system message and forwards it to
+// the TUI. kind makes the synthetic ID unique among notices sharing a timestamp.
+func pushSystemLine(s *state.AppState, chatJID types.JID, ts time.Time, kind, text string) {
+	s.Logger.Info("Group notice in " + chatJID.String() + ": " + text)
+	msg := apptypes.Message{
+		ID:        fmt.Sprintf("sys-%d-%s", ts.Unix(), kind),
+		Content:   text,
+		Timestamp: ts,
+		System:    true,
+	}
+	key := chatJID.String()
+
+	s.MessagesMu.Lock()
+	s.MessagesMap[key] = append(s.MessagesMap[key], msg)
+	s.MessagesMu.Unlock()
+
+	s.DB.PersistMessage(key, msg)
+
+	s.Events.Publish(state.MessageAdded{Chat: chatJID, Message: msg})
+}
+
+// DisplayName returns a human-readable name for jid, "You" for the logged-in
+// account, or the bare number if nothing better is known. Hidden-user (LID)
+// JIDs are mapped to their phone number first when the mapping is known.
+func DisplayName(s *state.AppState, jid types.JID) string {
+	if IsSelf(s, jid) {
+		return "You"
+	}
+	ctx := context.Background()
+	if name := resolveContactName(s, ctx, jid); name != "" {
+		return name
+	}
+	if jid.Server == types.HiddenUserServer && s.Client != nil {
+		if pn, err := s.Client.GetPNForLID(ctx, jid); err == nil && !pn.IsEmpty() {
+			if name := resolveContactName(s, ctx, pn); name != "" {
+				return name
+			}
+		}
+	}
+	return jid.User
+}
+
+// ── Contact lookup ────────────────────────────────────────────────────────────
+
+// ResolveJID turns user input into a JID. It accepts a full JID, a phone
+// number, or a (case-insensitive) chat name from the chat map. Names must be
+// unambiguous.
+func ResolveJID(s *state.AppState, query string) (types.JID, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return types.JID{}, errors.New("empty contact")
+	}
+	if strings.ContainsRune(query, '@') {
+		return types.ParseJID(query)
+	}
+	if num := strings.TrimPrefix(strings.ReplaceAll(query, " ", ""), "+"); looksLikeNumber(num) {
+		return types.NewJID(num, types.DefaultUserServer), nil
+	}
+
+	lower := strings.ToLower(query)
+	var exact, partial []types.JID
+	s.ChatsMu.RLock()
+	for _, c := range s.ChatsMap {
+		name := strings.ToLower(c.Name)
+		switch {
+		case name == lower:
+			exact = append(exact, c.JID)
+		case strings.Contains(name, lower):
+			partial = append(partial, c.JID)
+		}
+	}
+	s.ChatsMu.RUnlock()
+
+	switch {
+	case len(exact) == 1:
+		return exact[0], nil
+	case len(exact) > 1:
+		return types.JID{}, fmt.Errorf("%q matches %d chats", query, len(exact))
+	case len(partial) == 1:
+		return partial[0], nil
+	case len(partial) > 1:
+		return types.JID{}, fmt.Errorf("%q matches %d chats", query, len(partial))
+	}
+	return types.JID{}, fmt.Errorf("no chat matches %q", query)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+
+	apptypes "DevStarByte/internal/types"
+	"DevStarByte/internal/wa"
+)
+
+// ── Name resolution ───────────────────────────────────────────────────────────
+
+func TestDisplayName(t *testing.T) {
+	s, fake := newTestState(t)
+	carol := types.NewJID("491703333333", types.DefaultUserServer)
+	dave := types.NewJID("491704444444", types.DefaultUserServer)
+	aliceLID := types.NewJID("123456789", types.HiddenUserServer)
+	myLID := types.NewJID("987654321", types.HiddenUserServer)
+	fake.LID = myLID
+	fake.Contacts[alice] = types.ContactInfo{FullName: "Alice Smith", PushName: "Ali"}
+	fake.Contacts[bob] = types.ContactInfo{PushName: "Bobby"}
+	fake.Contacts[carol] = types.ContactInfo{BusinessName: "Carol's Café"}
+	fake.LIDs[aliceLID] = alice
+	s.DB.PersistMessage(dave.String(), apptypes.Message{ID: "d1", Sender: "Dave", SenderJID: dave, Content: "hey", Timestamp: t0})
+
+	tests := []struct {
+		jid  types.JID
+		want string
+	}{
+		{me, "You"},
+		{myLID, "You"},
+		{alice, "Alice Smith"}, // saved name beats push name
+		{bob, "Bobby"},
+		{carol, "Carol's Café"},
+		{dave, "Dave"}, // from message history
+		{aliceLID, "Alice Smith"},
+		{types.NewJID("491705555555", types.DefaultUserServer), "491705555555"},
+	}
+	for _, tt := range tests {
+		if got := DisplayName(s, tt.jid); got != tt.want {
+			t.Errorf("DisplayName(%s) = %q, want %q", tt.jid, got, tt.want)
+		}
+	}
+}
+
+func TestChatNameFromPushName(t *testing.T) {
+	s, fake := newTestState(t)
+	fake.Emit(wa.TextEvent(bob, bob, "Bob", "b1", "hi", t0))
+	if name := s.ChatsMap[bob.String()].Name; name != "Bob" {
+		t.Errorf("new chat named %q, want the push name", name)
+	}
+
+	// A chat still showing a number picks up the push name later.
+	s.ChatsMap[alice.String()] = &apptypes.ChatItem{JID: alice, Name: alice.User}
+	fake.Emit(wa.TextEvent(alice, alice, "Alice", "a1", "hi", t0))
+	if name := s.ChatsMap[alice.String()].Name; name != "Alice" {
+		t.Errorf("numbered chat renamed to %q, want Alice", name)
+	}
+}
+
+func TestLoadChatsMergesSources(t *testing.T) {
+	s, fake := newTestState(t)
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	s.DB.UpsertChat(alice.String(), alice.User, false, "hi", t0)
+	fake.Contacts[alice] = types.ContactInfo{FullName: "Alice Smith"}
+	fake.Groups[group] = &types.GroupInfo{JID: group, GroupName: types.GroupName{Name: "Team"}}
+	fake.Settings[group] = types.LocalChatSettings{Found: true, Pinned: true}
+
+	chats, err := LoadChats(s, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chats) != 2 || chats[0].JID != group || !chats[0].Pinned {
+		t.Fatalf("chats = %+v, want the pinned group first", chats)
+	}
+	if chats[1].Name != "Alice Smith" || chats[1].LastMsg != "hi" {
+		t.Errorf("alice = %+v", chats[1])
+	}
+	if stored := s.DB.LoadChats(); len(stored) != 2 {
+		t.Errorf("persisted %d chats, want 2", len(stored))
+	}
+}
+
+func TestResolveJID(t *testing.T) {
+	s, _ := newTestState(t)
+	s.ChatsMap[alice.String()] = &apptypes.ChatItem{JID: alice, Name: "Alice"}
+	s.ChatsMap[bob.String()] = &apptypes.ChatItem{JID: bob, Name: "Alina"}
+
+	if jid, err := ResolveJID(s, "alice"); err != nil || jid != alice {
+		t.Errorf("ResolveJID(alice) = %v, %v", jid, err)
+	}
+	if jid, err := ResolveJID(s, "+49 170 2222222"); err != nil || jid != bob {
+		t.Errorf("ResolveJID(number) = %v, %v", jid, err)
+	}
+	if _, err := ResolveJID(s, "Ali"); err == nil {
+		t.Error("ambiguous name resolved")
+	}
+	if _, err := ResolveJID(s, "nobody"); err == nil {
+		t.Error("unknown name resolved")
+	}
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"DevStarByte/internal/hooks"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Hook scripts ──────────────────────────────────────────────────────────────
+
+// hookMessage fires on-message for a fresh message from someone else. Its
+// output, if replies are enabled, is sent to the same chat.
+func hookMessage(s *state.AppState, evt *events.Message, chatName string, msg apptypes.Message) {
+	if s.Hooks == nil || msg.FromMe || msg.System || time.Since(msg.Timestamp) > notifyMaxAge {
+		return
+	}
+	chat := evt.Info.Chat
+	s.Hooks.Fire(hooks.Event{
+		Event:      hooks.OnMessage,
+		Chat:       chat,
+		ChatName:   chatName,
+		IsGroup:    chat.Server == types.GroupServer,
+		Sender:     msg.SenderJID,
+		SenderName: msg.Sender,
+		ID:         msg.ID,
+		Content:    msg.Content,
+		Timestamp:  msg.Timestamp,
+		MentionsMe: mentionsMe(s, evt.Message),
+	}, func(text string) {
+		if err := SendMessage(s, chat, text); err != nil {
+			s.Logger.Warning("Sending hook reply failed: " + err.Error())
+		}
+	})
+}
+
+// hookSent fires on-sent for a message I sent from here.
+func hookSent(s *state.AppState, chat apptypes.ChatItem, msg apptypes.Message) {
+	if s.Hooks == nil {
+		return
+	}
+	s.Hooks.Fire(hooks.Event{
+		Event:      hooks.OnSent,
+		Chat:       chat.JID,
+		ChatName:   chat.Name,
+		IsGroup:    chat.IsGroup,
+		Sender:     msg.SenderJID,
+		SenderName: msg.Sender,
+		ID:         msg.ID,
+		Content:    msg.Content,
+		Timestamp:  msg.Timestamp,
+	}, nil)
+}
+
+// hookConnection fires on-connect or on-disconnect.
+func hookConnection(s *state.AppState, name string) {
+	s.Hooks.Fire(hooks.Event{Event: name, Timestamp: time.Now()}, nil)
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Chat info ─────────────────────────────────────────────────────────────────
+
+// GetChatInfo collects the metadata for the info side panel: description,
+// creator and participants for groups; about text, phone number, business
+// name and profile picture for direct chats.
+func GetChatInfo(s *state.AppState, jid types.JID) (*apptypes.ChatInfo, error) {
+	var info apptypes.ChatInfo
+	if ok, err := forward(s, rpc.MethodChatsInfo, rpc.ChatParams{Chat: jid}, &info); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &info, nil
+	}
+	ctx := context.Background()
+	if jid.Server == types.GroupServer {
+		return getGroupChatInfo(s, ctx, jid)
+	}
+	return getContactChatInfo(s, ctx, jid)
+}
+
+func getGroupChatInfo(s *state.AppState, ctx context.Context, jid types.JID) (*apptypes.ChatInfo, error) {
+	s.Logger.Debug("Fetching group info for " + jid.String())
+	gi, err := s.Client.GetGroupInfo(ctx, jid)
+	if err != nil {
+		s.Logger.Warning("Failed to fetch group info: " + err.Error())
+		return nil, err
+	}
+	info := &apptypes.ChatInfo{
+		JID:         jid,
+		IsGroup:     true,
+		Description: gi.Topic,
+		Created:     gi.GroupCreated,
+	}
+	if !gi.OwnerJID.IsEmpty() {
+		info.Creator = DisplayName(s, gi.OwnerJID)
+	}
+	for _, p := range gi.Participants {
+		// Prefer the phone-number JID for name lookups; LIDs rarely resolve.
+		lookup := p.JID
+		if !p.PhoneNumber.IsEmpty() {
+			lookup = p.PhoneNumber
+		}
+		name := DisplayName(s, lookup)
+		if name == lookup.User && p.DisplayName != "" {
+			name = p.DisplayName
+		}
+		info.Participants = append(info.Participants, apptypes.Participant{
+			JID:          lookup,
+			Name:         name,
+			IsAdmin:      p.IsAdmin,
+			IsSuperAdmin: p.IsSuperAdmin,
+		})
+	}
+	// Admins first, then alphabetically.
+	sort.Slice(info.Participants, func(i, j int) bool {
+		a, b := info.Participants[i], info.Participants[j]
+		if a.IsSuperAdmin != b.IsSuperAdmin {
+			return a.IsSuperAdmin
+		}
+		if a.IsAdmin != b.IsAdmin {
+			return a.IsAdmin
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	return info, nil
+}
+
+func getContactChatInfo(s *state.AppState, ctx context.Context, jid types.JID) (*apptypes.ChatInfo, error) {
+	s.Logger.Debug("Fetching contact info for " + jid.String())
+	info := &apptypes.ChatInfo{JID: jid}
+	if jid.Server == types.DefaultUserServer {
+		info.Phone = "+" + jid.User
+	}
+	if c, err := s.Client.GetContact(ctx, jid); err == nil {
+		info.BusinessName = c.BusinessName
+	}
+	users, err := s.Client.GetUserInfo(ctx, []types.JID{jid})
+	if err != nil {
+		s.Logger.Warning("Failed to fetch user info: " + err.Error())
+		return info, nil
+	}
+	if u, ok := users[jid]; ok {
+		info.About = u.Status
+		if info.BusinessName == "" && u.VerifiedName != nil {
+			info.BusinessName = u.VerifiedName.Details.GetVerifiedName()
+		}
+	}
+	info.PicturePath = downloadProfilePicture(s, ctx, jid)
+	return info, nil
+}
+
+// downloadProfilePicture fetches the preview-sized profile picture of jid into
+// media_cache/. Returns the file path, or "" if there is none.
+func downloadProfilePicture(s *state.AppState, ctx context.Context, jid types.JID) string {
+	pic, err := s.Client.GetProfilePictureInfo(ctx, jid, &whatsmeow.GetProfilePictureParams{Preview: true})
+	if err != nil || pic == nil || pic.URL == "" {
+		return ""
+	}
+	fpath := filepath.Join("media_cache", fmt.Sprintf("avatar-%s-%s.jpg", jid.User, pic.ID))
+	if _, err := os.Stat(fpath); err == nil {
+		return fpath
+	}
+
+	httpClient := &http.Client{Timeout: 15 * time.Second}
+	resp, err := httpClient.Get(pic.URL)
+	if err != nil {
+		s.Logger.Warning("Failed to download profile picture: " + err.Error())
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		s.Logger.Warning("Failed to download profile picture: " + resp.Status)
+		return ""
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.Logger.Warning("Failed to download profile picture: " + err.Error())
+		return ""
+	}
+	if err := s.DB.WriteMedia(fpath, data); err != nil {
+		s.Logger.Warning("Failed to save profile picture: " + err.Error())
+		return ""
+	}
+	return fpath
+}
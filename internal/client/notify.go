@@ -0,0 +1,65 @@
+package client
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Notifications ─────────────────────────────────────────────────────────────
+
+const (
+	// notifyMaxAge skips notifications for messages delivered late, e.g. the
+	// backlog received after reconnecting.
+	notifyMaxAge = 10 * time.Minute
+
+	notifyPreviewLen = 120
+)
+
+// notifyMessage shows a desktop notification for an incoming message unless
+// it is mine, old, in the chat the user is looking at or in a muted chat.
+// Without a Notifier, as in a daemon without D-Bus, the notification is
+// published for attached clients instead.
+func notifyMessage(s *state.AppState, chatJID types.JID, chatName string, msg apptypes.Message) {
+	if msg.FromMe || msg.System || time.Since(msg.Timestamp) > notifyMaxAge {
+		return
+	}
+	key := chatJID.String()
+	s.FocusMu.RLock()
+	focused := s.FocusedChat == key
+	s.FocusMu.RUnlock()
+	if focused || isMuted(s, chatJID) {
+		return
+	}
+
+	title := msg.Sender
+	if chatJID.Server == types.GroupServer {
+		title = msg.Sender + " @ " + chatName
+	}
+	body := truncateLog(msg.Content, notifyPreviewLen)
+	if s.Config != nil && s.Config.NotifyPrivacy {
+		body = "New message"
+	}
+	if s.Notifier == nil {
+		s.Events.Publish(state.Notification{Chat: chatJID, Title: title, Body: body})
+		return
+	}
+	// D-Bus calls may block, so never hold up the event handler.
+	go func() {
+		if err := s.Notifier.Notify(key, title, body); err != nil {
+			s.Logger.Debug("Notification failed: " + err.Error())
+		}
+	}()
+}
+
+// isMuted reports whether notifications for a chat are muted, as synced from
+// the phone's app state.
+func isMuted(s *state.AppState, chatJID types.JID) bool {
+	s.ChatsMu.RLock()
+	defer s.ChatsMu.RUnlock()
+	chat, ok := s.ChatsMap[chatJID.String()]
+	return ok && chat.Muted()
+}
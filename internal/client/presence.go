@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+)
+
+// ── Presence ──────────────────────────────────────────────────────────────────
+
+// handlePresence publishes a contact coming online or going offline.
+func handlePresence(s *state.AppState, evt *events.Presence) {
+	p := state.Presence{JID: evt.From, State: state.PresenceOnline}
+	if evt.Unavailable {
+		p.State = state.PresenceOffline
+		p.LastSeen = evt.LastSeen
+	}
+	s.Events.Publish(p)
+}
+
+// handleChatPresence publishes someone starting or stopping to type or
+// record in a chat.
+func handleChatPresence(s *state.AppState, evt *events.ChatPresence) {
+	if IsSelf(s, evt.Sender) {
+		return
+	}
+	p := state.Presence{Chat: evt.Chat, JID: evt.Sender, State: state.PresencePaused}
+	if evt.State == types.ChatPresenceComposing {
+		p.State = state.PresenceTyping
+		if evt.Media == types.ChatPresenceMediaAudio {
+			p.State = state.PresenceRecording
+		}
+	}
+	s.Events.Publish(p)
+}
+
+// SubscribePresence asks WhatsApp for jid's online status. The server only
+// sends it while this device is marked available, so that is done first.
+func SubscribePresence(s *state.AppState, jid types.JID) error {
+	if ok, err := forward(s, rpc.MethodContactsPresence, rpc.JIDParams{JID: jid}, nil); ok {
+		return err
+	}
+	ctx := context.Background()
+	s.Logger.Debug("Subscribing to presence of " + jid.String())
+	if err := s.Client.SendPresence(ctx, types.PresenceAvailable); err != nil {
+		s.Logger.Error("Failed to send presence: " + err.Error())
+		return err
+	}
+	if err := s.Client.SubscribePresence(ctx, jid); err != nil {
+		s.Logger.Error("Failed to subscribe to presence: " + err.Error())
+		return err
+	}
+	return nil
+}
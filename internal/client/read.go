@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Read state ────────────────────────────────────────────────────────────────
+
+// countsAsUnread reports whether a message adds to a chat's unread count.
+func countsAsUnread(m apptypes.Message) bool {
+	return !m.FromMe && !m.System
+}
+
+// lastReadBefore returns the ID of the newest message that is read when the
+// newest `unread` incoming messages of msgs are not.
+func lastReadBefore(msgs []apptypes.Message, unread int) string {
+	i := len(msgs) - 1
+	for ; i >= 0 && unread > 0; i-- {
+		if countsAsUnread(msgs[i]) {
+			unread--
+		}
+	}
+	if i < 0 {
+		return ""
+	}
+	return msgs[i].ID
+}
+
+// FirstUnread returns the index of the first unread message of a chat, or -1
+// if everything is read. The last-read ID wins when it is among msgs; the
+// unread count is used otherwise.
+func FirstUnread(msgs []apptypes.Message, lastReadID string, unread int) int {
+	if unread <= 0 || len(msgs) == 0 {
+		return -1
+	}
+	if lastReadID != "" {
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].ID == lastReadID {
+				if i+1 < len(msgs) {
+					return i + 1
+				}
+				break
+			}
+		}
+	}
+	i := len(msgs) - 1
+	for ; i > 0 && unread > 0; i-- {
+		if countsAsUnread(msgs[i]) {
+			unread--
+			if unread == 0 {
+				break
+			}
+		}
+	}
+	return i
+}
+
+// MarkRead marks a chat as read up to its newest message. The unread count is
+// cleared and persisted, and the read state is synced to the other devices.
+func MarkRead(s *state.AppState, chat types.JID) error {
+	if ok, err := forward(s, rpc.MethodChatsMarkRead, rpc.ChatParams{Chat: chat}, nil); ok {
+		return err
+	}
+	key := chat.String()
+	lastTime, lastKey := lastMessageKey(s, chat)
+
+	s.ChatsMu.Lock()
+	c, ok := s.ChatsMap[key]
+	if !ok {
+		s.ChatsMu.Unlock()
+		return nil
+	}
+	wasUnread := c.Unread > 0
+	c.Unread = 0
+	if lastKey != nil {
+		c.LastReadID = lastKey.GetID()
+	}
+	lastReadID := c.LastReadID
+	s.ChatsMu.Unlock()
+
+	s.DB.SaveReadState(key, 0, lastReadID)
+	publishChat(s, key)
+	if !wasUnread || s.Client == nil {
+		return nil
+	}
+	s.Logger.Info("Marking " + key + " as read")
+	patch := appstate.BuildMarkChatAsRead(chat, true, lastTime, lastKey)
+	if err := s.Client.SendAppState(context.Background(), patch); err != nil {
+		s.Logger.Error("Failed to sync read state: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// handleMarkChatAsRead applies a chat being marked as read or unread on
+// another device.
+func handleMarkChatAsRead(s *state.AppState, evt *events.MarkChatAsRead) {
+	key := evt.JID.String()
+	read := evt.Action.GetRead()
+	s.Logger.Debug(fmt.Sprintf("Chat %s marked read=%v on another device", key, read))
+
+	s.MessagesMu.RLock()
+	msgs := s.MessagesMap[key]
+	var newest string
+	if len(msgs) > 0 {
+		newest = msgs[len(msgs)-1].ID
+	}
+	s.MessagesMu.RUnlock()
+
+	s.ChatsMu.Lock()
+	c, ok := s.ChatsMap[key]
+	if !ok {
+		s.ChatsMu.Unlock()
+		return
+	}
+	if read {
+		c.Unread = 0
+		c.LastReadID = newest
+	} else if c.Unread == 0 {
+		// "Mark as unread" on the phone: show the chat as having one.
+		c.Unread = 1
+	}
+	unread, lastReadID := c.Unread, c.LastReadID
+	s.ChatsMu.Unlock()
+
+	s.DB.SaveReadState(key, unread, lastReadID)
+	publishChat(s, key)
+}
+
+// publishChat publishes the current state of a chat list entry.
+func publishChat(s *state.AppState, key string) {
+	s.ChatsMu.RLock()
+	chat, ok := s.ChatsMap[key]
+	var c apptypes.ChatItem
+	if ok {
+		c = *chat
+	}
+	s.ChatsMu.RUnlock()
+	if ok {
+		s.Events.Publish(state.ChatUpdated{Chat: c})
+	}
+}
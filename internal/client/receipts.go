@@ -0,0 +1,66 @@
+package client
+
+import (
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Delivery receipts ─────────────────────────────────────────────────────────
+
+// handleReceipt raises the status of messages I sent when the recipient's
+// device got them or the recipient read them.
+func handleReceipt(s *state.AppState, evt *events.Receipt) {
+	// Receipts from my own devices are about messages I received.
+	if evt.IsFromMe {
+		return
+	}
+	var status apptypes.MessageStatus
+	switch evt.Type {
+	case types.ReceiptTypeDelivered:
+		status = apptypes.StatusDelivered
+	case types.ReceiptTypeRead, types.ReceiptTypePlayed:
+		status = apptypes.StatusRead
+	default:
+		return
+	}
+	key := evt.Chat.String()
+	ids := make(map[string]bool, len(evt.MessageIDs))
+	for _, id := range evt.MessageIDs {
+		ids[id] = true
+	}
+
+	var changed []string
+	s.MessagesMu.Lock()
+	msgs := s.MessagesMap[key]
+	for i := range msgs {
+		if msgs[i].FromMe && msgs[i].Status < status && ids[msgs[i].ID] {
+			msgs[i].Status = status
+			changed = append(changed, msgs[i].ID)
+		}
+	}
+	s.MessagesMu.Unlock()
+
+	s.DB.UpdateMessageStatus(key, evt.MessageIDs, status)
+	if len(changed) == 0 {
+		return
+	}
+	s.Logger.Debug("Receipt in " + key + " for " + changed[0] + ": " + string(evt.Type))
+	s.Events.Publish(state.Receipt{Chat: evt.Chat, MessageIDs: changed, Status: status, Timestamp: evt.Timestamp})
+}
+
+// historyStatus maps the status of a sent message from a history sync.
+func historyStatus(st waWeb.WebMessageInfo_Status) apptypes.MessageStatus {
+	switch st {
+	case waWeb.WebMessageInfo_SERVER_ACK:
+		return apptypes.StatusSent
+	case waWeb.WebMessageInfo_DELIVERY_ACK:
+		return apptypes.StatusDelivered
+	case waWeb.WebMessageInfo_READ, waWeb.WebMessageInfo_PLAYED:
+		return apptypes.StatusRead
+	}
+	return apptypes.StatusPending
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/rules"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Auto-reply rules ──────────────────────────────────────────────────────────
+
+// applyRules takes the actions of the first rule the message meets. Only
+// fresh messages from others count, so the backlog after reconnecting and my
+// own replies don't trigger anything.
+func applyRules(s *state.AppState, chat types.JID, chatName string, msg apptypes.Message) {
+	if s.Rules == nil || msg.FromMe || msg.System || time.Since(msg.Timestamp) > notifyMaxAge {
+		return
+	}
+	r, ok := s.Rules.Match(rules.Input{
+		Chat:     chat,
+		Sender:   msg.SenderJID,
+		Content:  msg.Content,
+		Time:     msg.Timestamp,
+		Previous: previousIncoming(s, chat, msg.ID),
+	})
+	if !ok {
+		return
+	}
+	if s.Rules.DryRun(r) {
+		s.Logger.Info(fmt.Sprintf("Rule %q (dry run) would %s for %s in %s", r.Name, r.Actions(), msg.ID, chatName))
+		return
+	}
+	s.Logger.Info(fmt.Sprintf("Rule %q: %s for %s in %s", r.Name, r.Actions(), msg.ID, chatName))
+	// The actions reach the server, so never hold up the event handler.
+	go runRule(s, r, chat, chatName, msg)
+}
+
+func runRule(s *state.AppState, r rules.Rule, chat types.JID, chatName string, msg apptypes.Message) {
+	fail := func(action string, err error) {
+		s.Logger.Warning(fmt.Sprintf("Rule %q: %s failed: %v", r.Name, action, err))
+	}
+	if r.MarkRead {
+		if err := MarkRead(s, chat); err != nil {
+			fail("mark read", err)
+		}
+	}
+	if r.React != "" {
+		if err := SendReaction(s, chat, msg, r.React); err != nil {
+			fail("react", err)
+		}
+	}
+	if r.Reply != "" {
+		if err := SendMessage(s, chat, r.Reply); err != nil {
+			fail("reply", err)
+		}
+	}
+	if r.Forward != "" {
+		to, err := ResolveJID(s, r.Forward)
+		if err == nil {
+			text := msg.Sender + ": " + msg.Content
+			if chat.Server == types.GroupServer {
+				text = msg.Sender + " @ " + chatName + ": " + msg.Content
+			}
+			err = SendMessage(s, to, text)
+		}
+		if err != nil {
+			fail("forward", err)
+		}
+	}
+}
+
+// previousIncoming returns when the last message from someone else before
+// the message with id arrived in chat.
+func previousIncoming(s *state.AppState, chat types.JID, id string) time.Time {
+	s.MessagesMu.RLock()
+	defer s.MessagesMu.RUnlock()
+	msgs := s.MessagesMap[chat.String()]
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if m := msgs[i]; m.ID != id && !m.FromMe && !m.System {
+			return m.Timestamp
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"slices"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+	"DevStarByte/internal/webhook"
+)
+
+// ── Webhooks ──────────────────────────────────────────────────────────────────
+
+// webhookMessage hands an incoming message to the webhooks. My own messages
+// are left out, so a bot answering through the API doesn't trigger itself.
+func webhookMessage(s *state.AppState, evt *events.Message, chatName string, msg apptypes.Message) {
+	if s.Webhooks == nil || msg.FromMe || msg.System {
+		return
+	}
+	s.Webhooks.Dispatch(webhook.Payload{
+		ID:         msg.ID,
+		Chat:       evt.Info.Chat,
+		ChatName:   chatName,
+		IsGroup:    evt.Info.Chat.Server == types.GroupServer,
+		Sender:     msg.SenderJID,
+		SenderName: msg.Sender,
+		Content:    msg.Content,
+		Timestamp:  msg.Timestamp,
+		MentionsMe: mentionsMe(s, evt.Message),
+	})
+}
+
+// mentionsMe reports whether m @-mentions me.
+func mentionsMe(s *state.AppState, m *waE2E.Message) bool {
+	if inner := m.GetDeviceSentMessage().GetMessage(); inner != nil {
+		m = inner
+	}
+	var ctx *waE2E.ContextInfo
+	switch {
+	case m.GetExtendedTextMessage() != nil:
+		ctx = m.GetExtendedTextMessage().GetContextInfo()
+	case m.GetImageMessage() != nil:
+		ctx = m.GetImageMessage().GetContextInfo()
+	case m.GetVideoMessage() != nil:
+		ctx = m.GetVideoMessage().GetContextInfo()
+	case m.GetDocumentMessage() != nil:
+		ctx = m.GetDocumentMessage().GetContextInfo()
+	}
+	return slices.ContainsFunc(ctx.GetMentionedJID(), func(j string) bool {
+		jid, err := types.ParseJID(j)
+		return err == nil && IsSelf(s, jid)
+	})
+}
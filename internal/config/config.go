@@ -0,0 +1,118 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/StarGames2025/Logger"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/notify"
+	"DevStarByte/internal/webhook"
+)
+
+// DefaultPath is where the config file is looked up, next to the databases.
+const DefaultPath = "config.json"
+
+// Config holds user settings. Every field has a sensible default so the file
+// is optional and may contain only the settings the user wants to change.
+type Config struct {
+	// InputMaxLines is how many lines the input bar may grow to for
+	// multi-line drafts before it starts scrolling.
+	InputMaxLines int `json:"input_max_lines"`
+
+	// Notify selects the notification backend: "auto", "dbus", "osc9",
+	// "osc777", "bell" or "off".
+	Notify string `json:"notify"`
+
+	// NotifyPrivacy hides message content in notifications.
+	NotifyPrivacy bool `json:"notify_privacy"`
+
+	// Webhooks receive a POST for every incoming message that passes their
+	// filters.
+	Webhooks []webhook.Hook `json:"webhooks,omitempty"`
+
+	// HooksDir holds the hook executables (on-message, on-sent, ...).
+	HooksDir string `json:"hooks_dir"`
+
+	// HooksTimeout is how many seconds a hook may run before it is killed.
+	HooksTimeout int `json:"hooks_timeout"`
+
+	// HooksMaxRunning is how many hooks may run at once.
+	HooksMaxRunning int `json:"hooks_max_running"`
+
+	// HooksReply sends the output of on-message back to the chat.
+	HooksReply bool `json:"hooks_reply"`
+
+	// RulesDryRun logs what the auto-reply rules would do instead of
+	// doing it.
+	RulesDryRun bool `json:"rules_dry_run"`
+
+	// RulesMinInterval is how many seconds must pass in a chat between two
+	// rules firing there, so auto-replies can't loop.
+	RulesMinInterval int `json:"rules_min_interval"`
+
+	// Encryption encrypts messages.db and media_cache/ at rest: "" (off),
+	// "passphrase" (asked at startup) or "keyring" (a key kept in the
+	// system keyring). Turning it on migrates a plain database.
+	Encryption string `json:"encryption"`
+}
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	return &Config{
+		InputMaxLines:    6,
+		Notify:           notify.BackendAuto,
+		HooksDir:         "hooks",
+		HooksTimeout:     10,
+		HooksMaxRunning:  4,
+		RulesMinInterval: 60,
+	}
+}
+
+// Load reads the config file at path on top of the defaults. A missing file
+// is not an error.
+func Load(logger *Logger.Logger, path string) (*Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Debug("No config file at " + path + ", using defaults")
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return Default(), err
+	}
+	cfg.normalise()
+	logger.Info("Loaded config from " + path)
+	return cfg, nil
+}
+
+// normalise clamps out-of-range values back to usable ones.
+func (c *Config) normalise() {
+	if c.InputMaxLines < 1 {
+		c.InputMaxLines = 1
+	}
+	if !slices.Contains(notify.Backends, c.Notify) {
+		c.Notify = notify.BackendAuto
+	}
+	if c.HooksTimeout < 1 {
+		c.HooksTimeout = 1
+	}
+	if c.HooksMaxRunning < 1 {
+		c.HooksMaxRunning = 1
+	}
+	if c.RulesMinInterval < 0 {
+		c.RulesMinInterval = 0
+	}
+	switch db.EncryptionMode(c.Encryption) {
+	case db.EncryptionPassphrase, db.EncryptionKeyring:
+	default:
+		c.Encryption = string(db.EncryptionOff)
+	}
+}
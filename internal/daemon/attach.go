@@ -0,0 +1,332 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+	"DevStarByte/internal/wa"
+)
+
+// historyLimit is how many messages per chat Attach copies from the daemon.
+const historyLimit = 500
+
+// Attach makes s a thin client of the daemon on c: actions are forwarded,
+// and the chat and message maps mirror the daemon's, kept current by its
+// events. It returns the chats in sidebar order.
+func Attach(s *state.AppState, c *rpc.Conn) ([]apptypes.ChatItem, error) {
+	if err := bind(s, c); err != nil {
+		return nil, err
+	}
+
+	// Events that arrive while the snapshot is copied are held back and
+	// applied after it, so nothing is lost or applied twice out of order.
+	var (
+		mu      sync.Mutex
+		pending []rpc.Event
+		ready   bool
+	)
+	err := c.Subscribe(func(e rpc.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !ready {
+			pending = append(pending, e)
+			return
+		}
+		mirror(s, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var chats []apptypes.ChatItem
+	if err := c.Call(rpc.MethodChatsList, nil, &chats); err != nil {
+		return nil, err
+	}
+	for _, ch := range chats {
+		var msgs []apptypes.Message
+		if err := c.Call(rpc.MethodHistory, rpc.HistoryParams{Chat: ch.JID, Limit: historyLimit}, &msgs); err != nil {
+			return nil, err
+		}
+		key := ch.JID.String()
+		s.ChatsMu.Lock()
+		ch := ch
+		s.ChatsMap[key] = &ch
+		s.ChatsMu.Unlock()
+		s.MessagesMu.Lock()
+		s.MessagesMap[key] = msgs
+		s.MessagesMu.Unlock()
+	}
+	s.Logger.Info(fmt.Sprintf("Daemon: attached, mirrored %d chats", len(chats)))
+
+	mu.Lock()
+	for _, e := range pending {
+		mirror(s, e)
+	}
+	pending, ready = nil, true
+	mu.Unlock()
+	return chats, nil
+}
+
+// Bind forwards the actions of s to the daemon on c without mirroring its
+// state, for short-lived commands. The daemon's chats are returned and put
+// in s.ChatsMap so names resolve.
+func Bind(s *state.AppState, c *rpc.Conn) ([]apptypes.ChatItem, error) {
+	if err := bind(s, c); err != nil {
+		return nil, err
+	}
+	var chats []apptypes.ChatItem
+	if err := c.Call(rpc.MethodChatsList, nil, &chats); err != nil {
+		return nil, err
+	}
+	s.ChatsMu.Lock()
+	for _, ch := range chats {
+		ch := ch
+		s.ChatsMap[ch.JID.String()] = &ch
+	}
+	s.ChatsMu.Unlock()
+	return chats, nil
+}
+
+// bind sets up s as a client of the daemon on c.
+func bind(s *state.AppState, c *rpc.Conn) error {
+	var info rpc.SessionInfo
+	if err := c.Call(rpc.MethodSessionInfo, nil, &info); err != nil {
+		return err
+	}
+	remote := &Remote{conn: c, own: info.Own, ownLID: info.OwnLID}
+	if err := c.Call(rpc.MethodContactsList, nil, &remote.contacts); err != nil {
+		s.Logger.Warning("Daemon: loading contacts failed: " + err.Error())
+	}
+	s.Client = remote
+	s.Daemon = c
+	return nil
+}
+
+// mirror applies a daemon event to the local maps the way the event
+// handlers would have, then publishes it to the local bus.
+func mirror(s *state.AppState, e rpc.Event) {
+	evt, ok := fromWire(e)
+	if !ok {
+		return
+	}
+	key := e.Chat.String()
+	switch evt := evt.(type) {
+	case state.MessageAdded:
+		s.MessagesMu.Lock()
+		if i := indexOf(s.MessagesMap[key], evt.Message.ID); i < 0 {
+			s.MessagesMap[key] = append(s.MessagesMap[key], evt.Message)
+		}
+		s.MessagesMu.Unlock()
+	case state.MessageUpdated:
+		s.MessagesMu.Lock()
+		if i := indexOf(s.MessagesMap[key], evt.Message.ID); i >= 0 {
+			s.MessagesMap[key][i] = evt.Message
+		}
+		s.MessagesMu.Unlock()
+	case state.MessagesMerged:
+		s.MessagesMu.Lock()
+		s.MessagesMap[key] = mergeMessages(s.MessagesMap[key], evt.Messages)
+		s.MessagesMu.Unlock()
+	case state.ChatUpdated:
+		ch := evt.Chat
+		s.ChatsMu.Lock()
+		s.ChatsMap[ch.JID.String()] = &ch
+		s.ChatsMu.Unlock()
+	case state.Receipt:
+		ids := make(map[string]bool, len(evt.MessageIDs))
+		for _, id := range evt.MessageIDs {
+			ids[id] = true
+		}
+		s.MessagesMu.Lock()
+		msgs := s.MessagesMap[key]
+		for i := range msgs {
+			if ids[msgs[i].ID] && msgs[i].Status < evt.Status {
+				msgs[i].Status = evt.Status
+			}
+		}
+		s.MessagesMu.Unlock()
+	case state.Notification:
+		go func() {
+			if err := s.Notifier.Notify(key, evt.Title, evt.Body); err != nil {
+				s.Logger.Debug("Notification failed: " + err.Error())
+			}
+		}()
+	}
+	s.Events.Publish(evt)
+}
+
+func indexOf(msgs []apptypes.Message, id string) int {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeMessages adds b to a, dropping known IDs, and sorts by time.
+func mergeMessages(a, b []apptypes.Message) []apptypes.Message {
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]apptypes.Message, 0, len(a)+len(b))
+	for _, m := range append(append([]apptypes.Message{}, a...), b...) {
+		if !seen[m.ID] {
+			seen[m.ID] = true
+			out = append(out, m)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
+
+// ── Remote client ─────────────────────────────────────────────────────────────
+
+// errRemote is returned by the Remote methods that only the daemon can
+// serve. The client package forwards the actions that use them.
+var errRemote = errors.New("not available when attached to a daemon")
+
+// Remote is the wa.Client of a process attached to a daemon. It answers
+// identity and contact lookups, caching them since names are resolved
+// while rendering.
+type Remote struct {
+	conn        *rpc.Conn
+	own, ownLID types.JID
+
+	mu       sync.Mutex
+	contacts map[types.JID]types.ContactInfo
+	pns      map[types.JID]types.JID
+}
+
+var _ wa.Client = (*Remote)(nil)
+
+func (r *Remote) OwnID() types.JID  { return r.own }
+func (r *Remote) OwnLID() types.JID { return r.ownLID }
+
+// AddEventHandler does nothing: events come from the daemon.
+func (r *Remote) AddEventHandler(whatsmeow.EventHandler) uint32 { return 0 }
+
+func (r *Remote) GetContact(_ context.Context, jid types.JID) (types.ContactInfo, error) {
+	r.mu.Lock()
+	info, ok := r.contacts[jid]
+	r.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+	if err := r.conn.Call(rpc.MethodContactsGet, rpc.JIDParams{JID: jid}, &info); err != nil {
+		return info, err
+	}
+	r.mu.Lock()
+	if r.contacts == nil {
+		r.contacts = make(map[types.JID]types.ContactInfo)
+	}
+	r.contacts[jid] = info
+	r.mu.Unlock()
+	return info, nil
+}
+
+func (r *Remote) GetAllContacts(context.Context) (map[types.JID]types.ContactInfo, error) {
+	var all map[types.JID]types.ContactInfo
+	if err := r.conn.Call(rpc.MethodContactsList, nil, &all); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.contacts = all
+	r.mu.Unlock()
+	return all, nil
+}
+
+func (r *Remote) GetPNForLID(_ context.Context, lid types.JID) (types.JID, error) {
+	r.mu.Lock()
+	pn, ok := r.pns[lid]
+	r.mu.Unlock()
+	if ok {
+		return pn, nil
+	}
+	if err := r.conn.Call(rpc.MethodContactsLIDPN, rpc.JIDParams{JID: lid}, &pn); err != nil {
+		return pn, err
+	}
+	r.mu.Lock()
+	if r.pns == nil {
+		r.pns = make(map[types.JID]types.JID)
+	}
+	r.pns[lid] = pn
+	r.mu.Unlock()
+	return pn, nil
+}
+
+func (r *Remote) SendMessage(context.Context, types.JID, *waE2E.Message, ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, errRemote
+}
+
+func (r *Remote) BuildReaction(types.JID, types.JID, types.MessageID, string) *waE2E.Message {
+	return nil
+}
+
+func (r *Remote) SendAppState(context.Context, appstate.PatchInfo) error { return errRemote }
+
+func (r *Remote) MarkRead(context.Context, []types.MessageID, time.Time, types.JID, types.JID, ...types.ReceiptType) error {
+	return errRemote
+}
+
+func (r *Remote) Download(context.Context, whatsmeow.DownloadableMessage) ([]byte, error) {
+	return nil, errRemote
+}
+
+func (r *Remote) Upload(context.Context, []byte, whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	return whatsmeow.UploadResponse{}, errRemote
+}
+
+func (r *Remote) GetUserInfo(context.Context, []types.JID) (map[types.JID]types.UserInfo, error) {
+	return nil, errRemote
+}
+
+func (r *Remote) GetProfilePictureInfo(context.Context, types.JID, *whatsmeow.GetProfilePictureParams) (*types.ProfilePictureInfo, error) {
+	return nil, errRemote
+}
+
+func (r *Remote) GetChatSettings(context.Context, types.JID) (types.LocalChatSettings, error) {
+	return types.LocalChatSettings{}, errRemote
+}
+
+func (r *Remote) GetJoinedGroups(context.Context) ([]*types.GroupInfo, error) {
+	return nil, errRemote
+}
+
+func (r *Remote) GetGroupInfo(context.Context, types.JID) (*types.GroupInfo, error) {
+	return nil, errRemote
+}
+
+func (r *Remote) CreateGroup(context.Context, whatsmeow.ReqCreateGroup) (*types.GroupInfo, error) {
+	return nil, errRemote
+}
+
+func (r *Remote) SetGroupName(context.Context, types.JID, string) error { return errRemote }
+
+func (r *Remote) SetGroupDescription(context.Context, types.JID, string) error { return errRemote }
+
+func (r *Remote) SetGroupPhoto(context.Context, types.JID, []byte) (string, error) {
+	return "", errRemote
+}
+
+func (r *Remote) UpdateGroupParticipants(context.Context, types.JID, []types.JID, whatsmeow.ParticipantChange) ([]types.GroupParticipant, error) {
+	return nil, errRemote
+}
+
+func (r *Remote) GetGroupInviteLink(context.Context, types.JID, bool) (string, error) {
+	return "", errRemote
+}
+
+func (r *Remote) SendPresence(context.Context, types.Presence) error { return errRemote }
+
+func (r *Remote) SubscribePresence(context.Context, types.JID) error { return errRemote }
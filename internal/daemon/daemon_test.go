@@ -0,0 +1,288 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/client"
+	"DevStarByte/internal/db/dbtest"
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/schedule"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+	"DevStarByte/internal/wa"
+)
+
+var (
+	me    = types.NewJID("491700000000", types.DefaultUserServer)
+	alice = types.NewJID("491701111111", types.DefaultUserServer)
+	t0    = time.Unix(1_760_000_000, 0)
+)
+
+func newLogger(t *testing.T) *Logger.Logger {
+	t.Helper()
+	logger, err := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logger
+}
+
+// startDaemon serves a fake session with one message from Alice and
+// returns the fake and the socket path.
+func startDaemon(t *testing.T) (*state.AppState, *wa.Fake, string) {
+	t.Helper()
+	logger, store := dbtest.New(t)
+	fake := wa.NewFake(me)
+	fake.Contacts[alice] = types.ContactInfo{Found: true, FullName: "Alice Smith"}
+	s := state.New(fake, store, logger, nil)
+	fake.AddEventHandler(client.NewEventHandler(s))
+	fake.Emit(wa.TextEvent(alice, alice, "Ali", "m1", "hello", t0))
+
+	path := filepath.Join(t.TempDir(), "d.sock")
+	srv, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.Serve(ctx, s)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return s, fake, path
+}
+
+func dial(t *testing.T, path string) *rpc.Conn {
+	t.Helper()
+	c, err := rpc.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// eventually polls cond until it holds or a second has passed.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for " + what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestListenRefusesRunningDaemon(t *testing.T) {
+	_, _, path := startDaemon(t)
+	if _, err := Listen(path); !errors.Is(err, ErrRunning) {
+		t.Errorf("second Listen = %v, want ErrRunning", err)
+	}
+}
+
+func TestListenSocketMode(t *testing.T) {
+	_, _, path := startDaemon(t)
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := fi.Mode().Perm(); mode != 0o600 {
+		t.Errorf("socket mode = %o, want 600", mode)
+	}
+}
+
+func TestCalls(t *testing.T) {
+	_, fake, path := startDaemon(t)
+	c := dial(t, path)
+
+	var info rpc.SessionInfo
+	if err := c.Call(rpc.MethodSessionInfo, nil, &info); err != nil || info.Own != me {
+		t.Errorf("session.info = %+v, %v", info, err)
+	}
+
+	var chats []apptypes.ChatItem
+	if err := c.Call(rpc.MethodChatsList, nil, &chats); err != nil {
+		t.Fatal(err)
+	}
+	if len(chats) != 1 || chats[0].JID != alice || chats[0].Unread != 1 {
+		t.Errorf("chats.list = %+v", chats)
+	}
+
+	var msgs []apptypes.Message
+	if err := c.Call(rpc.MethodHistory, rpc.HistoryParams{Chat: alice, Limit: 10}, &msgs); err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != "m1" || msgs[0].Content != "hello" {
+		t.Errorf("messages.history = %+v", msgs)
+	}
+	msgs = nil
+	if err := c.Call(rpc.MethodHistory, rpc.HistoryParams{Chat: alice, Before: t0}, &msgs); err != nil || len(msgs) != 0 {
+		t.Errorf("history before the first message = %+v, %v", msgs, err)
+	}
+
+	if err := c.Call(rpc.MethodSend, rpc.SendParams{Chat: alice, Text: "hi back"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.SentMessages()
+	if len(sent) != 1 || sent[0].To != alice || sent[0].Message.GetConversation() != "hi back" {
+		t.Errorf("sent = %+v", sent)
+	}
+
+	var rerr *rpc.Error
+	if err := c.Call("messages.nope", nil, nil); !errors.As(err, &rerr) || rerr.Code != rpc.CodeMethodNotFound {
+		t.Errorf("unknown method = %v", err)
+	}
+	if err := c.Call(rpc.MethodSend, nil, nil); !errors.As(err, &rerr) || rerr.Code != rpc.CodeInvalidParams {
+		t.Errorf("missing params = %v", err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	_, fake, path := startDaemon(t)
+	c := dial(t, path)
+
+	got := make(chan rpc.Event, 16)
+	if err := c.Subscribe(func(e rpc.Event) { got <- e }); err != nil {
+		t.Fatal(err)
+	}
+	fake.Emit(wa.TextEvent(alice, alice, "Ali", "m2", "are you there?", t0.Add(time.Minute)))
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case e := <-got:
+			if e.Type == rpc.EventMessage {
+				if e.Chat != alice || e.Message.ID != "m2" {
+					t.Errorf("event = %+v", e)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("no message event")
+		}
+	}
+}
+
+func TestAttach(t *testing.T) {
+	s, fake, path := startDaemon(t)
+	thin := state.New(nil, nil, newLogger(t), nil)
+	chats, err := Attach(thin, dial(t, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chats) != 1 || len(thin.MessagesMap[alice.String()]) != 1 {
+		t.Fatalf("mirrored %d chats, %d messages", len(chats), len(thin.MessagesMap[alice.String()]))
+	}
+	if name := client.DisplayName(thin, alice); name != "Alice Smith" {
+		t.Errorf("DisplayName = %q", name)
+	}
+
+	// New messages on the daemon reach the mirror and its bus.
+	sub := thin.Events.Subscribe()
+	defer sub.Close()
+	fake.Emit(wa.TextEvent(alice, alice, "Ali", "m2", "still there?", t0.Add(time.Minute)))
+	evts, _ := sub.Next(0)
+	if added, ok := evts[0].(state.MessageAdded); !ok || added.Message.ID != "m2" {
+		t.Errorf("first mirrored event = %#v", evts[0])
+	}
+	thin.MessagesMu.RLock()
+	n := len(thin.MessagesMap[alice.String()])
+	thin.MessagesMu.RUnlock()
+	if n != 2 {
+		t.Errorf("mirror has %d messages, want 2", n)
+	}
+
+	// Actions are carried out by the daemon.
+	if err := client.SendMessage(thin, alice, "yes"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := fake.SentMessages(); len(sent) != 1 || sent[0].Message.GetConversation() != "yes" {
+		t.Errorf("sent = %+v", sent)
+	}
+	if err := client.MarkRead(thin, alice); err != nil {
+		t.Fatal(err)
+	}
+	s.ChatsMu.RLock()
+	unread := s.ChatsMap[alice.String()].Unread
+	s.ChatsMu.RUnlock()
+	if unread != 0 {
+		t.Errorf("daemon still has %d unread", unread)
+	}
+	eventually(t, "the mirror to see the chat read", func() bool {
+		thin.ChatsMu.RLock()
+		defer thin.ChatsMu.RUnlock()
+		return thin.ChatsMap[alice.String()].Unread == 0
+	})
+
+	// Focus follows the attached client and is cleared when it leaves.
+	client.SetFocus(thin, alice.String())
+	eventually(t, "focus on the daemon", func() bool {
+		s.FocusMu.RLock()
+		defer s.FocusMu.RUnlock()
+		return s.FocusedChat == alice.String()
+	})
+	thin.Daemon.Close()
+	eventually(t, "focus to be cleared", func() bool {
+		s.FocusMu.RLock()
+		defer s.FocusMu.RUnlock()
+		return s.FocusedChat == ""
+	})
+}
+
+func TestAttachForwardsNotifications(t *testing.T) {
+	// The daemon has no Notifier, like one without D-Bus.
+	_, fake, path := startDaemon(t)
+	thin := state.New(nil, nil, newLogger(t), nil)
+	if _, err := Attach(thin, dial(t, path)); err != nil {
+		t.Fatal(err)
+	}
+	sub := thin.Events.Subscribe()
+	defer sub.Close()
+	fake.Emit(wa.TextEvent(alice, alice, "Ali", "m2", "ping", time.Now()))
+	for {
+		evts, ok := sub.Next(0)
+		if !ok {
+			t.Fatal("bus closed")
+		}
+		for _, evt := range evts {
+			if n, ok := evt.(state.Notification); ok {
+				if n.Chat != alice || n.Title != "Ali" || n.Body != "ping" {
+					t.Errorf("notification = %+v", n)
+				}
+				return
+			}
+		}
+	}
+}
+
+func TestScheduleWake(t *testing.T) {
+	s, fake, path := startDaemon(t)
+	s.Scheduler = schedule.New(s.Logger, s.DB, func(chat types.JID, text string) error {
+		return client.SendMessage(s, chat, text)
+	})
+	t.Cleanup(s.Scheduler.Close)
+	thin := state.New(nil, nil, newLogger(t), nil)
+	if _, err := Attach(thin, dial(t, path)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Added by the attached client, which shares messages.db.
+	if _, err := s.DB.AddScheduled(alice.String(), "later", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	client.ScheduleChanged(thin)
+	eventually(t, "the scheduled message to be sent", func() bool { return len(fake.SentMessages()) == 1 })
+}
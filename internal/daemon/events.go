@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+)
+
+// toWire converts a bus event for the socket.
+func toWire(evt state.Event) (rpc.Event, bool) {
+	switch e := evt.(type) {
+	case state.MessageAdded:
+		return rpc.Event{Type: rpc.EventMessage, Chat: e.Chat, Message: &e.Message}, true
+	case state.MessageUpdated:
+		return rpc.Event{Type: rpc.EventMessageUpdated, Chat: e.Chat, Message: &e.Message}, true
+	case state.MessagesMerged:
+		return rpc.Event{Type: rpc.EventMessagesMerged, Chat: e.Chat, Messages: e.Messages}, true
+	case state.ChatUpdated:
+		return rpc.Event{Type: rpc.EventChat, Chat: e.Chat.JID, ChatItem: &e.Chat}, true
+	case state.Receipt:
+		return rpc.Event{Type: rpc.EventReceipt, Chat: e.Chat, MessageIDs: e.MessageIDs, Status: e.Status, Timestamp: e.Timestamp}, true
+	case state.HistorySynced:
+		return rpc.Event{Type: rpc.EventHistorySynced, Conversations: e.Conversations}, true
+	case state.Presence:
+		return rpc.Event{Type: rpc.EventPresence, Chat: e.Chat, JID: e.JID, Presence: e.State, Timestamp: e.LastSeen}, true
+	case state.Notification:
+		return rpc.Event{Type: rpc.EventNotify, Chat: e.Chat, Title: e.Title, Body: e.Body}, true
+	}
+	return rpc.Event{}, false
+}
+
+// fromWire converts an event from the socket back for the local bus.
+func fromWire(e rpc.Event) (state.Event, bool) {
+	switch e.Type {
+	case rpc.EventMessage:
+		if e.Message != nil {
+			return state.MessageAdded{Chat: e.Chat, Message: *e.Message}, true
+		}
+	case rpc.EventMessageUpdated:
+		if e.Message != nil {
+			return state.MessageUpdated{Chat: e.Chat, Message: *e.Message}, true
+		}
+	case rpc.EventMessagesMerged:
+		return state.MessagesMerged{Chat: e.Chat, Messages: e.Messages}, true
+	case rpc.EventChat:
+		if e.ChatItem != nil {
+			return state.ChatUpdated{Chat: *e.ChatItem}, true
+		}
+	case rpc.EventReceipt:
+		return state.Receipt{Chat: e.Chat, MessageIDs: e.MessageIDs, Status: e.Status, Timestamp: e.Timestamp}, true
+	case rpc.EventHistorySynced:
+		return state.HistorySynced{Conversations: e.Conversations}, true
+	case rpc.EventPresence:
+		return state.Presence{Chat: e.Chat, JID: e.JID, State: e.Presence, LastSeen: e.Timestamp}, true
+	case rpc.EventNotify:
+		return state.Notification{Chat: e.Chat, Title: e.Title, Body: e.Body}, true
+	}
+	return nil, false
+}
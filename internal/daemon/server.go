@@ -0,0 +1,499 @@
+// Package daemon keeps the WhatsApp connection in a background process and
+// serves it over a Unix socket (see internal/rpc), so the TUI can come and
+// go without reconnecting or missing messages.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"DevStarByte/internal/client"
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ErrRunning is returned by Listen when another daemon answers on the socket.
+var ErrRunning = errors.New("a daemon is already listening on this socket")
+
+// Server answers requests on the socket from the shared state.
+type Server struct {
+	s    *state.AppState
+	ln   net.Listener
+	path string
+
+	mu    sync.Mutex
+	conns map[*conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// Listen opens the socket at path. A socket file left behind by a daemon
+// that died is replaced; one that still answers is not.
+func Listen(path string) (*Server, error) {
+	if nc, err := net.Dial("unix", path); err == nil {
+		nc.Close()
+		return nil, ErrRunning
+	}
+	os.Remove(path)
+	// The socket is created owner-only: a chmod afterwards would leave a
+	// moment in which others could connect.
+	umask := syscall.Umask(0o177)
+	ln, err := net.Listen("unix", path)
+	syscall.Umask(umask)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{ln: ln, path: path, conns: make(map[*conn]struct{})}, nil
+}
+
+// Serve answers connections from s until ctx is done, then closes them all
+// and removes the socket.
+func (srv *Server) Serve(ctx context.Context, s *state.AppState) error {
+	srv.s = s
+	go func() {
+		<-ctx.Done()
+		srv.ln.Close()
+	}()
+	for {
+		nc, err := srv.ln.Accept()
+		if err != nil {
+			srv.mu.Lock()
+			for c := range srv.conns {
+				c.nc.Close()
+			}
+			srv.mu.Unlock()
+			srv.wg.Wait()
+			os.Remove(srv.path)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		c := &conn{srv: srv, nc: nc, enc: json.NewEncoder(nc)}
+		srv.mu.Lock()
+		srv.conns[c] = struct{}{}
+		srv.mu.Unlock()
+		srv.wg.Add(1)
+		go func() {
+			defer srv.wg.Done()
+			c.serve()
+			srv.mu.Lock()
+			delete(srv.conns, c)
+			srv.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops listening without serving; use it when Serve is never called.
+func (srv *Server) Close() error {
+	err := srv.ln.Close()
+	os.Remove(srv.path)
+	return err
+}
+
+// ── Connections ───────────────────────────────────────────────────────────────
+
+type conn struct {
+	srv *Server
+	nc  net.Conn
+
+	wmu sync.Mutex
+	enc *json.Encoder
+
+	mu      sync.Mutex
+	sub     *state.Subscription
+	focused bool // this connection set the daemon's focused chat
+}
+
+func (c *conn) serve() {
+	s := c.srv.s
+	s.Logger.Info("Daemon: client connected")
+	defer func() {
+		c.unsubscribe()
+		c.mu.Lock()
+		if c.focused {
+			client.SetFocus(s, "")
+		}
+		c.mu.Unlock()
+		c.nc.Close()
+		s.Logger.Info("Daemon: client disconnected")
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	sc := bufio.NewScanner(c.nc)
+	sc.Buffer(nil, 16<<20)
+	for sc.Scan() {
+		var req rpc.Request
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			c.reply(nil, nil, &rpc.Error{Code: rpc.CodeParseError, Message: err.Error()})
+			continue
+		}
+		// Notifications such as focus changes are handled in order; calls
+		// run concurrently so a slow send doesn't hold up the rest, and
+		// their responses carry the request id.
+		if req.ID == nil {
+			c.handle(req)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, rerr := c.handle(req)
+			c.reply(req.ID, result, rerr)
+		}()
+	}
+}
+
+func (c *conn) handle(req rpc.Request) (interface{}, *rpc.Error) {
+	if req.JSONRPC != rpc.Version || req.Method == "" {
+		return nil, &rpc.Error{Code: rpc.CodeInvalidRequest, Message: "not a JSON-RPC 2.0 request"}
+	}
+	h := handlers[req.Method]
+	if h == nil {
+		return nil, &rpc.Error{Code: rpc.CodeMethodNotFound, Message: "unknown method " + req.Method}
+	}
+	result, err := h(c, req.Params)
+	var rerr *rpc.Error
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.As(err, &rerr):
+		return nil, rerr
+	}
+	return nil, &rpc.Error{Code: rpc.CodeActionFailed, Message: err.Error()}
+}
+
+func (c *conn) reply(id json.RawMessage, result interface{}, rerr *rpc.Error) {
+	resp := rpc.Response{JSONRPC: rpc.Version, ID: id, Error: rerr}
+	if id == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	if rerr == nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &rpc.Error{Code: rpc.CodeInternalError, Message: err.Error()}
+		} else {
+			resp.Result = b
+		}
+	}
+	c.write(resp)
+}
+
+func (c *conn) write(v interface{}) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := c.enc.Encode(v); err != nil {
+		c.nc.Close()
+	}
+}
+
+// subscribe streams the bus to the connection until it unsubscribes or
+// goes away.
+func (c *conn) subscribe() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sub != nil {
+		return
+	}
+	sub := c.srv.s.Events.Subscribe()
+	c.sub = sub
+	go func() {
+		for {
+			evts, ok := sub.Next(0)
+			if !ok {
+				return
+			}
+			for _, evt := range evts {
+				if w, ok := toWire(evt); ok {
+					c.write(rpc.Request{JSONRPC: rpc.Version, Method: rpc.MethodEvent, Params: mustJSON(w)})
+				}
+			}
+		}
+	}()
+}
+
+func (c *conn) unsubscribe() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sub != nil {
+		c.sub.Close()
+		c.sub = nil
+	}
+}
+
+func mustJSON(v interface{}) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// ── Methods ───────────────────────────────────────────────────────────────────
+
+type handler func(c *conn, params json.RawMessage) (interface{}, error)
+
+var handlers = map[string]handler{
+	rpc.MethodSessionInfo:  sessionInfo,
+	rpc.MethodSessionFocus: setFocus,
+
+	rpc.MethodChatsList:     listChats,
+	rpc.MethodChatsMarkRead: markRead,
+	rpc.MethodChatsPin:      setPinned,
+	rpc.MethodChatsArchive:  setArchived,
+	rpc.MethodChatsMute:     setMuted,
+	rpc.MethodChatsInfo:     chatInfo,
+
+	rpc.MethodHistory:  history,
+	rpc.MethodSend:     send,
+	rpc.MethodSendFile: sendFile,
+	rpc.MethodReact:    react,
+
+	rpc.MethodGroupCreate:       createGroup,
+	rpc.MethodGroupName:         setGroupName,
+	rpc.MethodGroupDescription:  setGroupDescription,
+	rpc.MethodGroupPhoto:        setGroupPhoto,
+	rpc.MethodGroupParticipants: updateParticipants,
+	rpc.MethodGroupInviteLink:   inviteLink,
+
+	rpc.MethodContactsGet:      getContact,
+	rpc.MethodContactsList:     listContacts,
+	rpc.MethodContactsLIDPN:    pnForLID,
+	rpc.MethodContactsPresence: subscribePresence,
+
+	rpc.MethodScheduleWake: wakeScheduler,
+
+	rpc.MethodSubscribe:   func(c *conn, _ json.RawMessage) (interface{}, error) { c.subscribe(); return true, nil },
+	rpc.MethodUnsubscribe: func(c *conn, _ json.RawMessage) (interface{}, error) { c.unsubscribe(); return true, nil },
+}
+
+// params decodes raw into p, reporting bad input as invalid params.
+func params(raw json.RawMessage, p interface{}) error {
+	if len(raw) == 0 {
+		return &rpc.Error{Code: rpc.CodeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(raw, p); err != nil {
+		return &rpc.Error{Code: rpc.CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func sessionInfo(c *conn, _ json.RawMessage) (interface{}, error) {
+	cl := c.srv.s.Client
+	return rpc.SessionInfo{Own: cl.OwnID(), OwnLID: cl.OwnLID()}, nil
+}
+
+func setFocus(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.FocusParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.focused = p.Chat != ""
+	c.mu.Unlock()
+	client.SetFocus(c.srv.s, p.Chat)
+	return true, nil
+}
+
+func listChats(c *conn, _ json.RawMessage) (interface{}, error) {
+	s := c.srv.s
+	s.ChatsMu.RLock()
+	chats := make([]apptypes.ChatItem, 0, len(s.ChatsMap))
+	for _, ch := range s.ChatsMap {
+		chats = append(chats, *ch)
+	}
+	s.ChatsMu.RUnlock()
+	client.SortChats(chats)
+	return chats, nil
+}
+
+func markRead(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.ChatParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.MarkRead(c.srv.s, p.Chat)
+}
+
+func setPinned(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.FlagParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.SetPinned(c.srv.s, p.Chat, p.On)
+}
+
+func setArchived(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.FlagParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.SetArchived(c.srv.s, p.Chat, p.On)
+}
+
+func setMuted(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.MuteParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	d := time.Duration(p.Seconds) * time.Second
+	if p.Seconds < 0 {
+		d = client.MuteForever
+	}
+	return true, client.SetMuted(c.srv.s, p.Chat, d)
+}
+
+func chatInfo(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.ChatParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return client.GetChatInfo(c.srv.s, p.Chat)
+}
+
+func history(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.HistoryParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	s := c.srv.s
+	s.MessagesMu.RLock()
+	msgs := s.MessagesMap[p.Chat.String()]
+	end := len(msgs)
+	if !p.Before.IsZero() {
+		end = sort.Search(len(msgs), func(i int) bool { return !msgs[i].Timestamp.Before(p.Before) })
+	}
+	start := 0
+	if p.Limit > 0 && end-p.Limit > 0 {
+		start = end - p.Limit
+	}
+	out := append([]apptypes.Message{}, msgs[start:end]...)
+	s.MessagesMu.RUnlock()
+	return out, nil
+}
+
+func send(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.SendParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.SendMessage(c.srv.s, p.Chat, p.Text, p.Mentions...)
+}
+
+func sendFile(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.SendFileParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.SendFile(c.srv.s, p.Chat, p.Path, p.Caption)
+}
+
+func react(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.ReactParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	s := c.srv.s
+	s.MessagesMu.RLock()
+	var target *apptypes.Message
+	for _, m := range s.MessagesMap[p.Chat.String()] {
+		if m.ID == p.MessageID {
+			target = &m
+			break
+		}
+	}
+	s.MessagesMu.RUnlock()
+	if target == nil {
+		return nil, &rpc.Error{Code: rpc.CodeInvalidParams, Message: "unknown message " + p.MessageID}
+	}
+	return true, client.SendReaction(s, p.Chat, *target, p.Emoji)
+}
+
+func createGroup(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.CreateGroupParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return client.CreateGroup(c.srv.s, p.Name, p.Participants)
+}
+
+func setGroupName(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.TextParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.SetGroupName(c.srv.s, p.Chat, p.Text)
+}
+
+func setGroupDescription(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.TextParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.SetGroupDescription(c.srv.s, p.Chat, p.Text)
+}
+
+func setGroupPhoto(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.TextParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.SetGroupPhoto(c.srv.s, p.Chat, p.Text)
+}
+
+func updateParticipants(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.ParticipantsParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.UpdateGroupParticipants(c.srv.s, p.Chat, p.Users, p.Action)
+}
+
+func inviteLink(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.FlagParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return client.GroupInviteLink(c.srv.s, p.Chat, p.On)
+}
+
+func getContact(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.JIDParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return c.srv.s.Client.GetContact(context.Background(), p.JID)
+}
+
+func listContacts(c *conn, _ json.RawMessage) (interface{}, error) {
+	return c.srv.s.Client.GetAllContacts(context.Background())
+}
+
+func pnForLID(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.JIDParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return c.srv.s.Client.GetPNForLID(context.Background(), p.JID)
+}
+
+func subscribePresence(c *conn, raw json.RawMessage) (interface{}, error) {
+	var p rpc.JIDParams
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	return true, client.SubscribePresence(c.srv.s, p.JID)
+}
+
+// wakeScheduler picks up scheduled messages a client added or edited in
+// messages.db.
+func wakeScheduler(c *conn, _ json.RawMessage) (interface{}, error) {
+	c.srv.s.Scheduler.Wake()
+	return true, nil
+}
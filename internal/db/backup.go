@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Snapshot copies the SQLite database at src to dest with SQLite's online
+// backup API. The copy is consistent even while another process writes to
+// src. dest must not exist yet.
+func Snapshot(src, dest string) error {
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return errors.New(dest + " already exists")
+	}
+	from, err := sql.Open("sqlite3", "file:"+src+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer from.Close()
+	to, err := sql.Open("sqlite3", "file:"+dest)
+	if err != nil {
+		return err
+	}
+	defer to.Close()
+
+	ctx := context.Background()
+	fromConn, err := from.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer fromConn.Close()
+	toConn, err := to.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer toConn.Close()
+
+	return toConn.Raw(func(toRaw any) error {
+		return fromConn.Raw(func(fromRaw any) error {
+			b, err := toRaw.(*sqlite3.SQLiteConn).Backup("main", fromRaw.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			// Copy every page in one step, so the snapshot sees a single
+			// state of src.
+			if _, err := b.Step(-1); err != nil {
+				b.Finish()
+				return err
+			}
+			return b.Finish()
+		})
+	})
+}
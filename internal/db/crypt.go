@@ -0,0 +1,372 @@
+package db
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ── Encryption at rest ────────────────────────────────────────────────────────
+
+// EncryptionMode says whether messages.db is encrypted and where its key
+// comes from.
+type EncryptionMode string
+
+const (
+	EncryptionOff        EncryptionMode = ""
+	EncryptionPassphrase EncryptionMode = "passphrase" // derived from a passphrase with Argon2id
+	EncryptionKeyring    EncryptionMode = "keyring"    // a random key kept in the system keyring
+)
+
+var (
+	// ErrWrongKey means the passphrase or keyring key doesn't open the
+	// database.
+	ErrWrongKey = errors.New("wrong passphrase or key")
+	// ErrLocked means the database is encrypted and Unlock wasn't called.
+	ErrLocked = errors.New("messages.db is encrypted and locked")
+)
+
+// An encrypted field is encPrefix and the base64 of nonce and AES-256-GCM
+// ciphertext, sealed with the column's name as additional data. Empty
+// values stay empty, so "keep the old value if the new one is empty"
+// updates work unchanged. Media files start with mediaMagic, then nonce and
+// ciphertext.
+const (
+	encPrefix  = "enc1:"
+	mediaMagic = "WATUIENC"
+	// KeySize is the size of a keyring key.
+	KeySize = 32
+	// lockedText stands in for fields that can't be decrypted.
+	lockedText = "[Encrypted]"
+	verifyText = "whatsapp-tui"
+)
+
+// Argon2id cost for passphrase keys.
+const (
+	kdfTime    = 3
+	kdfMemory  = 64 << 10 // KiB
+	kdfThreads = 4
+)
+
+// encryptedFields are the columns holding message text and names.
+var encryptedFields = map[string][]string{
+	"messages":      {"content", "sender_name"},
+	"chats":         {"last_msg"},
+	"drafts":        {"text"},
+	"scheduled":     {"text"},
+	"webhook_queue": {"body"}, // the whole incoming message as JSON
+	"rules":         {"data"}, // auto-reply texts and patterns as JSON
+}
+
+// Encryption returns how the database is encrypted.
+func (s *Store) Encryption() EncryptionMode {
+	if s == nil {
+		return EncryptionOff
+	}
+	return s.mode
+}
+
+// loadEncryption reads the encryption mode when the store opens.
+func (s *Store) loadEncryption() error {
+	err := s.db.QueryRow(`SELECT mode FROM encryption WHERE id = 1`).Scan(&s.mode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// Unlock opens an encrypted database with a passphrase or keyring key, as
+// its mode needs, and encrypts media files that were left plain.
+func (s *Store) Unlock(secret []byte) error {
+	if s == nil || s.db == nil {
+		return errNoDB
+	}
+	if s.mode == EncryptionOff {
+		return nil
+	}
+	var salt []byte
+	var verifier string
+	if err := s.db.QueryRow(`SELECT salt, verifier FROM encryption WHERE id = 1`).Scan(&salt, &verifier); err != nil {
+		return err
+	}
+	aead, err := newAEAD(s.mode, secret, salt)
+	if err != nil {
+		return err
+	}
+	if v, ok := openField(aead, "verifier", verifier); !ok || v != verifyText {
+		return ErrWrongKey
+	}
+	s.aead = aead
+	if n, err := s.encryptMedia(); err != nil {
+		s.logger.Warning("Failed to encrypt media_cache: " + err.Error())
+	} else if n > 0 {
+		s.logger.Info(fmt.Sprintf("Encrypted %d plain files in media_cache", n))
+	}
+	return nil
+}
+
+// EnableEncryption encrypts a plain database and media_cache/ with a key
+// from secret: a passphrase, or a KeySize key for EncryptionKeyring. The
+// database is vacuumed afterwards so no plain copy stays in free pages.
+func (s *Store) EnableEncryption(mode EncryptionMode, secret []byte) error {
+	if s == nil || s.db == nil {
+		return errNoDB
+	}
+	if s.mode != EncryptionOff {
+		return errors.New("messages.db is already encrypted")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := newAEAD(mode, secret, salt)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Encrypting messages.db...")
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for table, fields := range encryptedFields {
+		if err := encryptTable(tx, aead, table, fields); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO encryption(id, mode, salt, verifier) VALUES(1,?,?,?)`,
+		string(mode), salt, sealField(aead, "verifier", verifyText)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.mode, s.aead = mode, aead
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		s.logger.Warning("Failed to vacuum messages.db: " + err.Error())
+	}
+	if _, err := s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		s.logger.Warning("Failed to checkpoint messages.db: " + err.Error())
+	}
+	n, err := s.encryptMedia()
+	if err != nil {
+		return fmt.Errorf("encrypting media_cache: %w", err)
+	}
+	s.logger.Info(fmt.Sprintf("Encrypted messages.db and %d media files", n))
+	return nil
+}
+
+// encryptTable seals the plain values of fields in every row of table.
+func encryptTable(tx *sql.Tx, aead cipher.AEAD, table string, fields []string) error {
+	const batch = 500
+	for last := int64(-1); ; {
+		rows, err := tx.Query(`SELECT rowid, `+strings.Join(fields, ", ")+` FROM `+table+
+			` WHERE rowid > ? ORDER BY rowid LIMIT ?`, last, batch)
+		if err != nil {
+			return err
+		}
+		type row struct {
+			id     int64
+			values []string
+		}
+		var todo []row
+		for rows.Next() {
+			r := row{values: make([]string, len(fields))}
+			dest := []any{&r.id}
+			for i := range r.values {
+				dest = append(dest, &r.values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return err
+			}
+			todo = append(todo, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(todo) == 0 {
+			return nil
+		}
+		set := strings.Join(fields, " = ?, ") + " = ?"
+		for _, r := range todo {
+			args := make([]any, 0, len(fields)+1)
+			for i, f := range fields {
+				args = append(args, sealField(aead, f, r.values[i]))
+			}
+			if _, err := tx.Exec(`UPDATE `+table+` SET `+set+` WHERE rowid = ?`, append(args, r.id)...); err != nil {
+				return err
+			}
+			last = r.id
+		}
+	}
+}
+
+func newAEAD(mode EncryptionMode, secret, salt []byte) (cipher.AEAD, error) {
+	var key []byte
+	switch mode {
+	case EncryptionPassphrase:
+		if len(secret) == 0 {
+			return nil, errors.New("empty passphrase")
+		}
+		key = argon2.IDKey(secret, salt, kdfTime, kdfMemory, kdfThreads, KeySize)
+	case EncryptionKeyring:
+		if len(secret) != KeySize {
+			return nil, fmt.Errorf("the keyring key must be %d bytes", KeySize)
+		}
+		key = secret
+	default:
+		return nil, fmt.Errorf("unknown encryption mode %q", mode)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// locked reports whether the database is encrypted and Unlock wasn't
+// called.
+func (s *Store) locked() bool {
+	return s.mode != EncryptionOff && s.aead == nil
+}
+
+// seal encrypts a field's value for storage if the database is encrypted.
+func (s *Store) seal(field, v string) string {
+	if s.mode == EncryptionOff || v == "" {
+		return v
+	}
+	if s.locked() {
+		// Never store plain text in an encrypted database.
+		s.logger.Error("Dropped " + field + ": " + ErrLocked.Error())
+		return ""
+	}
+	return sealField(s.aead, field, v)
+}
+
+// open decrypts a stored field's value.
+func (s *Store) open(field, v string) string {
+	if s.mode == EncryptionOff || !strings.HasPrefix(v, encPrefix) {
+		return v
+	}
+	if s.aead == nil {
+		return lockedText
+	}
+	plain, ok := openField(s.aead, field, v)
+	if !ok {
+		s.logger.Warning("Failed to decrypt " + field)
+		return lockedText
+	}
+	return plain
+}
+
+func sealField(aead cipher.AEAD, field, v string) string {
+	if v == "" {
+		return v
+	}
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	return encPrefix + base64.RawStdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(v), []byte(field)))
+}
+
+func openField(aead cipher.AEAD, field, v string) (string, bool) {
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(v, encPrefix))
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", false
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(field))
+	return string(plain), err == nil
+}
+
+// ── Media files ───────────────────────────────────────────────────────────────
+
+// WriteMedia writes a file to media_cache/, encrypted if the database is.
+func (s *Store) WriteMedia(path string, data []byte) error {
+	if s.Encryption() == EncryptionOff {
+		return os.WriteFile(path, data, 0o644)
+	}
+	if s.aead == nil {
+		return ErrLocked
+	}
+	return writeFileAtomic(path, sealMedia(s.aead, data))
+}
+
+// ReadMedia reads a file from media_cache/, decrypting it if it is
+// encrypted.
+func (s *Store) ReadMedia(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !bytes.HasPrefix(data, []byte(mediaMagic)) {
+		return data, err
+	}
+	if s == nil || s.aead == nil {
+		return nil, ErrLocked
+	}
+	data = data[len(mediaMagic):]
+	n := s.aead.NonceSize()
+	if len(data) < n {
+		return nil, ErrWrongKey
+	}
+	plain, err := s.aead.Open(nil, data[:n], data[n:], []byte("media"))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", path, ErrWrongKey)
+	}
+	return plain, nil
+}
+
+func sealMedia(aead cipher.AEAD, data []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	return aead.Seal(append([]byte(mediaMagic), nonce...), nonce, data, []byte("media"))
+}
+
+// encryptMedia encrypts the plain files in media_cache/ and returns how
+// many there were.
+func (s *Store) encryptMedia() (int, error) {
+	n := 0
+	err := filepath.WalkDir("media_cache", func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil || bytes.HasPrefix(data, []byte(mediaMagic)) {
+			return err
+		}
+		n++
+		return writeFileAtomic(p, sealMedia(s.aead, data))
+	})
+	return n, err
+}
+
+// writeFileAtomic replaces path through a temporary file, so a crash
+// leaves either the old or the new content.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+
+	"DevStarByte/internal/types"
+)
+
+var testKey = bytes.Repeat([]byte{7}, KeySize)
+
+// reopen opens a second store on the working directory's messages.db.
+func reopen(t *testing.T) *Store {
+	t.Helper()
+	logger, _ := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	store, err := NewStore(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+// rawContains reports whether any messages.db file holds s in plain text.
+func rawContains(t *testing.T, s string) bool {
+	t.Helper()
+	for _, name := range []string{"messages.db", "messages.db-wal"} {
+		data, err := os.ReadFile(name)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEnableEncryption(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	s.PersistMessage(chat, types.Message{ID: "a", Sender: "Alice Secret", SenderJID: alice, Content: "plain secret", Timestamp: t0})
+	s.UpsertChat(chat, "Alice", false, "plain secret", t0)
+	s.SaveDraft(chat, "draft secret")
+	s.AddScheduled(chat, "scheduled secret", t0)
+	s.EnqueueWebhook("http://127.0.0.1/hook", []byte(`{"content":"queued secret"}`), "")
+	s.SaveRule(0, []byte(`{"reply":"rule secret"}`))
+	os.MkdirAll("media_cache", 0o755)
+	os.WriteFile(filepath.Join("media_cache", "a.jpg"), []byte("jpeg secret"), 0o644)
+
+	if err := s.EnableEncryption(EncryptionKeyring, testKey); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"plain secret", "Alice Secret", "draft secret", "scheduled secret", "queued secret", "rule secret"} {
+		if rawContains(t, secret) {
+			t.Errorf("messages.db still holds %q", secret)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join("media_cache", "a.jpg")); bytes.Contains(data, []byte("secret")) {
+		t.Error("media_cache/a.jpg is still plain")
+	}
+	if err := s.EnableEncryption(EncryptionKeyring, testKey); err == nil {
+		t.Error("encrypted twice")
+	}
+
+	// New writes are encrypted too and read back in plain text.
+	s.PersistMessage(chat, types.Message{ID: "b", Content: "later secret", Timestamp: t0.Add(time.Minute)})
+	if rawContains(t, "later secret") {
+		t.Error("new message stored in plain text")
+	}
+	msgs := s.LoadMessages(chat, 10)
+	if len(msgs) != 2 || msgs[0].Content != "plain secret" || msgs[0].Sender != "Alice Secret" || msgs[1].Content != "later secret" {
+		t.Errorf("messages = %+v", msgs)
+	}
+	if chats := s.LoadChats(); len(chats) != 1 || chats[0].LastMsg != "plain secret" {
+		t.Errorf("chats = %+v", chats)
+	}
+	if d := s.LoadDrafts()[chat]; d != "draft secret" {
+		t.Errorf("draft = %q", d)
+	}
+	if sch := s.DueScheduled(t0); len(sch) != 1 || sch[0].Text != "scheduled secret" {
+		t.Errorf("scheduled = %+v", sch)
+	}
+	if q := s.DueWebhooks(time.Now(), 10); len(q) != 1 || string(q[0].Body) != `{"content":"queued secret"}` {
+		t.Errorf("webhook queue = %+v", q)
+	}
+	if _, err := s.SaveRule(0, []byte(`{"reply":"later rule"}`)); err != nil || rawContains(t, "later rule") {
+		t.Errorf("new rule stored in plain text: %v", err)
+	}
+	if r := s.LoadRules(); len(r) != 2 || string(r[0].Data) != `{"reply":"rule secret"}` || string(r[1].Data) != `{"reply":"later rule"}` {
+		t.Errorf("rules = %+v", r)
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	s.PersistMessage(chat, types.Message{ID: "a", Content: "hello", Timestamp: t0})
+	if err := s.EnableEncryption(EncryptionPassphrase, []byte("right")); err != nil {
+		t.Fatal(err)
+	}
+
+	locked := reopen(t)
+	if locked.Encryption() != EncryptionPassphrase {
+		t.Fatalf("mode = %q", locked.Encryption())
+	}
+	if msgs := locked.LoadMessages(chat, 10); len(msgs) != 1 || msgs[0].Content != lockedText {
+		t.Errorf("locked messages = %+v", msgs)
+	}
+	// A locked store drops text rather than storing it in plain.
+	locked.PersistMessage(chat, types.Message{ID: "b", Content: "dropped", Timestamp: t0.Add(time.Minute)})
+	if rawContains(t, "dropped") {
+		t.Error("locked store wrote plain text")
+	}
+	if _, err := locked.AddScheduled(chat, "later", t0); !errors.Is(err, ErrLocked) {
+		t.Errorf("locked AddScheduled: err = %v", err)
+	}
+	if _, err := locked.SaveRule(0, []byte(`{}`)); !errors.Is(err, ErrLocked) {
+		t.Errorf("locked SaveRule: err = %v", err)
+	}
+	if err := locked.Unlock([]byte("wrong")); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("wrong passphrase: err = %v", err)
+	}
+	if err := locked.Unlock([]byte("right")); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := locked.LoadMessages(chat, 1); len(msgs) != 1 || msgs[0].Content != "hello" {
+		t.Errorf("unlocked messages = %+v", msgs)
+	}
+}
+
+func TestEncryptedImportDedup(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	if err := s.EnableEncryption(EncryptionKeyring, testKey); err != nil {
+		t.Fatal(err)
+	}
+	s.PersistMessage(chat, types.Message{ID: "live", SenderJID: alice, Content: "hi", Timestamp: t0})
+	imported := []types.Message{
+		{ID: ImportedIDPrefix + "1", SenderJID: alice, Content: "hi", Timestamp: t0.Truncate(time.Minute)},
+		{ID: ImportedIDPrefix + "2", SenderJID: alice, Content: "new", Timestamp: t0.Add(time.Hour)},
+	}
+	n, err := s.ImportMessages(chat, imported, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("added %d, want 1", n)
+	}
+}
+
+func TestMedia(t *testing.T) {
+	s := newTestStore(t)
+	os.MkdirAll("media_cache", 0o755)
+	path := filepath.Join("media_cache", "x.jpg")
+	if err := s.WriteMedia(path, []byte("plain")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := s.ReadMedia(path); string(data) != "plain" || err != nil {
+		t.Errorf("plain media = %q, %v", data, err)
+	}
+	if err := s.EnableEncryption(EncryptionKeyring, testKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteMedia(path, []byte("image")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.HasPrefix(data, []byte(mediaMagic)) {
+		t.Error("media written in plain")
+	}
+	if data, err := s.ReadMedia(path); string(data) != "image" || err != nil {
+		t.Errorf("media = %q, %v", data, err)
+	}
+	if _, err := reopen(t).ReadMedia(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("locked ReadMedia: err = %v", err)
+	}
+}
+
+func TestSearchMessages(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		s := newTestStore(t)
+		if encrypted {
+			if err := s.EnableEncryption(EncryptionKeyring, testKey); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s.PersistMessage(alice.String(), types.Message{ID: "a1", Sender: "Alice", Content: "Lunch at noon?", Timestamp: t0})
+		s.PersistMessage(alice.String(), types.Message{ID: "a2", Sender: "Alice", Content: "no", Timestamp: t0.Add(time.Minute)})
+		s.PersistMessage(group.String(), types.Message{ID: "g1", Sender: "Bob", Content: "lunch is ready", Timestamp: t0.Add(2 * time.Minute)})
+		s.ImportMessages(alice.String(), []types.Message{
+			{ID: ImportedIDPrefix + "1", Sender: "Alice", Content: "LUNCH last year", Timestamp: t0.AddDate(-1, 0, 0)},
+		}, time.Minute)
+
+		ids := func(query, chat string, limit int) []string {
+			t.Helper()
+			res, err := s.SearchMessages(query, chat, limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out []string
+			for _, r := range res {
+				out = append(out, r.Chat+"/"+r.Message.ID)
+			}
+			return out
+		}
+		a, g := alice.String(), group.String()
+		for _, tt := range []struct {
+			query, chat string
+			limit       int
+			want        []string
+		}{
+			{"lunch", "", 10, []string{g + "/g1", a + "/a1", a + "/" + ImportedIDPrefix + "1"}},
+			{"lunch", "", 2, []string{g + "/g1", a + "/a1"}},
+			{"Lunch", a, 10, []string{a + "/a1", a + "/" + ImportedIDPrefix + "1"}},
+			{"noon?", "", 10, []string{a + "/a1"}},
+			{"dinner", "", 10, nil},
+			{" ", "", 10, nil},
+		} {
+			if got := ids(tt.query, tt.chat, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("encrypted=%v: SearchMessages(%q, %q, %d) = %v, want %v", encrypted, tt.query, tt.chat, tt.limit, got, tt.want)
+			}
+		}
+		if res, _ := s.SearchMessages("noon", "", 1); len(res) != 1 || res[0].Message.Sender != "Alice" || res[0].Message.Content != "Lunch at noon?" {
+			t.Errorf("encrypted=%v: result = %+v", encrypted, res)
+		}
+	}
+
+	// Without the key there is nothing to match.
+	if _, err := reopen(t).SearchMessages("lunch", "", 10); !errors.Is(err, ErrLocked) {
+		t.Errorf("locked SearchMessages: err = %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+// Package dbtest opens message stores for tests.
+package dbtest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/StarGames2025/Logger"
+
+	"DevStarByte/internal/db"
+)
+
+// New changes to a fresh working directory and opens a store there, closed
+// when the test ends. The logger discards everything below errors.
+func New(t testing.TB) (*Logger.Logger, *db.Store) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	logger, err := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := db.NewStore(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+	return logger, store
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	watypes "go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/types"
+)
+
+// ImportedIDPrefix starts the synthetic IDs of messages imported from chat
+// exports, which have no WhatsApp message IDs.
+const ImportedIDPrefix = "import-"
+
+// ImportMessages adds imported messages to a chat in one transaction and
+// returns how many were new. A message is skipped if its ID is already
+// stored, or if the chat has a message that didn't come from an import
+// with the same content and direction sent within precision after its
+// timestamp: exports round times down to the minute or second. Repeats of
+// the same text in that window are matched one to one.
+func (s *Store) ImportMessages(chatJID string, msgs []types.Message, precision time.Duration) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, errNoDB
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	type key struct {
+		ts      int64
+		content string
+		fromMe  bool
+	}
+	seen := make(map[key]int)
+	added := 0
+	for _, m := range msgs {
+		fromMe, isSystem := 0, 0
+		if m.FromMe {
+			fromMe = 1
+		}
+		if m.System {
+			isSystem = 1
+		}
+		k := key{m.Timestamp.Unix(), m.Content, m.FromMe}
+		seen[k]++
+		similar, err := s.countSimilar(tx, chatJID, m, fromMe, precision)
+		if err != nil {
+			return 0, err
+		}
+		if similar >= seen[k] {
+			continue
+		}
+		res, err := tx.Exec(
+			`INSERT OR IGNORE INTO messages(id, chat_jid, sender_jid, sender_name, content, timestamp, from_me, image_path, is_system, status)
+			 VALUES(?,?,?,?,?,?,?,?,?,?)`,
+			m.ID, chatJID, m.SenderJID.String(), s.seal("sender_name", m.Sender), s.seal("content", m.Content),
+			m.Timestamp.Unix(), fromMe, m.ImagePath, isSystem, int(m.Status),
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			added++
+		}
+	}
+	return added, tx.Commit()
+}
+
+// countSimilar counts the chat's messages that didn't come from an import
+// with m's content and direction, sent within precision after m. Content
+// is compared after decrypting, as encrypted values never match.
+func (s *Store) countSimilar(tx *sql.Tx, chatJID string, m types.Message, fromMe int, precision time.Duration) (int, error) {
+	rows, err := tx.Query(
+		`SELECT content FROM messages
+		 WHERE chat_jid = ? AND timestamp >= ? AND timestamp < ? AND from_me = ? AND id NOT LIKE ?`,
+		chatJID, m.Timestamp.Unix(), m.Timestamp.Add(precision).Unix(), fromMe, ImportedIDPrefix+"%",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return 0, err
+		}
+		if s.open("content", content) == m.Content {
+			n++
+		}
+	}
+	return n, rows.Err()
+}
+
+// SenderJIDs maps the names people had in stored messages to their JIDs.
+// Names used by more than one JID are left out.
+func (s *Store) SenderJIDs() map[string]watypes.JID {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	rows, err := s.db.Query(
+		`SELECT DISTINCT sender_name, sender_jid FROM messages
+		 WHERE from_me = 0 AND sender_name != '' AND sender_jid != ''`,
+	)
+	if err != nil {
+		s.logger.Error("Failed to load senders: " + err.Error())
+		return nil
+	}
+	defer rows.Close()
+	out := make(map[string]watypes.JID)
+	ambiguous := make(map[string]bool)
+	for rows.Next() {
+		var name, jidStr string
+		if err := rows.Scan(&name, &jidStr); err != nil {
+			continue
+		}
+		jid, err := watypes.ParseJID(jidStr)
+		if err != nil {
+			continue
+		}
+		name = strings.ToLower(s.open("sender_name", name))
+		if prev, ok := out[name]; ok && prev != jid {
+			ambiguous[name] = true
+		}
+		out[name] = jid
+	}
+	for name := range ambiguous {
+		delete(out, name)
+	}
+	return out
+}
+
+// HasChat reports whether the chat is stored.
+func (s *Store) HasChat(jid string) bool {
+	if s == nil || s.db == nil {
+		return false
+	}
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM chats WHERE jid = ?`, jid).Scan(&n); err != nil {
+		s.logger.Error("Failed to look up chat: " + err.Error())
+		return false
+	}
+	return n > 0
+}
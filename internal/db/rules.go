@@ -0,0 +1,69 @@
+package db
+
+import "errors"
+
+// errNoDB is returned by writes that need the database when it failed to
+// open.
+var errNoDB = errors.New("messages.db is not open")
+
+// StoredRule is an auto-reply rule as JSON; the rules package owns the
+// format.
+type StoredRule struct {
+	ID   int64
+	Data []byte
+}
+
+// LoadRules returns all rules in the order they were created, or none
+// while the database is locked.
+func (s *Store) LoadRules() []StoredRule {
+	if s == nil || s.db == nil || s.locked() {
+		return nil
+	}
+	rows, err := s.db.Query(`SELECT id, data FROM rules ORDER BY id`)
+	if err != nil {
+		s.logger.Error("Failed to load rules: " + err.Error())
+		return nil
+	}
+	defer rows.Close()
+	var out []StoredRule
+	for rows.Next() {
+		var r StoredRule
+		var data string
+		if err := rows.Scan(&r.ID, &data); err == nil {
+			r.Data = []byte(s.open("data", data))
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// SaveRule updates the rule with id, or adds it if id is 0, and returns its
+// ID.
+func (s *Store) SaveRule(id int64, data []byte) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errNoDB
+	}
+	if s.locked() {
+		return 0, ErrLocked
+	}
+	sealed := s.seal("data", string(data))
+	if id != 0 {
+		_, err := s.db.Exec(`UPDATE rules SET data = ? WHERE id = ?`, sealed, id)
+		return id, err
+	}
+	res, err := s.db.Exec(`INSERT INTO rules(data) VALUES(?)`, sealed)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// DeleteRule removes a rule.
+func (s *Store) DeleteRule(id int64) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if _, err := s.db.Exec(`DELETE FROM rules WHERE id = ?`, id); err != nil {
+		s.logger.Error("Failed to delete rule: " + err.Error())
+	}
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ScheduleStatus is where a scheduled message is in its life.
+type ScheduleStatus int
+
+const (
+	SchedulePending ScheduleStatus = iota
+	ScheduleSending                // claimed by the scheduler
+	ScheduleFailed                 // given up on; can be edited to try again
+)
+
+// ErrScheduleGone is returned when a scheduled message was sent or
+// cancelled in the meantime.
+var ErrScheduleGone = errors.New("the scheduled message was already sent or cancelled")
+
+// ScheduledMessage is a message waiting to be sent at SendAt.
+type ScheduledMessage struct {
+	ID       int64
+	Chat     string
+	Text     string
+	SendAt   time.Time
+	Status   ScheduleStatus
+	Attempts int       // failed attempts so far
+	NextTry  time.Time // SendAt, or later after a failure
+	Error    string    // the last failure
+}
+
+const scheduledColumns = `id, chat_jid, text, send_at, status, attempts, next_try, last_error`
+
+func (s *Store) scanScheduled(rows *sql.Rows) []ScheduledMessage {
+	var out []ScheduledMessage
+	for rows.Next() {
+		var m ScheduledMessage
+		var sendAt, next int64
+		if err := rows.Scan(&m.ID, &m.Chat, &m.Text, &sendAt, &m.Status, &m.Attempts, &next, &m.Error); err != nil {
+			continue
+		}
+		m.Text = s.open("text", m.Text)
+		m.SendAt, m.NextTry = time.UnixMilli(sendAt), time.UnixMilli(next)
+		out = append(out, m)
+	}
+	return out
+}
+
+// AddScheduled stores text to be sent to chat at at, and returns its ID.
+func (s *Store) AddScheduled(chat, text string, at time.Time) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errNoDB
+	}
+	if s.locked() {
+		return 0, ErrLocked
+	}
+	res, err := s.db.Exec(
+		`INSERT INTO scheduled(chat_jid, text, send_at, next_try) VALUES(?,?,?,?)`,
+		chat, s.seal("text", text), at.UnixMilli(), at.UnixMilli(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// LoadScheduled returns the messages that are pending or failed, in the
+// order they are due.
+func (s *Store) LoadScheduled() []ScheduledMessage {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	rows, err := s.db.Query(
+		`SELECT `+scheduledColumns+` FROM scheduled WHERE status != ? ORDER BY send_at, id`,
+		ScheduleSending,
+	)
+	if err != nil {
+		s.logger.Error("Failed to load scheduled messages: " + err.Error())
+		return nil
+	}
+	defer rows.Close()
+	return s.scanScheduled(rows)
+}
+
+// DueScheduled returns the pending messages due at now, oldest first. A
+// locked store has none, so no placeholder text is ever sent.
+func (s *Store) DueScheduled(now time.Time) []ScheduledMessage {
+	if s == nil || s.db == nil || s.locked() {
+		return nil
+	}
+	rows, err := s.db.Query(
+		`SELECT `+scheduledColumns+` FROM scheduled
+		 WHERE status = ? AND next_try <= ? ORDER BY next_try, id`,
+		SchedulePending, now.UnixMilli(),
+	)
+	if err != nil {
+		s.logger.Error("Failed to load due messages: " + err.Error())
+		return nil
+	}
+	defer rows.Close()
+	return s.scanScheduled(rows)
+}
+
+// NextScheduledTime returns when the next pending message is due; false if
+// there is none.
+func (s *Store) NextScheduledTime() (time.Time, bool) {
+	if s == nil || s.db == nil {
+		return time.Time{}, false
+	}
+	var next sql.NullInt64
+	err := s.db.QueryRow(`SELECT MIN(next_try) FROM scheduled WHERE status = ?`, SchedulePending).Scan(&next)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.logger.Error("Failed to read scheduled messages: " + err.Error())
+	}
+	if !next.Valid {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(next.Int64), true
+}
+
+// ClaimScheduled marks a pending message as being sent. It reports false if
+// the message was edited, cancelled or claimed in the meantime.
+func (s *Store) ClaimScheduled(id int64) bool {
+	if s == nil || s.db == nil {
+		return false
+	}
+	res, err := s.db.Exec(`UPDATE scheduled SET status = ? WHERE id = ? AND status = ?`,
+		ScheduleSending, id, SchedulePending)
+	if err != nil {
+		s.logger.Error("Failed to claim scheduled message: " + err.Error())
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n == 1
+}
+
+// RetryScheduled records a failed attempt to send a claimed message. It is
+// tried again at next, or marked failed if next is zero.
+func (s *Store) RetryScheduled(id int64, attempts int, next time.Time, errText string) {
+	if s == nil || s.db == nil {
+		return
+	}
+	status := SchedulePending
+	if next.IsZero() {
+		status = ScheduleFailed
+	}
+	if _, err := s.db.Exec(
+		`UPDATE scheduled SET status = ?, attempts = ?, next_try = ?, last_error = ? WHERE id = ?`,
+		status, attempts, next.UnixMilli(), errText, id,
+	); err != nil {
+		s.logger.Error("Failed to reschedule message: " + err.Error())
+	}
+}
+
+// ResetScheduled returns messages left claimed by a crash to pending.
+func (s *Store) ResetScheduled() {
+	if s == nil || s.db == nil {
+		return
+	}
+	if _, err := s.db.Exec(`UPDATE scheduled SET status = ? WHERE status = ?`,
+		SchedulePending, ScheduleSending); err != nil {
+		s.logger.Error("Failed to reset scheduled messages: " + err.Error())
+	}
+}
+
+// UpdateScheduled changes the text and time of a message that isn't being
+// sent, and makes a failed one pending again.
+func (s *Store) UpdateScheduled(id int64, text string, at time.Time) error {
+	if s == nil || s.db == nil {
+		return errNoDB
+	}
+	if s.locked() {
+		return ErrLocked
+	}
+	res, err := s.db.Exec(
+		`UPDATE scheduled SET text = ?, send_at = ?, next_try = ?, status = ?, attempts = 0, last_error = ''
+		 WHERE id = ? AND status != ?`,
+		s.seal("text", text), at.UnixMilli(), at.UnixMilli(), SchedulePending, id, ScheduleSending,
+	)
+	return scheduleResult(res, err)
+}
+
+// DeleteScheduled removes a message that was sent, or cancels one that
+// isn't being sent.
+func (s *Store) DeleteScheduled(id int64, sent bool) error {
+	if s == nil || s.db == nil {
+		return errNoDB
+	}
+	if sent {
+		_, err := s.db.Exec(`DELETE FROM scheduled WHERE id = ?`, id)
+		return err
+	}
+	res, err := s.db.Exec(`DELETE FROM scheduled WHERE id = ? AND status != ?`, id, ScheduleSending)
+	return scheduleResult(res, err)
+}
+
+func scheduleResult(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrScheduleGone
+	}
+	return nil
+}
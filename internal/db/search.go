@@ -0,0 +1,67 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	watypes "go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/types"
+)
+
+// SearchResult is a message found by SearchMessages.
+type SearchResult struct {
+	Chat    string        `json:"chat"`
+	Message types.Message `json:"message"`
+}
+
+// SearchMessages returns up to limit messages whose text contains query,
+// ignoring case, newest first. A non-empty chat limits the search to that
+// chat. Encrypted text can't be matched in SQL, so the rows are read one at
+// a time, decrypted and matched here; only the matches are kept.
+func (s *Store) SearchMessages(query, chat string, limit int) ([]SearchResult, error) {
+	if s == nil || s.db == nil {
+		return nil, errNoDB
+	}
+	if s.locked() {
+		return nil, ErrLocked
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" || limit <= 0 {
+		return nil, nil
+	}
+	rows, err := s.db.Query(
+		`SELECT id, chat_jid, sender_jid, sender_name, content, timestamp, from_me, image_path, is_system, status
+		 FROM messages WHERE ? = '' OR chat_jid = ?
+		 ORDER BY timestamp DESC, rowid DESC`,
+		chat, chat,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SearchResult
+	for rows.Next() && len(out) < limit {
+		var r SearchResult
+		m := &r.Message
+		var senderJID string
+		var ts int64
+		var fromMe, isSystem, status int
+		if err := rows.Scan(&m.ID, &r.Chat, &senderJID, &m.Sender, &m.Content, &ts, &fromMe, &m.ImagePath, &isSystem,
+			&status); err != nil {
+			continue
+		}
+		m.Content = s.open("content", m.Content)
+		if !strings.Contains(strings.ToLower(m.Content), query) {
+			continue
+		}
+		m.SenderJID, _ = watypes.ParseJID(senderJID)
+		m.Sender = s.open("sender_name", m.Sender)
+		m.Timestamp = time.Unix(ts, 0)
+		m.FromMe = fromMe != 0
+		m.System = isSystem != 0
+		m.Status = types.MessageStatus(status)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
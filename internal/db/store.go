@@ -1,9 +1,13 @@
 package db
 
 import (
+	"crypto/cipher"
 	"database/sql"
 	"fmt"
+	"math"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/StarGames2025/Logger"
@@ -17,6 +21,8 @@ import (
 type Store struct {
 	db     *sql.DB
 	logger *Logger.Logger
+	mode   EncryptionMode
+	aead   cipher.AEAD // set by Unlock or EnableEncryption
 }
 
 // NewStore opens (or creates) the message database and returns a Store.
@@ -57,17 +63,88 @@ func NewStore(logger *Logger.Logger) (*Store, error) {
 		database.Close()
 		return nil, err
 	}
+	if _, err = database.Exec(`CREATE TABLE IF NOT EXISTS drafts (
+		chat_jid   TEXT PRIMARY KEY,
+		text       TEXT NOT NULL,
+		updated_ts INTEGER NOT NULL DEFAULT 0
+	)`); err != nil {
+		database.Close()
+		return nil, err
+	}
+	if _, err = database.Exec(`CREATE TABLE IF NOT EXISTS emoji_recent (
+		emoji   TEXT PRIMARY KEY,
+		used_ts INTEGER NOT NULL
+	)`); err != nil {
+		database.Close()
+		return nil, err
+	}
+	if _, err = database.Exec(`CREATE TABLE IF NOT EXISTS webhook_queue (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		url        TEXT    NOT NULL,
+		body       BLOB    NOT NULL,
+		signature  TEXT    NOT NULL DEFAULT '',
+		attempts   INTEGER NOT NULL DEFAULT 0,
+		next_try   INTEGER NOT NULL
+	)`); err != nil {
+		database.Close()
+		return nil, err
+	}
+	if _, err = database.Exec(`CREATE TABLE IF NOT EXISTS rules (
+		id   INTEGER PRIMARY KEY AUTOINCREMENT,
+		data TEXT NOT NULL
+	)`); err != nil {
+		database.Close()
+		return nil, err
+	}
+	if _, err = database.Exec(`CREATE TABLE IF NOT EXISTS scheduled (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_jid   TEXT    NOT NULL,
+		text       TEXT    NOT NULL,
+		send_at    INTEGER NOT NULL,
+		status     INTEGER NOT NULL DEFAULT 0,
+		attempts   INTEGER NOT NULL DEFAULT 0,
+		next_try   INTEGER NOT NULL,
+		last_error TEXT    NOT NULL DEFAULT ''
+	)`); err != nil {
+		database.Close()
+		return nil, err
+	}
+	if _, err = database.Exec(`CREATE TABLE IF NOT EXISTS encryption (
+		id       INTEGER PRIMARY KEY CHECK (id = 1),
+		mode     TEXT NOT NULL,
+		salt     BLOB NOT NULL,
+		verifier TEXT NOT NULL
+	)`); err != nil {
+		database.Close()
+		return nil, err
+	}
 	logger.Info("Message database initialised successfully")
 
 	// Migrate: add image_path column if missing (for existing databases).
 	_, _ = database.Exec(`ALTER TABLE messages ADD COLUMN image_path TEXT NOT NULL DEFAULT ''`)
+	// Migrate: add is_system column for group notices.
+	_, _ = database.Exec(`ALTER TABLE messages ADD COLUMN is_system INTEGER NOT NULL DEFAULT 0`)
+	// Migrate: add delivery status of sent messages.
+	_, _ = database.Exec(`ALTER TABLE messages ADD COLUMN status INTEGER NOT NULL DEFAULT 0`)
+	// Migrate: add pin / archive / mute settings synced from the phone.
+	_, _ = database.Exec(`ALTER TABLE chats ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0`)
+	_, _ = database.Exec(`ALTER TABLE chats ADD COLUMN archived INTEGER NOT NULL DEFAULT 0`)
+	_, _ = database.Exec(`ALTER TABLE chats ADD COLUMN muted_until INTEGER NOT NULL DEFAULT 0`)
+	// Migrate: add unread count and last-read message ID.
+	_, _ = database.Exec(`ALTER TABLE chats ADD COLUMN unread INTEGER NOT NULL DEFAULT 0`)
+	_, _ = database.Exec(`ALTER TABLE chats ADD COLUMN last_read_id TEXT NOT NULL DEFAULT ''`)
 
 	// Ensure media cache directory exists.
 	if err := os.MkdirAll("media_cache", 0o755); err != nil {
 		logger.Warning("Failed to create media_cache dir: " + err.Error())
 	}
 
-	return &Store{db: database, logger: logger}, nil
+	s := &Store{db: database, logger: logger}
+	if err := s.loadEncryption(); err != nil {
+		database.Close()
+		return nil, err
+	}
+	return s, nil
 }
 
 // Close closes the underlying database connection.
@@ -95,13 +172,61 @@ func (s *Store) UpsertChat(jid string, name string, isGroup bool, lastMsg string
 		   is_group = excluded.is_group,
 		   last_msg = CASE WHEN excluded.last_ts >= last_ts THEN excluded.last_msg ELSE last_msg END,
 		   last_ts  = CASE WHEN excluded.last_ts >= last_ts THEN excluded.last_ts  ELSE last_ts  END`,
-		jid, name, ig, lastMsg, lastTs.Unix(),
+		jid, name, ig, s.seal("last_msg", lastMsg), lastTs.Unix(),
 	)
 	if err != nil {
 		s.logger.Error("Failed to upsert chat: " + err.Error())
 	}
 }
 
+// SaveChatSettings stores a chat's pin, archive and mute state. A zero
+// mutedUntil means not muted.
+func (s *Store) SaveChatSettings(jid string, pinned, archived bool, mutedUntil time.Time) {
+	if s == nil || s.db == nil {
+		return
+	}
+	pin, arch := 0, 0
+	if pinned {
+		pin = 1
+	}
+	if archived {
+		arch = 1
+	}
+	var muted int64
+	if !mutedUntil.IsZero() {
+		muted = mutedUntil.Unix()
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO chats(jid, pinned, archived, muted_until) VALUES(?,?,?,?)
+		 ON CONFLICT(jid) DO UPDATE SET
+		   pinned      = excluded.pinned,
+		   archived    = excluded.archived,
+		   muted_until = excluded.muted_until`,
+		jid, pin, arch, muted,
+	)
+	if err != nil {
+		s.logger.Error("Failed to save chat settings: " + err.Error())
+	}
+}
+
+// SaveReadState stores a chat's unread count and the ID of the last message
+// the user has read.
+func (s *Store) SaveReadState(jid string, unread int, lastReadID string) {
+	if s == nil || s.db == nil {
+		return
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO chats(jid, unread, last_read_id) VALUES(?,?,?)
+		 ON CONFLICT(jid) DO UPDATE SET
+		   unread       = excluded.unread,
+		   last_read_id = excluded.last_read_id`,
+		jid, unread, lastReadID,
+	)
+	if err != nil {
+		s.logger.Error("Failed to save read state: " + err.Error())
+	}
+}
+
 // LoadChats returns all persisted chats, ordered by last message time.
 func (s *Store) LoadChats() []types.ChatItem {
 	if s == nil || s.db == nil {
@@ -109,7 +234,8 @@ func (s *Store) LoadChats() []types.ChatItem {
 	}
 	s.logger.Debug("Loading chats from database...")
 	rows, err := s.db.Query(
-		`SELECT jid, name, is_group, last_msg, last_ts FROM chats ORDER BY last_ts DESC`,
+		`SELECT jid, name, is_group, last_msg, last_ts, pinned, archived, muted_until, unread, last_read_id
+		 FROM chats ORDER BY last_ts DESC`,
 	)
 	if err != nil {
 		return nil
@@ -117,10 +243,11 @@ func (s *Store) LoadChats() []types.ChatItem {
 	defer rows.Close()
 	var items []types.ChatItem
 	for rows.Next() {
-		var jidStr, name, lastMsg string
-		var isGroup int
-		var lastTs int64
-		if err := rows.Scan(&jidStr, &name, &isGroup, &lastMsg, &lastTs); err != nil {
+		var jidStr, name, lastMsg, lastReadID string
+		var isGroup, pinned, archived, unread int
+		var lastTs, mutedUntil int64
+		if err := rows.Scan(&jidStr, &name, &isGroup, &lastMsg, &lastTs, &pinned, &archived, &mutedUntil,
+			&unread, &lastReadID); err != nil {
 			continue
 		}
 		jid, err := watypes.ParseJID(jidStr)
@@ -128,12 +255,19 @@ func (s *Store) LoadChats() []types.ChatItem {
 			continue
 		}
 		items = append(items, types.ChatItem{
-			JID:      jid,
-			Name:     name,
-			IsGroup:  isGroup != 0,
-			LastMsg:  lastMsg,
-			LastTime: time.Unix(lastTs, 0),
+			JID:        jid,
+			Name:       name,
+			IsGroup:    isGroup != 0,
+			LastMsg:    s.open("last_msg", lastMsg),
+			LastTime:   time.Unix(lastTs, 0),
+			Pinned:     pinned != 0,
+			Archived:   archived != 0,
+			Unread:     unread,
+			LastReadID: lastReadID,
 		})
+		if mutedUntil > 0 {
+			items[len(items)-1].MutedUntil = time.Unix(mutedUntil, 0)
+		}
 	}
 	s.logger.Info(fmt.Sprintf("Loaded %d chats from database", len(items)))
 	return items
@@ -149,21 +283,46 @@ func (s *Store) PersistMessage(chatJID string, msg types.Message) {
 	if msg.FromMe {
 		fromMe = 1
 	}
+	isSystem := 0
+	if msg.System {
+		isSystem = 1
+	}
 	_, err := s.db.Exec(
-		`INSERT INTO messages(id, chat_jid, sender_jid, sender_name, content, timestamp, from_me, image_path)
-		 VALUES(?,?,?,?,?,?,?,?)
+		`INSERT INTO messages(id, chat_jid, sender_jid, sender_name, content, timestamp, from_me, image_path, is_system, status)
+		 VALUES(?,?,?,?,?,?,?,?,?,?)
 		 ON CONFLICT(id, chat_jid) DO UPDATE SET
 		   image_path  = CASE WHEN excluded.image_path != '' THEN excluded.image_path ELSE image_path END,
 		   sender_name = CASE WHEN excluded.sender_name != '' THEN excluded.sender_name ELSE sender_name END,
-		   content     = CASE WHEN excluded.content     != '' THEN excluded.content     ELSE content     END`,
-		msg.ID, chatJID, msg.SenderJID.String(), msg.Sender, msg.Content,
-		msg.Timestamp.Unix(), fromMe, msg.ImagePath,
+		   content     = CASE WHEN excluded.content     != '' THEN excluded.content     ELSE content     END,
+		   status      = MAX(status, excluded.status)`,
+		msg.ID, chatJID, msg.SenderJID.String(), s.seal("sender_name", msg.Sender), s.seal("content", msg.Content),
+		msg.Timestamp.Unix(), fromMe, msg.ImagePath, isSystem, int(msg.Status),
 	)
 	if err != nil {
 		s.logger.Error("Failed to persist message: " + err.Error())
 	}
 }
 
+// UpdateMessageStatus raises the delivery status of sent messages. A status
+// never goes back, e.g. a late delivery receipt after the read receipt.
+func (s *Store) UpdateMessageStatus(chatJID string, ids []string, status types.MessageStatus) {
+	if s == nil || s.db == nil || len(ids) == 0 {
+		return
+	}
+	args := []interface{}{int(status), chatJID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err := s.db.Exec(
+		`UPDATE messages SET status = MAX(status, ?)
+		 WHERE chat_jid = ? AND id IN (?`+strings.Repeat(",?", len(ids)-1)+`)`,
+		args...,
+	)
+	if err != nil {
+		s.logger.Error("Failed to update message status: " + err.Error())
+	}
+}
+
 // LoadMessages returns messages for a specific chat, ordered by time.
 func (s *Store) LoadMessages(chatJID string, limit int) []types.Message {
 	if s == nil || s.db == nil {
@@ -171,7 +330,7 @@ func (s *Store) LoadMessages(chatJID string, limit int) []types.Message {
 	}
 	s.logger.Debug("Loading messages from DB for chat: " + chatJID)
 	rows, err := s.db.Query(
-		`SELECT id, sender_jid, sender_name, content, timestamp, from_me, image_path
+		`SELECT id, sender_jid, sender_name, content, timestamp, from_me, image_path, is_system, status
 		 FROM messages WHERE chat_jid = ? ORDER BY timestamp ASC LIMIT ?`,
 		chatJID, limit,
 	)
@@ -184,13 +343,119 @@ func (s *Store) LoadMessages(chatJID string, limit int) []types.Message {
 		var m types.Message
 		var senderJID string
 		var ts int64
-		var fromMe int
-		if err := rows.Scan(&m.ID, &senderJID, &m.Sender, &m.Content, &ts, &fromMe, &m.ImagePath); err != nil {
+		var fromMe, isSystem, status int
+		if err := rows.Scan(&m.ID, &senderJID, &m.Sender, &m.Content, &ts, &fromMe, &m.ImagePath, &isSystem,
+			&status); err != nil {
 			continue
 		}
 		m.SenderJID, _ = watypes.ParseJID(senderJID)
+		m.Sender, m.Content = s.open("sender_name", m.Sender), s.open("content", m.Content)
 		m.Timestamp = time.Unix(ts, 0)
 		m.FromMe = fromMe != 0
+		m.System = isSystem != 0
+		m.Status = types.MessageStatus(status)
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+// LoadMessagesBetween returns the messages of a chat sent in [from, to),
+// oldest first. A zero from or to leaves that end open. With limit > 0 only
+// the newest limit messages are returned.
+func (s *Store) LoadMessagesBetween(chatJID string, from, to time.Time, limit int) []types.Message {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	lo, hi := int64(math.MinInt64), int64(math.MaxInt64)
+	if !from.IsZero() {
+		lo = from.Unix()
+	}
+	if !to.IsZero() {
+		hi = to.Unix()
+	}
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	rows, err := s.db.Query(
+		`SELECT id, sender_jid, sender_name, content, timestamp, from_me, image_path, is_system, status
+		 FROM messages WHERE chat_jid = ? AND timestamp >= ? AND timestamp < ?
+		 ORDER BY timestamp DESC LIMIT ?`,
+		chatJID, lo, hi, limit,
+	)
+	if err != nil {
+		s.logger.Error("Failed to load messages for " + chatJID + ": " + err.Error())
+		return nil
+	}
+	defer rows.Close()
+	var msgs []types.Message
+	for rows.Next() {
+		var m types.Message
+		var senderJID string
+		var ts int64
+		var fromMe, isSystem, status int
+		if err := rows.Scan(&m.ID, &senderJID, &m.Sender, &m.Content, &ts, &fromMe, &m.ImagePath, &isSystem,
+			&status); err != nil {
+			continue
+		}
+		m.SenderJID, _ = watypes.ParseJID(senderJID)
+		m.Sender, m.Content = s.open("sender_name", m.Sender), s.open("content", m.Content)
+		m.Timestamp = time.Unix(ts, 0)
+		m.FromMe = fromMe != 0
+		m.System = isSystem != 0
+		m.Status = types.MessageStatus(status)
+		msgs = append(msgs, m)
+	}
+	slices.Reverse(msgs)
+	return msgs
+}
+
+// LoadMessagesPage returns up to limit messages of a chat sent in
+// [from, to) that come after the message after, oldest first; nil after
+// starts at the beginning. Paging through a chat this way keeps only one
+// page in memory.
+func (s *Store) LoadMessagesPage(chatJID string, from, to time.Time, after *types.Message, limit int) []types.Message {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	lo, hi := int64(math.MinInt64), int64(math.MaxInt64)
+	if !from.IsZero() {
+		lo = from.Unix()
+	}
+	if !to.IsZero() {
+		hi = to.Unix()
+	}
+	afterTS, afterID := int64(math.MinInt64), ""
+	if after != nil {
+		afterTS, afterID = after.Timestamp.Unix(), after.ID
+	}
+	rows, err := s.db.Query(
+		`SELECT id, sender_jid, sender_name, content, timestamp, from_me, image_path, is_system, status
+		 FROM messages WHERE chat_jid = ? AND timestamp >= ? AND timestamp < ?
+		 AND (timestamp > ? OR (timestamp = ? AND id > ?))
+		 ORDER BY timestamp, id LIMIT ?`,
+		chatJID, lo, hi, afterTS, afterTS, afterID, limit,
+	)
+	if err != nil {
+		s.logger.Error("Failed to load messages for " + chatJID + ": " + err.Error())
+		return nil
+	}
+	defer rows.Close()
+	var msgs []types.Message
+	for rows.Next() {
+		var m types.Message
+		var senderJID string
+		var ts int64
+		var fromMe, isSystem, status int
+		if err := rows.Scan(&m.ID, &senderJID, &m.Sender, &m.Content, &ts, &fromMe, &m.ImagePath, &isSystem,
+			&status); err != nil {
+			continue
+		}
+		m.SenderJID, _ = watypes.ParseJID(senderJID)
+		m.Sender, m.Content = s.open("sender_name", m.Sender), s.open("content", m.Content)
+		m.Timestamp = time.Unix(ts, 0)
+		m.FromMe = fromMe != 0
+		m.System = isSystem != 0
+		m.Status = types.MessageStatus(status)
 		msgs = append(msgs, m)
 	}
 	return msgs
@@ -203,7 +468,7 @@ func (s *Store) LoadAllMessages() map[string][]types.Message {
 	}
 	s.logger.Info("Bulk-loading all messages from database...")
 	rows, err := s.db.Query(
-		`SELECT id, chat_jid, sender_jid, sender_name, content, timestamp, from_me, image_path
+		`SELECT id, chat_jid, sender_jid, sender_name, content, timestamp, from_me, image_path, is_system, status
 		 FROM messages ORDER BY timestamp ASC`,
 	)
 	if err != nil {
@@ -217,13 +482,17 @@ func (s *Store) LoadAllMessages() map[string][]types.Message {
 		var m types.Message
 		var chatJID, senderJID string
 		var ts int64
-		var fromMe int
-		if err := rows.Scan(&m.ID, &chatJID, &senderJID, &m.Sender, &m.Content, &ts, &fromMe, &m.ImagePath); err != nil {
+		var fromMe, isSystem, status int
+		if err := rows.Scan(&m.ID, &chatJID, &senderJID, &m.Sender, &m.Content, &ts, &fromMe, &m.ImagePath, &isSystem,
+			&status); err != nil {
 			continue
 		}
 		m.SenderJID, _ = watypes.ParseJID(senderJID)
+		m.Sender, m.Content = s.open("sender_name", m.Sender), s.open("content", m.Content)
 		m.Timestamp = time.Unix(ts, 0)
 		m.FromMe = fromMe != 0
+		m.System = isSystem != 0
+		m.Status = types.MessageStatus(status)
 		result[chatJID] = append(result[chatJID], m)
 	}
 	count := 0
@@ -234,6 +503,83 @@ func (s *Store) LoadAllMessages() map[string][]types.Message {
 	return result
 }
 
+// SaveDraft stores the unsent draft for a chat. An empty text deletes it.
+func (s *Store) SaveDraft(chatJID string, text string) {
+	if s == nil || s.db == nil {
+		return
+	}
+	var err error
+	if text == "" {
+		_, err = s.db.Exec(`DELETE FROM drafts WHERE chat_jid = ?`, chatJID)
+	} else {
+		_, err = s.db.Exec(
+			`INSERT INTO drafts(chat_jid, text, updated_ts) VALUES(?,?,?)
+			 ON CONFLICT(chat_jid) DO UPDATE SET text = excluded.text, updated_ts = excluded.updated_ts`,
+			chatJID, s.seal("text", text), time.Now().Unix(),
+		)
+	}
+	if err != nil {
+		s.logger.Error("Failed to save draft: " + err.Error())
+	}
+}
+
+// LoadDrafts returns all persisted drafts keyed by chat JID.
+func (s *Store) LoadDrafts() map[string]string {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	rows, err := s.db.Query(`SELECT chat_jid, text FROM drafts`)
+	if err != nil {
+		s.logger.Error("Failed to load drafts: " + err.Error())
+		return nil
+	}
+	defer rows.Close()
+	drafts := make(map[string]string)
+	for rows.Next() {
+		var jid, text string
+		if err := rows.Scan(&jid, &text); err != nil {
+			continue
+		}
+		drafts[jid] = s.open("text", text)
+	}
+	return drafts
+}
+
+// RecordEmoji marks an emoji as just used for the picker's recent list.
+func (s *Store) RecordEmoji(emoji string) {
+	if s == nil || s.db == nil {
+		return
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO emoji_recent(emoji, used_ts) VALUES(?,?)
+		 ON CONFLICT(emoji) DO UPDATE SET used_ts = excluded.used_ts`,
+		emoji, time.Now().UnixNano(),
+	)
+	if err != nil {
+		s.logger.Error("Failed to record emoji: " + err.Error())
+	}
+}
+
+// RecentEmoji returns the most recently used emoji, newest first.
+func (s *Store) RecentEmoji(limit int) []string {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	rows, err := s.db.Query(`SELECT emoji FROM emoji_recent ORDER BY used_ts DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var e string
+		if err := rows.Scan(&e); err == nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
 // ResolveNameFromMessages looks at message history to find a name for a JID.
 func (s *Store) ResolveNameFromMessages(jid string) string {
 	if s == nil || s.db == nil {
@@ -247,7 +593,7 @@ func (s *Store) ResolveNameFromMessages(jid string) string {
 		jid,
 	).Scan(&name)
 	if err == nil && name != "" {
-		return name
+		return s.open("sender_name", name)
 	}
 	return ""
 }
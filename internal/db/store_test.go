@@ -0,0 +1,245 @@
+package db
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	watypes "go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/types"
+)
+
+// newTestStore opens a store in a fresh working directory.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	logger, err := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+var (
+	alice = watypes.NewJID("491701111111", watypes.DefaultUserServer)
+	group = watypes.NewJID("120363000000000001", watypes.GroupServer)
+	t0    = time.Unix(1_760_000_000, 0)
+)
+
+func TestPersistAndLoadMessages(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	s.PersistMessage(chat, types.Message{ID: "b", Sender: "Alice", SenderJID: alice, Content: "second", Timestamp: t0.Add(time.Minute)})
+	s.PersistMessage(chat, types.Message{ID: "a", Sender: "You", Content: "first", Timestamp: t0, FromMe: true, Status: types.StatusSent})
+	s.PersistMessage(group.String(), types.Message{ID: "sys-1-name", Content: "renamed", Timestamp: t0, System: true})
+
+	msgs := s.LoadMessages(chat, 10)
+	if len(msgs) != 2 || msgs[0].ID != "a" || msgs[1].ID != "b" {
+		t.Fatalf("LoadMessages = %+v, want a, b", msgs)
+	}
+	if !msgs[0].FromMe || msgs[0].Status != types.StatusSent || msgs[1].SenderJID != alice {
+		t.Errorf("fields not round-tripped: %+v", msgs)
+	}
+	if got := s.LoadMessages(chat, 1); len(got) != 1 {
+		t.Errorf("limit ignored: got %d messages", len(got))
+	}
+
+	all := s.LoadAllMessages()
+	if len(all) != 2 || len(all[chat]) != 2 || !all[group.String()][0].System {
+		t.Errorf("LoadAllMessages = %+v", all)
+	}
+}
+
+func TestLoadMessagesBetween(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	for i, id := range []string{"a", "b", "c", "d"} {
+		s.PersistMessage(chat, types.Message{ID: id, Content: id, Timestamp: t0.Add(time.Duration(i) * time.Hour)})
+	}
+	ids := func(msgs []types.Message) []string {
+		var out []string
+		for _, m := range msgs {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+	tests := []struct {
+		name     string
+		from, to time.Time
+		limit    int
+		want     []string
+	}{
+		{"all", time.Time{}, time.Time{}, 0, []string{"a", "b", "c", "d"}},
+		{"since", t0.Add(time.Hour), time.Time{}, 0, []string{"b", "c", "d"}},
+		{"until", time.Time{}, t0.Add(2 * time.Hour), 0, []string{"a", "b"}},
+		{"newest within limit", t0.Add(time.Hour), time.Time{}, 2, []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		if got := ids(s.LoadMessagesBetween(chat, tt.from, tt.to, tt.limit)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadMessagesPage(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	// Two messages share a second; paging must not skip or repeat either.
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		s.PersistMessage(chat, types.Message{ID: id, Content: id, Timestamp: t0.Add(time.Duration(i/2) * time.Hour)})
+	}
+	var got []string
+	var after *types.Message
+	for pages := 0; ; pages++ {
+		page := s.LoadMessagesPage(chat, t0, time.Time{}, after, 2)
+		if len(page) == 0 {
+			if pages != 3 {
+				t.Errorf("%d pages", pages)
+			}
+			break
+		}
+		for _, m := range page {
+			got = append(got, m.ID)
+		}
+		after = &page[len(page)-1]
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if page := s.LoadMessagesPage(chat, t0.Add(time.Hour), t0.Add(2*time.Hour), nil, 10); len(page) != 2 || page[0].ID != "c" {
+		t.Errorf("range page = %+v", page)
+	}
+}
+
+func TestImportMessages(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	// Received live; the export has it rounded down to the minute.
+	minute := t0.Truncate(time.Minute)
+	s.PersistMessage(chat, types.Message{ID: "real", Content: "ok", Timestamp: minute.Add(40 * time.Second)})
+	msgs := []types.Message{
+		{ID: ImportedIDPrefix + "1", Content: "ok", Timestamp: minute},
+		{ID: ImportedIDPrefix + "2", Content: "ok", Timestamp: minute},
+		{ID: ImportedIDPrefix + "3", Content: "hi", Timestamp: minute, FromMe: true, Sender: "You"},
+	}
+	if n, err := s.ImportMessages(chat, msgs, time.Minute); err != nil || n != 2 {
+		t.Fatalf("ImportMessages = %d, %v; want 2 (one \"ok\" is the received one)", n, err)
+	}
+	if n, err := s.ImportMessages(chat, msgs, time.Minute); err != nil || n != 0 {
+		t.Errorf("re-import = %d, %v; want 0", n, err)
+	}
+	if got := len(s.LoadMessages(chat, 10)); got != 3 {
+		t.Errorf("%d messages stored, want 3", got)
+	}
+}
+
+func TestPersistMessageKeepsKnownFields(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	s.PersistMessage(chat, types.Message{ID: "a", Sender: "Alice", Content: "[Image]", ImagePath: "media_cache/x.jpg", Timestamp: t0})
+	// A second copy, e.g. from history sync, without the cached image.
+	s.PersistMessage(chat, types.Message{ID: "a", Content: "[Image: cat]", Timestamp: t0})
+
+	msgs := s.LoadMessages(chat, 10)
+	if len(msgs) != 1 {
+		t.Fatalf("duplicate stored: %+v", msgs)
+	}
+	m := msgs[0]
+	if m.Sender != "Alice" || m.ImagePath != "media_cache/x.jpg" || m.Content != "[Image: cat]" {
+		t.Errorf("upsert = %+v", m)
+	}
+}
+
+func TestUpdateMessageStatusOnlyRaises(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	for _, id := range []string{"a", "b", "c"} {
+		s.PersistMessage(chat, types.Message{ID: id, Content: id, Timestamp: t0, FromMe: true, Status: types.StatusSent})
+	}
+	s.UpdateMessageStatus(chat, []string{"a", "b"}, types.StatusRead)
+	s.UpdateMessageStatus(chat, []string{"a"}, types.StatusDelivered) // late delivery receipt
+	s.PersistMessage(chat, types.Message{ID: "b", Content: "b", Timestamp: t0, FromMe: true, Status: types.StatusSent})
+
+	want := map[string]types.MessageStatus{"a": types.StatusRead, "b": types.StatusRead, "c": types.StatusSent}
+	for _, m := range s.LoadMessages(chat, 10) {
+		if m.Status != want[m.ID] {
+			t.Errorf("status of %s = %d, want %d", m.ID, m.Status, want[m.ID])
+		}
+	}
+}
+
+func TestChats(t *testing.T) {
+	s := newTestStore(t)
+	s.UpsertChat(alice.String(), "Alice", false, "hi", t0.Add(time.Hour))
+	// Older message and no name: neither may overwrite.
+	s.UpsertChat(alice.String(), "", false, "old", t0)
+	s.UpsertChat(group.String(), "Team", true, "", t0)
+	until := t0.Add(8 * time.Hour)
+	s.SaveChatSettings(group.String(), true, false, until)
+	s.SaveReadState(alice.String(), 3, "m1")
+
+	chats := s.LoadChats()
+	if len(chats) != 2 {
+		t.Fatalf("LoadChats = %+v", chats)
+	}
+	a, g := chats[0], chats[1]
+	if a.Name != "Alice" || a.LastMsg != "hi" || !a.LastTime.Equal(t0.Add(time.Hour)) {
+		t.Errorf("alice = %+v", a)
+	}
+	if a.Unread != 3 || a.LastReadID != "m1" {
+		t.Errorf("read state = %d %q", a.Unread, a.LastReadID)
+	}
+	if !g.IsGroup || !g.Pinned || g.Archived || !g.MutedUntil.Equal(until) {
+		t.Errorf("group = %+v", g)
+	}
+}
+
+func TestDraftsAndEmoji(t *testing.T) {
+	s := newTestStore(t)
+	s.SaveDraft("a", "hello")
+	s.SaveDraft("b", "bye")
+	s.SaveDraft("b", "")
+	if d := s.LoadDrafts(); len(d) != 1 || d["a"] != "hello" {
+		t.Errorf("LoadDrafts = %v", d)
+	}
+
+	for _, e := range []string{"👍", "😂", "👍", "🎉"} {
+		s.RecordEmoji(e)
+	}
+	if got := s.RecentEmoji(2); !slices.Equal(got, []string{"🎉", "👍"}) {
+		t.Errorf("RecentEmoji = %v", got)
+	}
+}
+
+func TestResolveNameFromMessages(t *testing.T) {
+	s := newTestStore(t)
+	chat := alice.String()
+	s.PersistMessage(chat, types.Message{ID: "a", Sender: "Ali", Content: "x", Timestamp: t0})
+	s.PersistMessage(chat, types.Message{ID: "b", Sender: "Alice", Content: "y", Timestamp: t0.Add(time.Minute)})
+	s.PersistMessage(chat, types.Message{ID: "c", Sender: "You", Content: "z", Timestamp: t0.Add(time.Hour), FromMe: true})
+	if got := s.ResolveNameFromMessages(chat); got != "Alice" {
+		t.Errorf("ResolveNameFromMessages = %q, want Alice", got)
+	}
+	if got := s.ResolveNameFromMessages(group.String()); got != "" {
+		t.Errorf("unknown chat resolved to %q", got)
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	s.PersistMessage("x", types.Message{ID: "a"})
+	s.UpsertChat("x", "", false, "", time.Time{})
+	s.SaveDraft("x", "y")
+	if s.LoadChats() != nil || s.LoadMessages("x", 1) != nil || s.LoadDrafts() != nil {
+		t.Error("nil store returned data")
+	}
+	s.Close()
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// WebhookDelivery is a webhook POST waiting in the queue.
+type WebhookDelivery struct {
+	ID        int64
+	URL       string
+	Body      []byte
+	Signature string
+	Attempts  int // failed attempts so far
+	NextTry   time.Time
+}
+
+// EnqueueWebhook queues a POST of body to url, due now.
+func (s *Store) EnqueueWebhook(url string, body []byte, signature string) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if s.locked() {
+		s.logger.Error("Dropped webhook delivery: " + ErrLocked.Error())
+		return
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO webhook_queue(url, body, signature, next_try) VALUES(?,?,?,?)`,
+		url, []byte(s.seal("body", string(body))), signature, time.Now().UnixMilli(),
+	)
+	if err != nil {
+		s.logger.Error("Failed to queue webhook: " + err.Error())
+	}
+}
+
+// DueWebhooks returns up to limit deliveries due at now, oldest first. A
+// locked store has none.
+func (s *Store) DueWebhooks(now time.Time, limit int) []WebhookDelivery {
+	if s == nil || s.db == nil || s.locked() {
+		return nil
+	}
+	rows, err := s.db.Query(
+		`SELECT id, url, body, signature, attempts, next_try FROM webhook_queue
+		 WHERE next_try <= ? ORDER BY next_try, id LIMIT ?`,
+		now.UnixMilli(), limit,
+	)
+	if err != nil {
+		s.logger.Error("Failed to load webhook queue: " + err.Error())
+		return nil
+	}
+	defer rows.Close()
+	var out []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var next int64
+		if err := rows.Scan(&d.ID, &d.URL, &d.Body, &d.Signature, &d.Attempts, &next); err != nil {
+			continue
+		}
+		d.Body = []byte(s.open("body", string(d.Body)))
+		d.NextTry = time.UnixMilli(next)
+		out = append(out, d)
+	}
+	return out
+}
+
+// NextWebhookTime returns when the next queued delivery is due; false if the
+// queue is empty.
+func (s *Store) NextWebhookTime() (time.Time, bool) {
+	if s == nil || s.db == nil {
+		return time.Time{}, false
+	}
+	var next sql.NullInt64
+	err := s.db.QueryRow(`SELECT MIN(next_try) FROM webhook_queue`).Scan(&next)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.logger.Error("Failed to read webhook queue: " + err.Error())
+	}
+	if !next.Valid {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(next.Int64), true
+}
+
+// RetryWebhook records a failed attempt and reschedules the delivery.
+func (s *Store) RetryWebhook(id int64, attempts int, next time.Time) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if _, err := s.db.Exec(
+		`UPDATE webhook_queue SET attempts = ?, next_try = ? WHERE id = ?`,
+		attempts, next.UnixMilli(), id,
+	); err != nil {
+		s.logger.Error("Failed to reschedule webhook: " + err.Error())
+	}
+}
+
+// DeleteWebhook removes a delivery that succeeded or was given up on.
+func (s *Store) DeleteWebhook(id int64) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if _, err := s.db.Exec(`DELETE FROM webhook_queue WHERE id = ?`, id); err != nil {
+		s.logger.Error("Failed to delete webhook: " + err.Error())
+	}
+}
@@ -0,0 +1,185 @@
+// Package demo runs the app without a phone or network: a fixture database
+// and a fake WhatsApp client that plays a short script of incoming events.
+package demo
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	wastore "go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"DevStarByte/internal/db"
+	apptypes "DevStarByte/internal/types"
+	"DevStarByte/internal/wa"
+)
+
+// ── Fixture ───────────────────────────────────────────────────────────────────
+
+var (
+	Me     = types.NewJID("4915100000000", types.DefaultUserServer)
+	Alice  = types.NewJID("4915111111111", types.DefaultUserServer)
+	Bob    = types.NewJID("4915122222222", types.DefaultUserServer)
+	Carol  = types.NewJID("4915133333333", types.DefaultUserServer)
+	Mum    = types.NewJID("4915144444444", types.DefaultUserServer)
+	Team   = types.NewJID("120363000000000001", types.GroupServer)
+	Family = types.NewJID("120363000000000002", types.GroupServer)
+)
+
+type fixtureChat struct {
+	jid      types.JID
+	name     string
+	pinned   bool
+	archived bool
+	muted    bool
+	unread   int
+	msgs     []apptypes.Message
+}
+
+// fixture returns the demo chats with messages timed relative to now.
+func fixture(now time.Time) []fixtureChat {
+	at := func(ago time.Duration) time.Time { return now.Add(-ago).Truncate(time.Minute) }
+	mine := func(id, text string, ago time.Duration, st apptypes.MessageStatus) apptypes.Message {
+		return apptypes.Message{ID: id, Sender: "You", SenderJID: Me, Content: text, Timestamp: at(ago), FromMe: true, Status: st}
+	}
+	from := func(jid types.JID, name, id, text string, ago time.Duration) apptypes.Message {
+		return apptypes.Message{ID: id, Sender: name, SenderJID: jid, Content: text, Timestamp: at(ago)}
+	}
+	return []fixtureChat{
+		{jid: Bob, name: "Bob", pinned: true, msgs: []apptypes.Message{
+			from(Bob, "Bob", "b1", "Did you push the fix?", 26*time.Hour),
+			mine("b2", "Yes, it's on *main* now", 25*time.Hour, apptypes.StatusRead),
+			from(Bob, "Bob", "b3", "Great, thanks! 🙌", 3*time.Hour),
+			mine("b4", "Let me know if the build breaks again", 2*time.Hour, apptypes.StatusDelivered),
+		}},
+		{jid: Alice, name: "Alice", unread: 2, msgs: []apptypes.Message{
+			mine("a1", "Lunch tomorrow?", 30*time.Hour, apptypes.StatusRead),
+			from(Alice, "Alice", "a2", "Sure! The usual place at 12:30?", 29*time.Hour),
+			mine("a3", "👍", 29*time.Hour, apptypes.StatusRead),
+			from(Alice, "Alice", "a4", "I found the photos from the trip, they're _amazing_. "+
+				"The one from the ridge at sunrise is my new wallpaper 🏔️", 40*time.Minute),
+			from(Alice, "Alice", "a5", "Sending them tonight", 39*time.Minute),
+		}},
+		{jid: Team, name: "Team", unread: 1, msgs: []apptypes.Message{
+			{ID: "sys-t0-name", Content: `Carol changed the subject to "Team"`, Timestamp: at(50 * time.Hour), System: true},
+			from(Carol, "Carol", "t1", "Standup moved to 10:15 tomorrow", 5*time.Hour),
+			from(Bob, "Bob", "t2", "ok", 5*time.Hour),
+			mine("t3", "Works for me", 4*time.Hour, apptypes.StatusRead),
+			from(Carol, "Carol", "t4", "@4915100000000 can you review ~the old PR~ the new one? `make test` passes", 15*time.Minute),
+		}},
+		{jid: Family, name: "Family", muted: true, unread: 3, msgs: []apptypes.Message{
+			from(Mum, "Mum", "f1", "Who's bringing dessert on Sunday?", 7*time.Hour),
+			from(Mum, "Mum", "f2", "[Image: cake]", 7*time.Hour),
+			from(Mum, "Mum", "f3", "Never mind, I baked one 🎂", 6*time.Hour),
+		}},
+		{jid: Carol, name: "Carol", archived: true, msgs: []apptypes.Message{
+			from(Carol, "Carol", "c1", "Happy new year!", 300*24*time.Hour),
+		}},
+	}
+}
+
+// Seed fills store with the demo chats, their messages and read state.
+func Seed(store *db.Store, now time.Time) {
+	for _, c := range fixture(now) {
+		key := c.jid.String()
+		for _, m := range c.msgs {
+			store.PersistMessage(key, m)
+		}
+		last := c.msgs[len(c.msgs)-1]
+		store.UpsertChat(key, c.name, c.jid.Server == types.GroupServer, last.Content, last.Timestamp)
+		var mutedUntil time.Time
+		if c.muted {
+			mutedUntil = wastore.MutedForever
+		}
+		store.SaveChatSettings(key, c.pinned, c.archived, mutedUntil)
+		lastRead := ""
+		if n := len(c.msgs) - c.unread - 1; n >= 0 {
+			lastRead = c.msgs[n].ID
+		}
+		store.SaveReadState(key, c.unread, lastRead)
+	}
+}
+
+// NewClient returns a fake client that knows the demo contacts and groups.
+func NewClient(now time.Time) *wa.Fake {
+	f := wa.NewFake(Me)
+	f.Contacts[Alice] = types.ContactInfo{FullName: "Alice", PushName: "Alice"}
+	f.Contacts[Bob] = types.ContactInfo{FullName: "Bob", PushName: "Bobby"}
+	f.Contacts[Carol] = types.ContactInfo{FullName: "Carol", PushName: "Carol"}
+	f.Contacts[Mum] = types.ContactInfo{FullName: "Mum"}
+	f.Users[Alice] = types.UserInfo{Status: "Out hiking 🥾"}
+	member := func(jid types.JID, admin bool) types.GroupParticipant {
+		return types.GroupParticipant{JID: jid, PhoneNumber: jid, IsAdmin: admin}
+	}
+	f.Groups[Team] = &types.GroupInfo{
+		JID:          Team,
+		OwnerJID:     Carol,
+		GroupName:    types.GroupName{Name: "Team"},
+		GroupTopic:   types.GroupTopic{Topic: "Release planning and standups"},
+		GroupCreated: now.Add(-90 * 24 * time.Hour),
+		Participants: []types.GroupParticipant{member(Me, false), member(Alice, false), member(Bob, false), member(Carol, true)},
+	}
+	f.Groups[Family] = &types.GroupInfo{
+		JID:          Family,
+		GroupName:    types.GroupName{Name: "Family"},
+		GroupCreated: now.Add(-400 * 24 * time.Hour),
+		Participants: []types.GroupParticipant{member(Me, true), member(Mum, true)},
+	}
+	return f
+}
+
+// Script returns the events the fake plays while the demo runs: new
+// messages, a read receipt and a group rename.
+func Script(now time.Time) []wa.Step {
+	at := func(d time.Duration) time.Time { return now.Add(d) }
+	rename := &events.GroupInfo{
+		JID:       Team,
+		Sender:    &Carol,
+		Timestamp: at(14 * time.Second),
+		Name:      &types.GroupName{Name: "Team 🚀"},
+	}
+	return []wa.Step{
+		// An empty history sync ends the "Syncing…" state as on a real login.
+		{Event: &events.HistorySync{Data: &waHistorySync.HistorySync{}}},
+		{After: 3 * time.Second, Event: wa.TextEvent(Alice, Alice, "Alice", "demo-1", "Are you coming tonight? 🎉", at(3*time.Second))},
+		{After: 3 * time.Second, Event: &events.Receipt{
+			MessageSource: types.MessageSource{Chat: Bob, Sender: Bob},
+			MessageIDs:    []types.MessageID{"b4"},
+			Timestamp:     at(6 * time.Second),
+			Type:          types.ReceiptTypeRead,
+		}},
+		{After: 4 * time.Second, Event: wa.TextEvent(Team, Bob, "Bob", "demo-2", "Release notes are up, please have a look", at(10*time.Second))},
+		{After: 4 * time.Second, Event: rename},
+		{After: 5 * time.Second, Event: wa.TextEvent(Bob, Bob, "Bob", "demo-3", "Build is green again ✅", at(19*time.Second))},
+	}
+}
+
+// ── Setup ─────────────────────────────────────────────────────────────────────
+
+// Setup switches to a fresh temporary directory so the demo never touches
+// the real message database, then seeds it. The caller removes dir when done.
+func Setup(logger *Logger.Logger, now time.Time) (fake *wa.Fake, store *db.Store, dir string, err error) {
+	dir, err = os.MkdirTemp("", "whatsapp-tui-demo-")
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if err = os.Chdir(dir); err != nil {
+		return nil, nil, dir, err
+	}
+	store, err = db.NewStore(logger)
+	if err != nil {
+		return nil, nil, dir, err
+	}
+	Seed(store, now)
+	logger.Info("Demo data seeded in " + dir)
+	return NewClient(now), store, dir, nil
+}
+
+// Play starts the demo script on fake.
+func Play(ctx context.Context, fake *wa.Fake) {
+	fake.Play(ctx, Script(time.Now()))
+}
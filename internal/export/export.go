@@ -0,0 +1,211 @@
+// Package export writes chats from messages.db to files: WhatsApp's own
+// .txt format, JSON, Markdown or an HTML page. Every chat gets a directory
+// with the chat file and a media folder holding copies of its cached images.
+package export
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"DevStarByte/internal/db"
+	apptypes "DevStarByte/internal/types"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	Text     Format = "txt"  // as WhatsApp's "Export chat", importable again
+	JSON     Format = "json" // structured, one object per chat
+	Markdown Format = "md"
+	HTML     Format = "html" // a page to open in a browser
+)
+
+// Formats are the supported formats.
+var Formats = []Format{Text, JSON, Markdown, HTML}
+
+// ParseFormat returns the format called s; "markdown" is accepted too.
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(s))
+	if f == "markdown" {
+		f = Markdown
+	}
+	if !slices.Contains(Formats, f) {
+		return "", fmt.Errorf("unknown format %q: use txt, json, md or html", s)
+	}
+	return f, nil
+}
+
+// Options select what is exported and how.
+type Options struct {
+	Format   Format
+	From, To time.Time // messages in [From, To); zero leaves that end open
+}
+
+// Result counts what was exported.
+type Result struct {
+	Chats        int // chats with messages in the range
+	Messages     int
+	Media        int // images copied
+	MissingMedia int // images no longer in the cache
+}
+
+// pageSize is how many messages are read from the database at a time.
+const pageSize = 500
+
+// ChatFile is the name of the chat file in each chat's directory, without
+// the extension.
+const ChatFile = "chat"
+
+// MediaDir is the media folder in each chat's directory.
+const MediaDir = "media"
+
+// Export writes the messages of chats in the range to a directory per chat
+// below dir. Chats without messages in the range are skipped.
+func Export(store *db.Store, chats []apptypes.ChatItem, dir string, opts Options) (Result, error) {
+	var res Result
+	if store == nil {
+		return res, errors.New("messages.db is not open")
+	}
+	if opts.Format == "" {
+		opts.Format = Text
+	}
+	used := make(map[string]bool)
+	for _, c := range chats {
+		first := store.LoadMessagesPage(c.JID.String(), opts.From, opts.To, nil, pageSize)
+		if len(first) == 0 {
+			continue
+		}
+		chatDir := filepath.Join(dir, dirName(c, used))
+		if err := exportChat(store, c, first, chatDir, opts, &res); err != nil {
+			return res, fmt.Errorf("exporting %s: %w", c.Name, err)
+		}
+		res.Chats++
+	}
+	return res, nil
+}
+
+// exportChat writes one chat, starting with its first page of messages.
+func exportChat(store *db.Store, c apptypes.ChatItem, page []apptypes.Message, dir string, opts Options, res *Result) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, ChatFile+"."+string(opts.Format)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	w := newWriter(opts, bw)
+	if err := w.begin(c); err != nil {
+		return err
+	}
+	copied := make(map[string]string) // cache path → path in the export
+	for len(page) > 0 {
+		for _, m := range page {
+			media, ok := copied[m.ImagePath]
+			if m.ImagePath != "" && !ok {
+				media, err = copyMedia(store, m.ImagePath, dir)
+				if err != nil {
+					return err
+				}
+				if media != "" {
+					res.Media++
+				} else {
+					res.MissingMedia++
+				}
+				copied[m.ImagePath] = media
+			}
+			if err := w.message(m, media); err != nil {
+				return err
+			}
+			res.Messages++
+		}
+		page = store.LoadMessagesPage(c.JID.String(), opts.From, opts.To, &page[len(page)-1], pageSize)
+	}
+	if err := w.end(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// copyMedia copies a cached file into the media folder below dir and
+// returns its path relative to dir, or "" if the file is gone.
+func copyMedia(store *db.Store, src, dir string) (string, error) {
+	// Encrypted files are exported decrypted.
+	data, err := store.ReadMedia(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, MediaDir), 0o755); err != nil {
+		return "", err
+	}
+	rel := filepath.ToSlash(filepath.Join(MediaDir, filepath.Base(src)))
+	return rel, os.WriteFile(filepath.Join(dir, rel), data, 0o644)
+}
+
+// dirName returns a file name for the chat's directory that is not in used
+// yet, and adds it.
+func dirName(c apptypes.ChatItem, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, c.Name)
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		name = c.JID.User
+	}
+	if used[strings.ToLower(name)] {
+		name += " (" + c.JID.User + ")"
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// ── Formats ───────────────────────────────────────────────────────────────────
+
+// writer writes one chat file in a format.
+type writer interface {
+	begin(c apptypes.ChatItem) error
+	// message writes m; media is the copied image relative to the chat's
+	// directory, "" if there is none.
+	message(m apptypes.Message, media string) error
+	end() error
+}
+
+func newWriter(opts Options, w *bufio.Writer) writer {
+	switch opts.Format {
+	case JSON:
+		return &jsonWriter{w: w, opts: opts}
+	case Markdown:
+		return &markdownWriter{w: w}
+	case HTML:
+		return &htmlWriter{w: w}
+	}
+	return &textWriter{w: w}
+}
+
+// caption returns the caption of an image message, whose content is
+// "[Image]" or "[Image: caption]".
+func caption(content string) string {
+	if c, ok := strings.CutPrefix(content, "[Image: "); ok {
+		return strings.TrimSuffix(c, "]")
+	}
+	if content == "[Image]" {
+		return ""
+	}
+	return content
+}
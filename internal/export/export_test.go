@@ -0,0 +1,178 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/db/dbtest"
+	apptypes "DevStarByte/internal/types"
+)
+
+var (
+	alice = types.NewJID("491701111111", types.DefaultUserServer)
+	team  = types.NewJID("120363000000000001", types.GroupServer)
+	day   = time.Date(2026, 10, 20, 9, 0, 0, 0, time.Local)
+)
+
+// newStore returns a store with a direct chat and a group, the group's
+// messages spread over two days.
+func newStore(t *testing.T) (*db.Store, []apptypes.ChatItem) {
+	t.Helper()
+	_, store := dbtest.New(t)
+	if err := os.WriteFile(filepath.Join("media_cache", "img-1.jpg"), []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store.PersistMessage(alice.String(), apptypes.Message{ID: "a1", Sender: "Alice", SenderJID: alice, Content: "Hi <there> & you", Timestamp: day})
+	store.PersistMessage(alice.String(), apptypes.Message{ID: "a2", Sender: "You", Content: "Line one\nLine two", Timestamp: day.Add(time.Minute), FromMe: true, Status: apptypes.StatusRead})
+	store.PersistMessage(team.String(), apptypes.Message{ID: "t1", Content: "Alice added Bob", Timestamp: day, System: true})
+	store.PersistMessage(team.String(), apptypes.Message{ID: "t2", Sender: "Alice", SenderJID: alice, Content: "[Image: Our stand]", ImagePath: filepath.Join("media_cache", "img-1.jpg"), Timestamp: day.Add(time.Hour)})
+	store.PersistMessage(team.String(), apptypes.Message{ID: "t3", Sender: "Alice", SenderJID: alice, Content: "[Image]", ImagePath: filepath.Join("media_cache", "gone.jpg"), Timestamp: day.Add(24 * time.Hour)})
+	return store, []apptypes.ChatItem{
+		{JID: alice, Name: "Alice"},
+		{JID: team, Name: "Team: A/B", IsGroup: true},
+		{JID: types.NewJID("491709999999", types.DefaultUserServer), Name: "Empty"},
+	}
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestText(t *testing.T) {
+	store, chats := newStore(t)
+	res, err := Export(store, chats, "out", Options{Format: Text})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (Result{Chats: 2, Messages: 5, Media: 1, MissingMedia: 1}) {
+		t.Errorf("Result = %+v", res)
+	}
+	if got, want := read(t, "out/Alice/chat.txt"), "20/10/2026, 09:00 - Alice: Hi <there> & you\n20/10/2026, 09:01 - You: Line one\nLine two\n"; got != want {
+		t.Errorf("Alice:\n%s\nwant:\n%s", got, want)
+	}
+	want := "20/10/2026, 09:00 - Alice added Bob\n" +
+		"20/10/2026, 10:00 - Alice: img-1.jpg (file attached)\nOur stand\n" +
+		"21/10/2026, 09:00 - Alice: <Media omitted>\n"
+	if got := read(t, "out/Team_ A_B/chat.txt"); got != want {
+		t.Errorf("Team:\n%s\nwant:\n%s", got, want)
+	}
+	if read(t, "out/Team_ A_B/media/img-1.jpg") != "jpeg" {
+		t.Error("image not copied")
+	}
+	if _, err := os.Stat("out/Empty"); err == nil {
+		t.Error("empty chat exported")
+	}
+}
+
+func TestJSONRange(t *testing.T) {
+	store, chats := newStore(t)
+	res, err := Export(store, chats, "out", Options{Format: JSON, From: day.Add(time.Hour), To: day.Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Chats != 1 || res.Messages != 1 {
+		t.Errorf("Result = %+v", res)
+	}
+	var got struct {
+		Chat struct {
+			JID     types.JID `json:"jid"`
+			IsGroup bool      `json:"is_group"`
+		} `json:"chat"`
+		Messages []jsonMessage `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(read(t, "out/Team_ A_B/chat.json")), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Chat.JID != team || !got.Chat.IsGroup || len(got.Messages) != 1 || got.Messages[0].Media != "media/img-1.jpg" {
+		t.Errorf("JSON = %+v", got)
+	}
+}
+
+func TestJSONStatus(t *testing.T) {
+	store, chats := newStore(t)
+	if _, err := Export(store, chats[:1], "out", Options{Format: JSON}); err != nil {
+		t.Fatal(err)
+	}
+	var got struct{ Messages []jsonMessage }
+	if err := json.Unmarshal([]byte(read(t, "out/Alice/chat.json")), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Status != "" || got.Messages[1].Status != "read" || got.Messages[1].Content != "Line one\nLine two" {
+		t.Errorf("messages = %+v", got.Messages)
+	}
+}
+
+func TestMarkdownAndHTML(t *testing.T) {
+	store, chats := newStore(t)
+	if _, err := Export(store, chats, "md", Options{Format: Markdown}); err != nil {
+		t.Fatal(err)
+	}
+	md := read(t, "md/Team_ A_B/chat.md")
+	for _, want := range []string{"# Team: A/B\n", "## Tuesday, October 20, 2026\n", "_Alice added Bob · 09:00_", "**Alice** · 10:00  \n![](media/img-1.jpg)  \nOur stand\n", "## Wednesday, October 21, 2026\n"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown lacks %q:\n%s", want, md)
+		}
+	}
+
+	if md := read(t, "md/Alice/chat.md"); !strings.Contains(md, "Hi &lt;there> &amp; you") {
+		t.Errorf("Markdown doesn't escape HTML:\n%s", md)
+	}
+
+	if _, err := Export(store, chats, "html", Options{Format: HTML}); err != nil {
+		t.Fatal(err)
+	}
+	page := read(t, "html/Alice/chat.html")
+	for _, want := range []string{"<title>Alice</title>", "Hi &lt;there&gt; &amp; you", `<div class="msg me"><div class="text">Line one` + "\nLine two</div>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML lacks %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, `class="sender"`) {
+		t.Error("sender shown in a direct chat")
+	}
+	if page := read(t, "html/Team_ A_B/chat.html"); !strings.Contains(page, `<img src="media/img-1.jpg"`) || !strings.Contains(page, `<div class="sender">Alice</div>`) {
+		t.Errorf("group page:\n%s", page)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"txt": Text, "JSON": JSON, "markdown": Markdown, "md": Markdown, "html": HTML} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("ParseFormat accepted pdf")
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain text":                "plain text",
+		"*bold* _it_ `code` ~s~":    "\\*bold\\* \\_it\\_ \\`code\\` \\~s\\~",
+		"[click](http://x.example)": `\[click\](http://x.example)`,
+		"<img src=x> & co":          "&lt;img src=x> &amp; co",
+		"# title":                   `\# title`,
+		"> quote":                   `\> quote`,
+		"- item":                    `\- item`,
+		"1. first":                  `1\. first`,
+		`C:\path`:                   `C:\\path`,
+	} {
+		if got := escapeMarkdown(in); got != want {
+			t.Errorf("escapeMarkdown(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
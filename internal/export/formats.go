@@ -0,0 +1,276 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── txt ───────────────────────────────────────────────────────────────────────
+
+// TextTimeFormat is the timestamp of WhatsApp's Android export, in local
+// time; lines start with it and " - ".
+const TextTimeFormat = "02/01/2006, 15:04"
+
+type textWriter struct{ w *bufio.Writer }
+
+func (t *textWriter) begin(apptypes.ChatItem) error { return nil }
+
+func (t *textWriter) message(m apptypes.Message, media string) error {
+	text := m.Content
+	switch {
+	case media != "":
+		text = strings.TrimPrefix(media, MediaDir+"/") + " (file attached)"
+		if c := caption(m.Content); c != "" {
+			text += "\n" + c
+		}
+	case m.ImagePath != "":
+		text = "<Media omitted>"
+		if c := caption(m.Content); c != "" {
+			text += "\n" + c
+		}
+	}
+	line := m.Timestamp.Local().Format(TextTimeFormat) + " - "
+	if !m.System {
+		line += m.Sender + ": "
+	}
+	_, err := t.w.WriteString(line + text + "\n")
+	return err
+}
+
+func (t *textWriter) end() error { return nil }
+
+// ── JSON ──────────────────────────────────────────────────────────────────────
+
+type jsonWriter struct {
+	w     *bufio.Writer
+	opts  Options
+	count int
+}
+
+// jsonMessage is a message in the JSON export.
+type jsonMessage struct {
+	ID        string    `json:"id"`
+	Sender    string    `json:"sender,omitempty"`
+	SenderJID types.JID `json:"sender_jid,omitzero"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	FromMe    bool      `json:"from_me,omitempty"`
+	System    bool      `json:"system,omitempty"`
+	Status    string    `json:"status,omitempty"` // of my messages: sent, delivered or read
+	Media     string    `json:"media,omitempty"`  // relative to the JSON file
+}
+
+var statusNames = map[apptypes.MessageStatus]string{
+	apptypes.StatusPending:   "pending",
+	apptypes.StatusSent:      "sent",
+	apptypes.StatusDelivered: "delivered",
+	apptypes.StatusRead:      "read",
+}
+
+func (j *jsonWriter) begin(c apptypes.ChatItem) error {
+	head, err := json.MarshalIndent(struct {
+		JID     types.JID `json:"jid"`
+		Name    string    `json:"name"`
+		IsGroup bool      `json:"is_group"`
+	}{c.JID, c.Name, c.IsGroup}, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(j.w, "{\n  \"chat\": %s,\n  \"exported_at\": %q,\n", head, time.Now().Format(time.RFC3339))
+	if !j.opts.From.IsZero() {
+		fmt.Fprintf(j.w, "  \"from\": %q,\n", j.opts.From.Format(time.RFC3339))
+	}
+	if !j.opts.To.IsZero() {
+		fmt.Fprintf(j.w, "  \"to\": %q,\n", j.opts.To.Format(time.RFC3339))
+	}
+	_, err = j.w.WriteString("  \"messages\": [")
+	return err
+}
+
+func (j *jsonWriter) message(m apptypes.Message, media string) error {
+	jm := jsonMessage{
+		ID:        m.ID,
+		Sender:    m.Sender,
+		SenderJID: m.SenderJID,
+		Content:   m.Content,
+		Timestamp: m.Timestamp,
+		FromMe:    m.FromMe,
+		System:    m.System,
+		Media:     media,
+	}
+	if m.FromMe {
+		jm.Status = statusNames[m.Status]
+	}
+	b, err := json.MarshalIndent(jm, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	sep := ",\n    "
+	if j.count == 0 {
+		sep = "\n    "
+	}
+	j.count++
+	_, err = j.w.WriteString(sep + string(b))
+	return err
+}
+
+func (j *jsonWriter) end() error {
+	_, err := j.w.WriteString("\n  ]\n}\n")
+	return err
+}
+
+// ── Markdown ──────────────────────────────────────────────────────────────────
+
+type markdownWriter struct {
+	w   *bufio.Writer
+	day string
+}
+
+func (md *markdownWriter) begin(c apptypes.ChatItem) error {
+	_, err := fmt.Fprintf(md.w, "# %s\n\n_Exported from WhatsApp TUI on %s._\n", escapeMarkdown(c.Name), time.Now().Format("January 2, 2006"))
+	return err
+}
+
+func (md *markdownWriter) message(m apptypes.Message, media string) error {
+	ts := m.Timestamp.Local()
+	if day := ts.Format("Monday, January 2, 2006"); day != md.day {
+		md.day = day
+		fmt.Fprintf(md.w, "\n## %s\n", day)
+	}
+	text := m.Content
+	if media != "" {
+		text = caption(m.Content)
+	}
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		lines = append(lines, escapeMarkdown(l))
+	}
+	text = strings.Join(lines, "  \n")
+
+	if m.System {
+		_, err := fmt.Fprintf(md.w, "\n_%s · %s_\n", text, ts.Format("15:04"))
+		return err
+	}
+	fmt.Fprintf(md.w, "\n**%s** · %s  \n", escapeMarkdown(m.Sender), ts.Format("15:04"))
+	if media != "" {
+		fmt.Fprintf(md.w, "![](%s)  \n", media)
+	}
+	_, err := md.w.WriteString(text + "\n")
+	return err
+}
+
+func (md *markdownWriter) end() error { return nil }
+
+// mdInline escapes what Markdown reads as emphasis, code, links or HTML
+// anywhere in a line.
+var mdInline = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `~`, `\~`, `[`, `\[`, `]`, `\]`,
+	`#`, `\#`, `|`, `\|`, `<`, `&lt;`, `&`, `&amp;`,
+)
+
+// listRe matches an ordered list marker at the start of a line.
+var listRe = regexp.MustCompile(`^(\d+)([.)])`)
+
+// escapeMarkdown makes a line of message text render as typed: inline
+// markup, raw HTML and block markers at the line start are escaped.
+func escapeMarkdown(l string) string {
+	l = mdInline.Replace(l)
+	if l != "" && strings.ContainsRune(`>+-=`, rune(l[0])) {
+		return `\` + l
+	}
+	return listRe.ReplaceAllString(l, `$1\$2`)
+}
+
+// ── HTML ──────────────────────────────────────────────────────────────────────
+
+type htmlWriter struct {
+	w     *bufio.Writer
+	group bool
+	day   string
+}
+
+var htmlTemplates = template.Must(template.New("head").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+body { margin: 0; background: #0b141a; color: #e9edef; font: 15px/1.4 system-ui, sans-serif; }
+header { position: sticky; top: 0; padding: 12px 20px; background: #202c33; }
+h1 { margin: 0; font-size: 18px; }
+header p { margin: 2px 0 0; color: #8696a0; font-size: 13px; }
+main { max-width: 860px; margin: 0 auto; padding: 12px 20px 40px; display: flex; flex-direction: column; }
+.day { align-self: center; margin: 14px 0 6px; padding: 4px 12px; border-radius: 8px; background: #182229; color: #8696a0; font-size: 13px; }
+.sys { align-self: center; margin: 6px 0; color: #8696a0; font-size: 13px; text-align: center; }
+.msg { max-width: 70%; margin: 3px 0; padding: 6px 9px 4px; border-radius: 8px; background: #202c33; }
+.msg.me { align-self: flex-end; background: #005c4b; }
+.sender { color: #53bdeb; font-size: 13px; font-weight: 600; }
+.text { white-space: pre-wrap; overflow-wrap: anywhere; }
+.msg img { display: block; max-width: 100%; margin: 3px 0; border-radius: 6px; }
+.time { color: #8696a0; font-size: 11px; text-align: right; }
+</style>
+</head>
+<body>
+<header><h1>{{.Name}}</h1><p>Exported from WhatsApp TUI on {{.Exported}}</p></header>
+<main>
+`))
+
+func init() {
+	template.Must(htmlTemplates.New("day").Parse(`<div class="day">{{.}}</div>
+`))
+	template.Must(htmlTemplates.New("sys").Parse(`<div class="sys">{{.Text}} · {{.Time}}</div>
+`))
+	template.Must(htmlTemplates.New("msg").Parse(`<div class="msg{{if .FromMe}} me{{end}}">
+{{- if .Sender}}<div class="sender">{{.Sender}}</div>{{end}}
+{{- if .Media}}<a href="{{.Media}}"><img src="{{.Media}}" alt="" loading="lazy"></a>{{end}}
+{{- if .Text}}<div class="text">{{.Text}}</div>{{end -}}
+<div class="time">{{.Time}}</div></div>
+`))
+}
+
+func (h *htmlWriter) begin(c apptypes.ChatItem) error {
+	h.group = c.IsGroup
+	return htmlTemplates.ExecuteTemplate(h.w, "head", map[string]string{
+		"Name":     c.Name,
+		"Exported": time.Now().Format("January 2, 2006"),
+	})
+}
+
+func (h *htmlWriter) message(m apptypes.Message, media string) error {
+	ts := m.Timestamp.Local()
+	if day := ts.Format("Monday, January 2, 2006"); day != h.day {
+		h.day = day
+		if err := htmlTemplates.ExecuteTemplate(h.w, "day", day); err != nil {
+			return err
+		}
+	}
+	data := struct {
+		Sender, Text, Time, Media string
+		FromMe                    bool
+	}{Text: m.Content, Time: ts.Format("15:04"), Media: media, FromMe: m.FromMe}
+	if m.System {
+		return htmlTemplates.ExecuteTemplate(h.w, "sys", data)
+	}
+	if h.group && !m.FromMe {
+		data.Sender = m.Sender
+	}
+	if media != "" {
+		data.Text = caption(m.Content)
+	}
+	return htmlTemplates.ExecuteTemplate(h.w, "msg", data)
+}
+
+func (h *htmlWriter) end() error {
+	_, err := h.w.WriteString("</main>\n</body>\n</html>\n")
+	return err
+}
@@ -0,0 +1,205 @@
+// Package hooks runs executables from a hooks directory on events, for
+// scripting without Go. Each gets the event as JSON on stdin and in WA_*
+// environment variables; the stdout of on-message can be sent back to the
+// chat.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Hook names, which are also the file names looked up in the directory.
+const (
+	OnMessage    = "on-message"    // a message from someone else arrived
+	OnSent       = "on-sent"       // I sent a message from here
+	OnConnect    = "on-connect"    // connected to WhatsApp
+	OnDisconnect = "on-disconnect" // the connection dropped
+)
+
+// maxOutput caps what is kept of a hook's stdout and stderr.
+const maxOutput = 64 << 10
+
+// Event is the data a hook gets. Message fields are empty for OnConnect and
+// OnDisconnect.
+type Event struct {
+	Event      string    `json:"event"`
+	Chat       types.JID `json:"chat,omitzero"`
+	ChatName   string    `json:"chat_name,omitempty"`
+	IsGroup    bool      `json:"is_group,omitempty"`
+	Sender     types.JID `json:"sender,omitzero"`
+	SenderName string    `json:"sender_name,omitempty"`
+	ID         string    `json:"id,omitempty"`
+	Content    string    `json:"content,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	MentionsMe bool      `json:"mentions_me,omitempty"`
+}
+
+// env returns the event as environment variables.
+func (e Event) env() []string {
+	env := []string{
+		"WA_EVENT=" + e.Event,
+		"WA_TIMESTAMP=" + strconv.FormatInt(e.Timestamp.Unix(), 10),
+	}
+	if e.Chat.IsEmpty() {
+		return env
+	}
+	return append(env,
+		"WA_CHAT="+e.Chat.String(),
+		"WA_CHAT_NAME="+e.ChatName,
+		"WA_IS_GROUP="+flag(e.IsGroup),
+		"WA_SENDER="+e.Sender.String(),
+		"WA_SENDER_NAME="+e.SenderName,
+		"WA_MESSAGE_ID="+e.ID,
+		"WA_CONTENT="+e.Content,
+		"WA_MENTIONS_ME="+flag(e.MentionsMe),
+	)
+}
+
+func flag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// Options configure a Runner.
+type Options struct {
+	Timeout    time.Duration // a hook running longer is killed
+	MaxRunning int           // hooks running at once; more wait
+	Reply      bool          // send the stdout of on-message to the chat
+}
+
+// Runner runs the hooks in one directory.
+type Runner struct {
+	logger *Logger.Logger
+	dir    string
+	opts   Options
+	slots  chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// New returns a Runner for dir, or nil, which disables hooks, if dir
+// doesn't exist. Hooks are looked up when they fire, so they can be added
+// and removed while the app runs.
+func New(logger *Logger.Logger, dir string, opts Options) *Runner {
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		logger.Debug("No hooks directory at " + dir)
+		return nil
+	}
+	opts.MaxRunning = max(opts.MaxRunning, 1)
+	logger.Info("Running hooks from " + dir)
+	return &Runner{
+		logger: logger,
+		dir:    dir,
+		opts:   opts,
+		slots:  make(chan struct{}, opts.MaxRunning),
+	}
+}
+
+// Fire runs the hook for evt in the background, if there is one. reply,
+// if not nil, gets the trimmed stdout when replies are enabled and it isn't
+// empty.
+func (r *Runner) Fire(evt Event, reply func(text string)) {
+	if r == nil {
+		return
+	}
+	path := filepath.Join(r.dir, evt.Event)
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() || fi.Mode().Perm()&0o111 == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.slots <- struct{}{}
+		out, err := r.run(path, evt)
+		<-r.slots
+		if err != nil {
+			r.logger.Warning("Hook " + evt.Event + " failed: " + err.Error())
+			return
+		}
+		if text := strings.TrimSpace(out); text != "" && r.opts.Reply && reply != nil {
+			reply(text)
+		}
+	}()
+}
+
+// run runs one hook and returns its stdout.
+func (r *Runner) run(path string, evt Event) (string, error) {
+	input, err := json.Marshal(evt)
+	if err != nil {
+		return "", err
+	}
+	ctx := context.Background()
+	if r.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Env = append(os.Environ(), evt.env()...)
+	var stdout, stderr capped
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// Don't wait for children that keep the pipes open after a kill.
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("killed after %s", r.opts.Timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	r.logger.Debug(fmt.Sprintf("Hook %s done in %s", evt.Event, time.Since(start).Round(time.Millisecond)))
+	return stdout.String(), nil
+}
+
+// Close waits for the hooks fired so far, and ignores later ones.
+func (r *Runner) Close() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// capped is a buffer that keeps the first maxOutput bytes written to it.
+type capped struct {
+	bytes.Buffer
+}
+
+func (c *capped) Write(p []byte) (int, error) {
+	if room := maxOutput - c.Len(); room > 0 {
+		c.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,123 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var alice = types.NewJID("491701111111", types.DefaultUserServer)
+
+// newRunner returns a Runner on a fresh directory with the given scripts.
+func newRunner(t *testing.T, opts Options, scripts map[string]string) (*Runner, string) {
+	t.Helper()
+	logger, err := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := New(logger, dir, opts)
+	if r == nil {
+		t.Fatal("New returned nil")
+	}
+	return r, dir
+}
+
+func TestStdinAndEnv(t *testing.T) {
+	r, dir := newRunner(t, Options{Timeout: 5 * time.Second}, map[string]string{
+		OnMessage: `cat > "$(dirname "$0")/stdin"; env | grep ^WA_ | sort > "$(dirname "$0")/env"`,
+	})
+	evt := Event{Event: OnMessage, Chat: alice, ChatName: "Alice", Sender: alice, SenderName: "Ali", ID: "m1", Content: "hi", Timestamp: time.Unix(1_760_000_000, 0).UTC()}
+	r.Fire(evt, nil)
+	r.Close()
+
+	stdin, _ := os.ReadFile(filepath.Join(dir, "stdin"))
+	var got Event
+	if err := json.Unmarshal(stdin, &got); err != nil || got != evt {
+		t.Errorf("stdin = %s", stdin)
+	}
+	env, _ := os.ReadFile(filepath.Join(dir, "env"))
+	for _, want := range []string{"WA_EVENT=on-message", "WA_CHAT=" + alice.String(), "WA_CONTENT=hi", "WA_IS_GROUP=0", "WA_TIMESTAMP=1760000000"} {
+		if !strings.Contains(string(env), want+"\n") {
+			t.Errorf("env lacks %s:\n%s", want, env)
+		}
+	}
+}
+
+func TestReply(t *testing.T) {
+	script := map[string]string{OnMessage: `echo; echo "ack $WA_CONTENT"`}
+	for _, enabled := range []bool{true, false} {
+		r, _ := newRunner(t, Options{Timeout: 5 * time.Second, Reply: enabled}, script)
+		replies := make(chan string, 1)
+		r.Fire(Event{Event: OnMessage, Chat: alice, Content: "hi"}, func(text string) { replies <- text })
+		r.Close()
+		select {
+		case text := <-replies:
+			if !enabled || text != "ack hi" {
+				t.Errorf("Reply %v: got reply %q", enabled, text)
+			}
+		default:
+			if enabled {
+				t.Error("no reply")
+			}
+		}
+	}
+}
+
+func TestTimeoutKills(t *testing.T) {
+	r, _ := newRunner(t, Options{Timeout: 100 * time.Millisecond, Reply: true}, map[string]string{
+		OnMessage: "echo too late; exec sleep 10",
+	})
+	start := time.Now()
+	r.Fire(Event{Event: OnMessage}, func(text string) { t.Errorf("reply %q from killed hook", text) })
+	r.Close()
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("Close took %s", d)
+	}
+}
+
+func TestMaxRunning(t *testing.T) {
+	// A second hook entering while the lock directory exists overlaps.
+	r, dir := newRunner(t, Options{Timeout: 5 * time.Second, MaxRunning: 1}, map[string]string{
+		OnSent: `d=$(dirname "$0"); mkdir "$d/lock" || echo overlap >> "$d/log"; sleep 0.05; rmdir "$d/lock"; echo ran >> "$d/log"`,
+	})
+	for range 4 {
+		r.Fire(Event{Event: OnSent}, nil)
+	}
+	r.Close()
+	log, _ := os.ReadFile(filepath.Join(dir, "log"))
+	if got := string(log); got != strings.Repeat("ran\n", 4) {
+		t.Errorf("log = %q", got)
+	}
+}
+
+func TestMissingHooks(t *testing.T) {
+	r, dir := newRunner(t, Options{}, nil)
+	// Not executable: ignored.
+	os.WriteFile(filepath.Join(dir, OnConnect), []byte("#!/bin/sh\ntouch \"$(dirname \"$0\")/ran\"\n"), 0o644)
+	r.Fire(Event{Event: OnConnect}, nil)
+	r.Fire(Event{Event: OnDisconnect}, nil)
+	r.Close()
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Error("non-executable hook ran")
+	}
+
+	logger, _ := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	if New(logger, filepath.Join(dir, "nope"), Options{}) != nil {
+		t.Error("New returned a Runner for a missing directory")
+	}
+	var none *Runner
+	none.Fire(Event{Event: OnConnect}, nil)
+	none.Close()
+}
@@ -0,0 +1,379 @@
+// Package importer reads the archives of WhatsApp's "Export chat" (a .zip,
+// or its unpacked chat .txt and attachments) into messages.db, so history
+// from before this client was linked can be searched and read.
+package importer
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Archives ──────────────────────────────────────────────────────────────────
+
+// Archive is an opened chat export.
+type Archive struct {
+	// Name is the chat's name as given in the export's file name, "" if it
+	// can't be told.
+	Name  string
+	chat  string // name of the chat .txt
+	files map[string]func() (io.ReadCloser, error)
+	close func() error
+}
+
+// Open opens an export: a .zip as shared by the phone, a chat .txt with
+// its attachments next to it, or a directory holding both.
+func Open(path string) (*Archive, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{files: make(map[string]func() (io.ReadCloser, error)), close: func() error { return nil }}
+	switch {
+	case info.IsDir():
+		err = a.addDir(path)
+	case strings.EqualFold(filepath.Ext(path), ".zip"):
+		var zr *zip.ReadCloser
+		zr, err = zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		a.close = zr.Close
+		for _, f := range zr.File {
+			if !f.FileInfo().IsDir() {
+				a.files[filepath.Base(f.Name)] = f.Open
+			}
+		}
+	default:
+		err = a.addDir(filepath.Dir(path))
+		a.chat = filepath.Base(path)
+	}
+	if err != nil {
+		a.Close()
+		return nil, err
+	}
+	if a.chat == "" {
+		a.chat = a.findChat()
+	}
+	if a.chat == "" {
+		a.Close()
+		return nil, fmt.Errorf("%s has no chat .txt", path)
+	}
+	a.Name = chatName(a.chat)
+	if a.Name == "" {
+		a.Name = chatName(filepath.Base(path))
+	}
+	return a, nil
+}
+
+func (a *Archive) addDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			p := filepath.Join(dir, e.Name())
+			a.files[e.Name()] = func() (io.ReadCloser, error) { return os.Open(p) }
+		}
+	}
+	return nil
+}
+
+// findChat picks the chat file: iOS calls it _chat.txt, Android names it
+// after the chat.
+func (a *Archive) findChat() string {
+	if _, ok := a.files["_chat.txt"]; ok {
+		return "_chat.txt"
+	}
+	var txts []string
+	for name := range a.files {
+		if strings.EqualFold(filepath.Ext(name), ".txt") {
+			if chatName(name) != "" {
+				return name
+			}
+			txts = append(txts, name)
+		}
+	}
+	if len(txts) == 1 {
+		return txts[0]
+	}
+	return ""
+}
+
+// Close releases the archive.
+func (a *Archive) Close() error { return a.close() }
+
+// fileNameRe matches how exports name their files, in the common languages:
+// "WhatsApp Chat with Alice.txt", "WhatsApp Chat - Alice.zip".
+var fileNameRe = regexp.MustCompile(`(?i)^WhatsApp[ -].*?(?: with | mit | con | avec | com | met | - )(.+)$`)
+
+// chatName returns the chat's name from an export's file name, "" if the
+// name doesn't say.
+func chatName(file string) string {
+	name := strings.TrimSuffix(file, filepath.Ext(file))
+	if m := fileNameRe.FindStringSubmatch(name); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
+
+// ── Importing ─────────────────────────────────────────────────────────────────
+
+// Options control an import.
+type Options struct {
+	Chat      types.JID // the chat the export belongs to
+	Me        []string  // names I have in the export; guessed in direct chats if empty
+	DateOrder DateOrder // "" to guess
+	DryRun    bool      // parse and count, but write nothing
+	// Resolve finds the JID of a sender by name; it may be nil.
+	Resolve func(name string) (types.JID, bool)
+	// MediaDir receives attached images; "media_cache" if empty.
+	MediaDir string
+}
+
+// Result counts what was imported.
+type Result struct {
+	Messages int    // messages in the export
+	Added    int    // new in messages.db; the rest were there already
+	Media    int    // images copied
+	Me       string // the sender taken as me, "" if none
+	First    time.Time
+	Last     time.Time
+}
+
+// Import parses an archive and adds its messages to the chat in store.
+// Importing the same export again, or one that overlaps messages already
+// received, adds nothing twice.
+func Import(store *db.Store, a *Archive, opts Options) (Result, error) {
+	var res Result
+	if store == nil {
+		return res, errors.New("messages.db is not open")
+	}
+	if opts.Chat.IsEmpty() {
+		return res, errors.New("no chat given")
+	}
+	if opts.MediaDir == "" {
+		opts.MediaDir = "media_cache"
+	}
+	open, ok := a.files[a.chat]
+	if !ok {
+		return res, fmt.Errorf("%s is missing", a.chat)
+	}
+	r, err := open()
+	if err != nil {
+		return res, err
+	}
+	defer r.Close()
+	hasFile := func(name string) bool { _, ok := a.files[name]; return ok }
+	entries, precision, err := Parse(r, opts.DateOrder, hasFile)
+	if err != nil {
+		return res, err
+	}
+
+	me := meNames(entries, opts, a.Name)
+	chat := opts.Chat.String()
+	occurrences := make(map[string]int)
+	msgs := make([]apptypes.Message, 0, len(entries))
+	for _, e := range entries {
+		m := apptypes.Message{Timestamp: e.Time, System: e.Sender == ""}
+		switch {
+		case m.System:
+		case me[strings.ToLower(e.Sender)]:
+			m.FromMe, m.Sender, m.Status = true, "You", apptypes.StatusSent
+			res.Me = e.Sender
+		default:
+			m.Sender = e.Sender
+			m.SenderJID = senderJID(e.Sender, opts)
+		}
+		m.Content = e.Text
+		switch {
+		case e.Attachment != "":
+			kind := attachmentKind(e.Attachment)
+			if kind == "Image" && !opts.DryRun {
+				m.ImagePath, err = a.copyImage(store, e.Attachment, opts.MediaDir)
+				if err != nil {
+					return res, err
+				}
+				if m.ImagePath != "" {
+					res.Media++
+				}
+			}
+			m.Content = mediaContent(kind, e.Attachment, e.Text)
+		case e.Omitted != "":
+			m.Content = mediaContent(omittedKinds[e.Omitted], "", e.Text)
+		}
+
+		key := fmt.Sprintf("%s|%d|%s|%s", chat, m.Timestamp.Unix(), e.Sender, m.Content)
+		occurrences[key]++
+		h := sha256.Sum256(fmt.Appendf(nil, "%s|%d", key, occurrences[key]))
+		m.ID = db.ImportedIDPrefix + hex.EncodeToString(h[:10])
+		msgs = append(msgs, m)
+	}
+	res.Messages = len(msgs)
+	res.First, res.Last = msgs[0].Timestamp, msgs[len(msgs)-1].Timestamp
+	if opts.DryRun {
+		return res, nil
+	}
+
+	res.Added, err = store.ImportMessages(chat, msgs, precision)
+	if err != nil {
+		return res, err
+	}
+	last := msgs[len(msgs)-1]
+	name := ""
+	if !store.HasChat(chat) {
+		name = a.Name
+	}
+	store.UpsertChat(chat, name, opts.Chat.Server == types.GroupServer, last.Content, last.Timestamp)
+	return res, nil
+}
+
+// meNames returns the lowercased names that are me. Without Options.Me,
+// a direct chat's export with two senders, one of them the other person,
+// gives the second.
+func meNames(entries []Entry, opts Options, chatName string) map[string]bool {
+	me := make(map[string]bool)
+	for _, n := range opts.Me {
+		me[strings.ToLower(strings.TrimSpace(n))] = true
+	}
+	if len(me) > 0 || opts.Chat.Server != types.DefaultUserServer {
+		return me
+	}
+	senders := make(map[string]bool)
+	for _, e := range entries {
+		if e.Sender != "" {
+			senders[strings.ToLower(e.Sender)] = true
+		}
+	}
+	if len(senders) > 2 {
+		return me
+	}
+	other := ""
+	for s := range senders {
+		if s == strings.ToLower(chatName) || phoneUser(s) == opts.Chat.User {
+			other = s
+		}
+	}
+	if other == "" {
+		return me
+	}
+	for s := range senders {
+		if s != other {
+			me[s] = true
+		}
+	}
+	return me
+}
+
+// senderJID finds the JID of a sender other than me: the other person in a
+// direct chat, a phone number, or a known name.
+func senderJID(name string, opts Options) types.JID {
+	if opts.Chat.Server == types.DefaultUserServer {
+		return opts.Chat
+	}
+	if user := phoneUser(name); user != "" {
+		return types.NewJID(user, types.DefaultUserServer)
+	}
+	if opts.Resolve != nil {
+		if jid, ok := opts.Resolve(name); ok {
+			return jid
+		}
+	}
+	return types.JID{}
+}
+
+// phoneUser returns the digits of a sender shown as a phone number, such as
+// "+49 151 23456789", or "" if it isn't one.
+func phoneUser(name string) string {
+	digits := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("+ -()", r):
+			return -1
+		}
+		return 'x'
+	}, name)
+	if len(digits) < 7 || len(digits) > 15 || strings.ContainsRune(digits, 'x') {
+		return ""
+	}
+	return digits
+}
+
+// attachmentKind tells the kind of message from an attached file's name.
+func attachmentKind(file string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file), "."))
+	upper := strings.ToUpper(file)
+	switch {
+	case ext == "webp" || strings.HasPrefix(upper, "STK-") || strings.Contains(upper, "-STICKER-"):
+		return "Sticker"
+	case ext == "jpg" || ext == "jpeg" || ext == "png" || ext == "gif":
+		return "Image"
+	case ext == "mp4" || ext == "3gp" || ext == "mov" || ext == "mkv":
+		return "Video"
+	case ext == "opus" || ext == "ogg" || ext == "m4a" || ext == "aac" || ext == "mp3" || ext == "amr":
+		return "Voice message"
+	}
+	return "File"
+}
+
+// omittedKinds map Entry.Omitted to the kind of message.
+var omittedKinds = map[string]string{
+	"image": "Image", "video": "Video", "gif": "Video", "audio": "Voice message",
+	"sticker": "Sticker", "document": "Document", "media": "Media omitted",
+}
+
+// mediaContent returns the content the client stores for a media message:
+// "[Image: caption]", "[Voice message]", "[File: name]" and so on.
+func mediaContent(kind, file, caption string) string {
+	switch kind {
+	case "File":
+		return "[File: " + file + "]"
+	case "Image", "Video", "Media omitted":
+		if caption != "" {
+			return "[" + kind + ": " + caption + "]"
+		}
+	}
+	return "[" + kind + "]"
+}
+
+// copyImage copies an attached image into dir under a name from its
+// content and returns the path, or "" if the export doesn't include it.
+func (a *Archive) copyImage(store *db.Store, name, dir string) (string, error) {
+	open, ok := a.files[name]
+	if !ok {
+		return "", nil
+	}
+	r, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(data)
+	path := filepath.Join(dir, hex.EncodeToString(h[:8])+strings.ToLower(filepath.Ext(name)))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return path, store.WriteMedia(path, data)
+}
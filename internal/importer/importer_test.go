@@ -0,0 +1,192 @@
+package importer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/db/dbtest"
+	apptypes "DevStarByte/internal/types"
+)
+
+var alice = types.NewJID("491701111111", types.DefaultUserServer)
+
+func parse(t *testing.T, text string, order DateOrder) ([]Entry, time.Duration) {
+	t.Helper()
+	entries, precision, err := Parse(strings.NewReader(text), order, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries, precision
+}
+
+func TestParseAndroid(t *testing.T) {
+	entries, precision := parse(t, "\ufeff20/10/2026, 09:00 - Messages and calls are end-to-end encrypted.\r\n"+
+		"20/10/2026, 09:01 - Alice: Hi: there\r\n"+
+		"second line\r\n"+
+		"20/10/2026, 09:02 - Alice: IMG-20261020-WA0001.jpg (file attached)\r\n"+
+		"Our stand\r\n"+
+		"21/10/2026, 18:30 - Bob: <Media omitted>\r\n", "")
+	if len(entries) != 4 || precision != time.Minute {
+		t.Fatalf("%d entries, precision %v", len(entries), precision)
+	}
+	if e := entries[0]; e.Sender != "" || e.Text != "Messages and calls are end-to-end encrypted." {
+		t.Errorf("system line = %+v", e)
+	}
+	if e := entries[1]; e.Sender != "Alice" || e.Text != "Hi: there\nsecond line" ||
+		!e.Time.Equal(time.Date(2026, 10, 20, 9, 1, 0, 0, time.Local)) {
+		t.Errorf("multi-line = %+v", e)
+	}
+	if e := entries[2]; e.Attachment != "IMG-20261020-WA0001.jpg" || e.Text != "Our stand" {
+		t.Errorf("attachment = %+v", e)
+	}
+	if e := entries[3]; e.Omitted != "media" || e.Sender != "Bob" || e.Time.Day() != 21 {
+		t.Errorf("omitted = %+v", e)
+	}
+}
+
+func TestParseIOS(t *testing.T) {
+	entries, precision := parse(t, "[20.10.26, 09:00:05] Team: \u200eAlice created group \"Team\"\n"+
+		"\u200e[20.10.26, 09:01:30] Alice: \u200e<attached: 00000003-PHOTO-2026-10-20-09-01-30.jpg>\n"+
+		"[20.10.26, 09:02:00] Bob: \u200evideo omitted\n"+
+		"[20.10.26, 21:15:00] Bob: 20.10.26, 21:15 is not a new message\n", "")
+	if len(entries) != 4 || precision != time.Second {
+		t.Fatalf("%d entries, precision %v", len(entries), precision)
+	}
+	if e := entries[0]; e.Sender != "" || e.Text != `Alice created group "Team"` {
+		t.Errorf("system line = %+v", e)
+	}
+	if e := entries[1]; e.Attachment != "00000003-PHOTO-2026-10-20-09-01-30.jpg" ||
+		!e.Time.Equal(time.Date(2026, 10, 20, 9, 1, 30, 0, time.Local)) {
+		t.Errorf("attachment = %+v", e)
+	}
+	if e := entries[2]; e.Omitted != "video" {
+		t.Errorf("omitted = %+v", e)
+	}
+	if e := entries[3]; e.Text != "20.10.26, 21:15 is not a new message" {
+		t.Errorf("text = %+v", e)
+	}
+}
+
+func TestParseDateOrders(t *testing.T) {
+	us := "10/20/26, 9:05 PM - Alice: evening\n1/2/26, 12:30 AM - Alice: night\n"
+	entries, _ := parse(t, us, "")
+	if got := entries[0].Time; !got.Equal(time.Date(2026, 10, 20, 21, 5, 0, 0, time.Local)) {
+		t.Errorf("PM = %v", got)
+	}
+	if got := entries[1].Time; !got.Equal(time.Date(2026, 1, 2, 0, 30, 0, 0, time.Local)) {
+		t.Errorf("12 AM = %v", got)
+	}
+	// 1/2 alone is ambiguous; the override decides.
+	entries, _ = parse(t, "1/2/26, 10:00 - Alice: hi\n", MDY)
+	if entries[0].Time.Month() != time.January {
+		t.Errorf("mdy = %v", entries[0].Time)
+	}
+	entries, _ = parse(t, "2026-10-20, 10:00 - Alice: hi\n", "")
+	if entries[0].Time.Day() != 20 {
+		t.Errorf("ymd = %v", entries[0].Time)
+	}
+	if _, _, err := Parse(strings.NewReader("20/10/26, 10:00 - Alice: hi\n"), MDY, nil); err == nil {
+		t.Error("month 20 accepted")
+	}
+	if _, _, err := Parse(strings.NewReader("just some text\n"), "", nil); err == nil {
+		t.Error("text without messages accepted")
+	}
+}
+
+func TestChatName(t *testing.T) {
+	for file, want := range map[string]string{
+		"WhatsApp Chat with Alice.txt":     "Alice",
+		"WhatsApp Chat - Team A - B.zip":   "Team A - B",
+		"WhatsApp-Chat mit Oma.txt":        "Oma",
+		"Chat de WhatsApp con Juan.txt":    "",
+		"_chat.txt":                        "",
+		"WhatsApp Chat with +49 170 1.txt": "+49 170 1",
+	} {
+		if got := chatName(file); got != want {
+			t.Errorf("chatName(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+// writeZip writes an Android export of a chat with Alice.
+func writeZip(t *testing.T, chat string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "WhatsApp Chat with Alice.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range map[string]string{
+		"WhatsApp Chat with Alice.txt": chat,
+		"IMG-20261020-WA0001.jpg":      "jpeg",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return path
+}
+
+func TestImport(t *testing.T) {
+	_, store := dbtest.New(t)
+	// Received live after linking; the export repeats it.
+	store.PersistMessage(alice.String(), apptypes.Message{ID: "live", Sender: "Alice", SenderJID: alice, Content: "See you",
+		Timestamp: time.Date(2026, 10, 21, 8, 0, 42, 0, time.Local)})
+
+	path := writeZip(t, "20/10/2026, 09:00 - Alice: Hi\n"+
+		"20/10/2026, 09:01 - Sam: Hello\nhow are you?\n"+
+		"20/10/2026, 09:02 - Alice: IMG-20261020-WA0001.jpg (file attached)\nOur stand\n"+
+		"20/10/2026, 09:02 - Alice: Hi\n"+
+		"21/10/2026, 08:00 - Alice: See you\n")
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if a.Name != "Alice" {
+		t.Errorf("Name = %q", a.Name)
+	}
+	opts := Options{Chat: alice}
+	res, err := Import(store, a, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Messages != 5 || res.Added != 4 || res.Media != 1 || res.Me != "Sam" {
+		t.Errorf("result = %+v", res)
+	}
+
+	msgs := store.LoadMessages(alice.String(), 10)
+	if len(msgs) != 5 {
+		t.Fatalf("%d messages stored", len(msgs))
+	}
+	if m := msgs[1]; !m.FromMe || m.Sender != "You" || m.Content != "Hello\nhow are you?" || !strings.HasPrefix(m.ID, db.ImportedIDPrefix) {
+		t.Errorf("own message = %+v", m)
+	}
+	if m := msgs[2]; m.Content != "[Image: Our stand]" || m.SenderJID != alice {
+		t.Errorf("image = %+v", m)
+	} else if data, err := os.ReadFile(m.ImagePath); err != nil || string(data) != "jpeg" {
+		t.Errorf("copied image: %q, %v", data, err)
+	}
+	if c := store.LoadChats(); len(c) != 1 || c[0].Name != "Alice" || c[0].LastMsg != "See you" {
+		t.Errorf("chats = %+v", c)
+	}
+
+	res, err = Import(store, a, opts)
+	if err != nil || res.Added != 0 {
+		t.Errorf("re-import added %d, %v", res.Added, err)
+	}
+}
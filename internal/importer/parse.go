@@ -0,0 +1,242 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── Parsing ───────────────────────────────────────────────────────────────────
+
+// Entry is one message of an export.
+type Entry struct {
+	Time   time.Time
+	Sender string // "" for system lines
+	Text   string // lines after the first are joined with "\n"
+	// Attachment is the file name of an attached file, "" if none. Text is
+	// then the caption.
+	Attachment string
+	// Omitted is the kind of a media message that was left out of the
+	// export: "image", "video", "audio", "sticker", "gif", "document", or
+	// "media" if the export doesn't say.
+	Omitted string
+}
+
+// DateOrder is the order of day, month and year in the export's dates,
+// which depends on the phone's language.
+type DateOrder string
+
+const (
+	DMY DateOrder = "dmy" // 20/10/2026, 20.10.26
+	MDY DateOrder = "mdy" // 10/20/26
+	YMD DateOrder = "ymd" // 2026-10-20
+)
+
+// Header of every message, in the layouts of Android ("20/10/2026, 09:00 -
+// Alice: Hi", "10/20/26, 9:00 PM - …") and iOS ("[20.10.26, 09:00:00]
+// Alice: Hi"). Lines that don't start with it continue the message before.
+var headerRe = regexp.MustCompile(`^\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),? (\d{1,2})[:.](\d{2})(?:[:.](\d{2}))? ?([AaPp])?(?:\. ?)?(?:[Mm]\.?)?\]?(?: [-–] | )(.*)$`)
+
+var (
+	// iOS: "<attached: 00000012-PHOTO-2026-10-20-09-00-00.jpg>", localized
+	// before the colon.
+	attachedIOS = regexp.MustCompile(`^<[^<>:]+: ?([^<>]+\.[A-Za-z0-9]{2,5})>$`)
+	// Android: "IMG-20261020-WA0001.jpg (file attached)", localized in the
+	// parentheses.
+	attachedAndroid = regexp.MustCompile(`^(.+\.[A-Za-z0-9]{2,5}) \(([^()]+)\)$`)
+	// iOS placeholder for media left out of the export: "image omitted".
+	omittedIOS = regexp.MustCompile(`(?i)^(image|video|audio|sticker|GIF|document) omitted$`)
+)
+
+// attachedWords are what Android writes in parentheses after an attached
+// file, in the common languages.
+var attachedWords = []string{
+	"file attached", "datei angehängt", "archivo adjunto", "fichier joint",
+	"arquivo anexado", "file allegato", "bestand bijgevoegd", "plik załączony",
+	"dosya ekli", "файл прикреплён", "файл прикреплен",
+}
+
+// omittedWords are Android's placeholder for media left out of the export.
+var omittedWords = []string{
+	"<Media omitted>", "<Medien ausgeschlossen>", "<Multimedia omitido>",
+	"<Médias omis>", "<Mídia oculta>", "<Media omessi>", "<Media weggelaten>",
+	"<Pominięto multimedia>", "<Medya dahil edilmedi>", "<Без медиафайлов>",
+}
+
+// rawEntry is an entry whose date is not interpreted yet.
+type rawEntry struct {
+	date    [3]int
+	clock   [3]int // hour, minute, second
+	pm, am  bool
+	seconds bool
+	body    string
+}
+
+// Parse reads an export. With order "", the date order is guessed from the
+// dates in it. hasFile reports whether a file of the export exists, which
+// confirms attachments in unusual wording; it may be nil. precision is
+// how exactly the times are given: a minute or a second.
+func Parse(r io.Reader, order DateOrder, hasFile func(name string) bool) (entries []Entry, precision time.Duration, err error) {
+	var raws []rawEntry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 16<<20)
+	first := true
+	for sc.Scan() {
+		line := normalise(sc.Text(), first)
+		first = false
+		m := headerRe.FindStringSubmatch(line)
+		if m == nil {
+			if len(raws) > 0 {
+				raws[len(raws)-1].body += "\n" + line
+			}
+			continue
+		}
+		var e rawEntry
+		for i := range 3 {
+			e.date[i], _ = strconv.Atoi(m[1+i])
+		}
+		e.clock[0], _ = strconv.Atoi(m[4])
+		e.clock[1], _ = strconv.Atoi(m[5])
+		if m[6] != "" {
+			e.clock[2], _ = strconv.Atoi(m[6])
+			e.seconds = true
+		}
+		e.pm = strings.EqualFold(m[7], "p")
+		e.am = strings.EqualFold(m[7], "a")
+		e.body = m[8]
+		raws = append(raws, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(raws) == 0 {
+		return nil, 0, fmt.Errorf("no messages found: not a WhatsApp chat export?")
+	}
+	if order == "" {
+		order = guessOrder(raws)
+	}
+
+	precision = time.Second
+	for _, raw := range raws {
+		t, err := raw.time(order)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !raw.seconds {
+			precision = time.Minute
+		}
+		entries = append(entries, parseBody(t, raw.body, hasFile))
+	}
+	return entries, precision, nil
+}
+
+// normalise removes the marks and odd spaces phones put into exports.
+func normalise(line string, first bool) string {
+	if first {
+		line = strings.TrimPrefix(line, "\ufeff")
+	}
+	line = strings.TrimSuffix(line, "\r")
+	// Keep a left-to-right mark after "Name: ", which iOS uses for system
+	// lines, but drop it before the header.
+	line = strings.TrimLeft(line, "\u200e\u200f")
+	return strings.NewReplacer("\u202f", " ", "\u00a0", " ").Replace(line)
+}
+
+// guessOrder picks the date order that fits all dates: a first number over
+// 31 is a year, one over 12 a day.
+func guessOrder(raws []rawEntry) DateOrder {
+	firstOver12, secondOver12 := false, false
+	for _, r := range raws {
+		if r.date[0] > 31 {
+			return YMD
+		}
+		firstOver12 = firstOver12 || r.date[0] > 12
+		secondOver12 = secondOver12 || r.date[1] > 12
+	}
+	if secondOver12 && !firstOver12 {
+		return MDY
+	}
+	return DMY
+}
+
+func (r rawEntry) time(order DateOrder) (time.Time, error) {
+	var y, mo, d int
+	switch order {
+	case MDY:
+		mo, d, y = r.date[0], r.date[1], r.date[2]
+	case YMD:
+		y, mo, d = r.date[0], r.date[1], r.date[2]
+	default:
+		d, mo, y = r.date[0], r.date[1], r.date[2]
+	}
+	if y < 100 {
+		y += 2000
+	}
+	h := r.clock[0]
+	switch {
+	case r.pm && h < 12:
+		h += 12
+	case r.am && h == 12:
+		h = 0
+	}
+	t := time.Date(y, time.Month(mo), d, h, r.clock[1], r.clock[2], 0, time.Local)
+	if t.Day() != d || t.Month() != time.Month(mo) || h > 23 || r.clock[1] > 59 {
+		return time.Time{}, fmt.Errorf("invalid date %d/%d/%d in %s order", r.date[0], r.date[1], r.date[2], order)
+	}
+	return t, nil
+}
+
+// parseBody splits "Sender: text" and recognises attachments and system
+// lines.
+func parseBody(t time.Time, body string, hasFile func(string) bool) Entry {
+	e := Entry{Time: t, Text: body}
+	i := strings.Index(body, ": ")
+	first, _, _ := strings.Cut(body, "\n")
+	if i < 0 || i > len(first) || strings.ContainsRune(body[:i], '"') || len([]rune(body[:i])) > 80 {
+		// Android system lines have no sender.
+		e.Text = strings.TrimLeft(body, "\u200e")
+		return e
+	}
+	e.Sender, e.Text = body[:i], body[i+2:]
+	iosMark := strings.HasPrefix(e.Text, "\u200e")
+	e.Text = strings.TrimLeft(e.Text, "\u200e")
+
+	line, caption, _ := strings.Cut(e.Text, "\n")
+	line = strings.TrimSpace(line)
+	if m := attachedIOS.FindStringSubmatch(line); m != nil {
+		e.Attachment, e.Text = m[1], caption
+		return e
+	}
+	if m := attachedAndroid.FindStringSubmatch(line); m != nil &&
+		(slicesContainsFold(attachedWords, m[2]) || hasFile != nil && hasFile(m[1])) {
+		e.Attachment, e.Text = m[1], caption
+		return e
+	}
+	if m := omittedIOS.FindStringSubmatch(line); m != nil {
+		e.Omitted, e.Text = strings.ToLower(m[1]), caption
+		return e
+	}
+	if slicesContainsFold(omittedWords, line) {
+		e.Omitted, e.Text = "media", caption
+		return e
+	}
+	if iosMark {
+		// iOS marks system lines, which it gives the chat's name as the
+		// sender.
+		e.Sender = ""
+	}
+	return e
+}
+
+func slicesContainsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+// Package keyring stores secrets in the system keyring (GNOME Keyring,
+// KWallet, KeePassXC, …) through the freedesktop Secret Service D-Bus API.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	serviceDest    = "org.freedesktop.secrets"
+	servicePath    = "/org/freedesktop/secrets"
+	serviceIface   = "org.freedesktop.Secret.Service"
+	collectionFace = "org.freedesktop.Secret.Collection"
+	itemIface      = "org.freedesktop.Secret.Item"
+	promptIface    = "org.freedesktop.Secret.Prompt"
+
+	// promptTimeout is how long the user has to answer an unlock prompt.
+	promptTimeout = 2 * time.Minute
+)
+
+// noPath is what the Secret Service returns for "no object" or "no prompt".
+const noPath = dbus.ObjectPath("/")
+
+// ErrNotFound means no item matches the attributes.
+var ErrNotFound = errors.New("no such secret in the keyring")
+
+// secret is the Secret Service's (oayays) secret struct.
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// client is a session with the Secret Service.
+type client struct {
+	conn    *dbus.Conn
+	svc     dbus.BusObject
+	session dbus.ObjectPath
+}
+
+// connect opens a plain session; the secret only crosses the local bus.
+func connect() (*client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("D-Bus session bus unavailable: %w", err)
+	}
+	c := &client{conn: conn, svc: conn.Object(serviceDest, servicePath)}
+	var out dbus.Variant
+	if err := c.svc.Call(serviceIface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&out, &c.session); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no Secret Service: %w", err)
+	}
+	return c, nil
+}
+
+func (c *client) close() {
+	c.conn.Object(serviceDest, c.session).Call("org.freedesktop.Secret.Session.Close", 0)
+	c.conn.Close()
+}
+
+// unlock unlocks objects, showing the keyring's prompt if it needs one.
+func (c *client) unlock(objects ...dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := c.svc.Call(serviceIface+".Unlock", 0, objects).Store(&unlocked, &prompt); err != nil {
+		return err
+	}
+	return c.prompt(prompt)
+}
+
+// prompt runs a prompt and waits until the user answers it.
+func (c *client) prompt(path dbus.ObjectPath) error {
+	if path == noPath || path == "" {
+		return nil
+	}
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(promptIface),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := c.conn.AddMatchSignal(match...); err != nil {
+		return err
+	}
+	defer c.conn.RemoveMatchSignal(match...)
+	signals := make(chan *dbus.Signal, 1)
+	c.conn.Signal(signals)
+	defer c.conn.RemoveSignal(signals)
+
+	if err := c.conn.Object(serviceDest, path).Call(promptIface+".Prompt", 0, "").Err; err != nil {
+		return err
+	}
+	timeout := time.After(promptTimeout)
+	for {
+		select {
+		case sig := <-signals:
+			if sig.Path != path || len(sig.Body) == 0 {
+				continue
+			}
+			if dismissed, _ := sig.Body[0].(bool); dismissed {
+				return errors.New("the keyring prompt was dismissed")
+			}
+			return nil
+		case <-timeout:
+			return errors.New("the keyring prompt timed out")
+		}
+	}
+}
+
+// Get returns the secret of the item matching attrs.
+func Get(attrs map[string]string) ([]byte, error) {
+	c, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+	var unlocked, locked []dbus.ObjectPath
+	if err := c.svc.Call(serviceIface+".SearchItems", 0, attrs).Store(&unlocked, &locked); err != nil {
+		return nil, err
+	}
+	var item dbus.ObjectPath
+	switch {
+	case len(unlocked) > 0:
+		item = unlocked[0]
+	case len(locked) > 0:
+		item = locked[0]
+		if err := c.unlock(item); err != nil {
+			return nil, fmt.Errorf("unlocking the keyring: %w", err)
+		}
+	default:
+		return nil, ErrNotFound
+	}
+	var s secret
+	if err := c.conn.Object(serviceDest, item).Call(itemIface+".GetSecret", 0, c.session).Store(&s); err != nil {
+		return nil, err
+	}
+	return s.Value, nil
+}
+
+// Set stores value in the default collection as an item with attrs,
+// replacing any item that has the same attributes.
+func Set(label string, attrs map[string]string, value []byte) error {
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	var coll dbus.ObjectPath
+	if err := c.svc.Call(serviceIface+".ReadAlias", 0, "default").Store(&coll); err != nil {
+		return err
+	}
+	if coll == noPath {
+		return errors.New("the keyring has no default collection")
+	}
+	if err := c.unlock(coll); err != nil {
+		return fmt.Errorf("unlocking the keyring: %w", err)
+	}
+	props := map[string]dbus.Variant{
+		itemIface + ".Label":      dbus.MakeVariant(label),
+		itemIface + ".Attributes": dbus.MakeVariant(attrs),
+	}
+	s := secret{Session: c.session, Value: value, ContentType: "application/octet-stream"}
+	var item, prompt dbus.ObjectPath
+	if err := c.conn.Object(serviceDest, coll).Call(collectionFace+".CreateItem", 0, props, s, true).Store(&item, &prompt); err != nil {
+		return err
+	}
+	return c.prompt(prompt)
+}
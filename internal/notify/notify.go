@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/StarGames2025/Logger"
+	"github.com/godbus/dbus/v5"
+)
+
+// Backend names as used by the "notify" config setting.
+const (
+	BackendAuto   = "auto"
+	BackendDBus   = "dbus"   // freedesktop notifications over the D-Bus session bus
+	BackendOSC9   = "osc9"   // iTerm2 / kitty / WezTerm / Windows Terminal
+	BackendOSC777 = "osc777" // VTE terminals (GNOME Terminal, Tilix, …), foot, urxvt
+	BackendBell   = "bell"   // terminal bell; tmux flags the window
+	BackendOff    = "off"
+)
+
+// Backends lists the valid backend names.
+var Backends = []string{BackendAuto, BackendDBus, BackendOSC9, BackendOSC777, BackendBell, BackendOff}
+
+const (
+	appName     = "WhatsApp TUI"
+	dbusDest    = "org.freedesktop.Notifications"
+	dbusPath    = "/org/freedesktop/Notifications"
+	dbusNotify  = dbusDest + ".Notify"
+	dbusTimeout = int32(-1) // server default
+)
+
+// Notifier shows desktop notifications through one backend. A nil Notifier
+// is valid and does nothing.
+type Notifier struct {
+	logger  *Logger.Logger
+	backend string
+	out     io.Writer // terminal for escape-sequence backends
+
+	mu       sync.Mutex
+	conn     *dbus.Conn
+	replaces map[string]uint32 // notification ID per key, so a chat keeps one bubble
+}
+
+// New creates a Notifier for the named backend. "auto" picks D-Bus when a
+// session bus is reachable and falls back to what the terminal supports.
+func New(logger *Logger.Logger, backend string) *Notifier {
+	n := &Notifier{
+		logger:   logger,
+		backend:  backend,
+		out:      os.Stdout,
+		replaces: make(map[string]uint32),
+	}
+	if backend == BackendAuto || backend == BackendDBus {
+		conn, err := dbus.ConnectSessionBus()
+		switch {
+		case err == nil:
+			n.conn = conn
+			n.backend = BackendDBus
+		case backend == BackendDBus:
+			logger.Warning("D-Bus session bus unavailable, notifications disabled: " + err.Error())
+			n.backend = BackendOff
+		default:
+			n.backend = detectTerminal()
+		}
+	}
+	logger.Info("Notification backend: " + n.backend)
+	return n
+}
+
+// detectTerminal guesses which escape-sequence notification the terminal
+// understands, falling back to the bell.
+func detectTerminal() string {
+	term := os.Getenv("TERM")
+	switch prog := os.Getenv("TERM_PROGRAM"); {
+	case prog == "iTerm.app", prog == "WezTerm", os.Getenv("WT_SESSION") != "",
+		strings.Contains(term, "kitty"):
+		return BackendOSC9
+	case os.Getenv("VTE_VERSION") != "", strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "rxvt"):
+		return BackendOSC777
+	}
+	return BackendBell
+}
+
+// Backend returns the backend in use.
+func (n *Notifier) Backend() string {
+	if n == nil {
+		return BackendOff
+	}
+	return n.backend
+}
+
+// Notify shows a notification. key groups notifications (e.g. per chat): on
+// D-Bus a new notification replaces the previous one with the same key.
+func (n *Notifier) Notify(key, title, body string) error {
+	if n == nil {
+		return nil
+	}
+	title, body = sanitize(title), sanitize(body)
+	switch n.backend {
+	case BackendDBus:
+		return n.notifyDBus(key, title, body)
+	case BackendOSC9:
+		return n.writeTerm(osc("9;" + title + ": " + body))
+	case BackendOSC777:
+		return n.writeTerm(osc("777;notify;" + strings.ReplaceAll(title, ";", ",") + ";" + body))
+	case BackendBell:
+		// tmux handles the bell itself, so it is never wrapped.
+		_, err := io.WriteString(n.out, "\a")
+		return err
+	}
+	return nil
+}
+
+// Close releases the D-Bus connection.
+func (n *Notifier) Close() {
+	if n != nil && n.conn != nil {
+		n.conn.Close()
+	}
+}
+
+func (n *Notifier) notifyDBus(key, title, body string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		return errors.New("no D-Bus connection")
+	}
+	// The body may contain markup, so escape it.
+	body = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(body)
+	obj := n.conn.Object(dbusDest, dbus.ObjectPath(dbusPath))
+	call := obj.Call(dbusNotify, 0, appName, n.replaces[key], "", title, body,
+		[]string{}, map[string]dbus.Variant{}, dbusTimeout)
+	var id uint32
+	if err := call.Store(&id); err != nil {
+		n.logger.Warning("D-Bus notification failed: " + err.Error())
+		return err
+	}
+	n.replaces[key] = id
+	return nil
+}
+
+// writeTerm writes an escape sequence to the terminal, wrapped in tmux's
+// passthrough sequence when running inside tmux. It is written in one call so
+// it does not interleave with the TUI's frames.
+func (n *Notifier) writeTerm(seq string) error {
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err := io.WriteString(n.out, seq)
+	return err
+}
+
+// osc builds an OSC sequence terminated by BEL.
+func osc(payload string) string {
+	return "\x1b]" + payload + "\a"
+}
+
+// sanitize flattens text to one line and drops control characters, which
+// would otherwise end an escape sequence early.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case r < 0x20 || r >= 0x7f && r <= 0x9f:
+			return -1
+		}
+		return r
+	}, s)
+}
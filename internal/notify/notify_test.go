@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/StarGames2025/Logger"
+)
+
+// clearTerm unsets every variable detectTerminal and writeTerm look at, and
+// points D-Bus at a socket that doesn't exist.
+func clearTerm(t *testing.T) {
+	t.Helper()
+	for _, k := range []string{"TERM", "TERM_PROGRAM", "WT_SESSION", "VTE_VERSION", "TMUX"} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+t.TempDir()+"/bus")
+}
+
+func newLogger(t *testing.T) *Logger.Logger {
+	t.Helper()
+	logger, err := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logger
+}
+
+func TestDetectTerminal(t *testing.T) {
+	for _, tt := range []struct {
+		env  map[string]string
+		want string
+	}{
+		{nil, BackendBell},
+		{map[string]string{"TERM": "xterm-256color"}, BackendBell},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, BackendOSC9},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, BackendOSC9},
+		{map[string]string{"WT_SESSION": "1"}, BackendOSC9},
+		{map[string]string{"TERM": "xterm-kitty"}, BackendOSC9},
+		{map[string]string{"TERM": "xterm-256color", "VTE_VERSION": "7600"}, BackendOSC777},
+		{map[string]string{"TERM": "foot"}, BackendOSC777},
+		{map[string]string{"TERM": "rxvt-unicode-256color"}, BackendOSC777},
+	} {
+		clearTerm(t)
+		for k, v := range tt.env {
+			t.Setenv(k, v)
+		}
+		if got := detectTerminal(); got != tt.want {
+			t.Errorf("detectTerminal with %v = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	logger := newLogger(t)
+	for _, tt := range []struct {
+		backend, term, want string
+	}{
+		// Without a session bus, auto falls back to the terminal and an
+		// explicit dbus turns notifications off.
+		{BackendAuto, "xterm-kitty", BackendOSC9},
+		{BackendAuto, "dumb", BackendBell},
+		{BackendDBus, "xterm-kitty", BackendOff},
+		{BackendOSC777, "xterm-kitty", BackendOSC777},
+		{BackendBell, "", BackendBell},
+		{BackendOff, "", BackendOff},
+	} {
+		clearTerm(t)
+		t.Setenv("TERM", tt.term)
+		n := New(logger, tt.backend)
+		if got := n.Backend(); got != tt.want {
+			t.Errorf("New(%q) with TERM=%q: backend = %q, want %q", tt.backend, tt.term, got, tt.want)
+		}
+		n.Close()
+	}
+	var n *Notifier
+	if n.Backend() != BackendOff || n.Notify("k", "t", "b") != nil {
+		t.Error("nil Notifier isn't a no-op")
+	}
+}
+
+func TestNotifyEscapes(t *testing.T) {
+	logger := newLogger(t)
+	for _, tt := range []struct {
+		backend, tmux, want string
+	}{
+		{BackendOSC9, "", "\x1b]9;Alice: hi there\a"},
+		{BackendOSC777, "", "\x1b]777;notify;Alice;hi there\a"},
+		{BackendBell, "", "\a"},
+		{BackendOff, "", ""},
+		// tmux gets the sequence wrapped in its passthrough, with ESC doubled.
+		{BackendOSC9, "/tmp/tmux-0/default,1,0", "\x1bPtmux;\x1b\x1b]9;Alice: hi there\a\x1b\\"},
+		{BackendBell, "/tmp/tmux-0/default,1,0", "\a"},
+	} {
+		clearTerm(t)
+		t.Setenv("TMUX", tt.tmux)
+		n := New(logger, tt.backend)
+		var out bytes.Buffer
+		n.out = &out
+		// Control characters in the text would end the sequence early.
+		if err := n.Notify("chat", "Ali\x07ce", "hi\nthere\x1b"); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s (TMUX=%q) wrote %q, want %q", tt.backend, tt.tmux, got, tt.want)
+		}
+	}
+
+	// OSC 777 separates fields with semicolons.
+	clearTerm(t)
+	n := New(logger, BackendOSC777)
+	var out bytes.Buffer
+	n.out = &out
+	n.Notify("chat", "Team; Ops", "a;b")
+	if want := "\x1b]777;notify;Team, Ops;a;b\a"; out.String() != want {
+		t.Errorf("OSC 777 wrote %q, want %q", out.String(), want)
+	}
+}
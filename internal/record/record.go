@@ -0,0 +1,288 @@
+// Package record writes the WhatsApp events the app receives to a file and
+// reads them back, so a user's bug can be replayed without their account.
+//
+// A recording is JSON lines: a Header, then one entry per event. Proto
+// payloads are stored as protojson, everything else with encoding/json.
+package record
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"DevStarByte/internal/wa"
+)
+
+// Header is the first line of a recording: who was logged in and what the
+// contact store knew, since name resolution depends on both.
+type Header struct {
+	Started  time.Time                       `json:"started"`
+	Own      types.JID                       `json:"own"`
+	OwnLID   types.JID                       `json:"own_lid"`
+	Redacted bool                            `json:"redacted,omitempty"`
+	Contacts map[types.JID]types.ContactInfo `json:"contacts,omitempty"`
+}
+
+// entry is one recorded event. At is the time since the recording started.
+type entry struct {
+	At    time.Duration   `json:"at"`
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event,omitempty"`
+	Proto json.RawMessage `json:"proto,omitempty"`
+}
+
+// kinds lists the events NewEventHandler acts on, by entry type. Others are
+// not recorded.
+var kinds = map[string]func() interface{}{
+	"Message":        func() interface{} { return &events.Message{} },
+	"HistorySync":    func() interface{} { return &events.HistorySync{} },
+	"GroupInfo":      func() interface{} { return &events.GroupInfo{} },
+	"JoinedGroup":    func() interface{} { return &events.JoinedGroup{} },
+	"Pin":            func() interface{} { return &events.Pin{} },
+	"Archive":        func() interface{} { return &events.Archive{} },
+	"Mute":           func() interface{} { return &events.Mute{} },
+	"MarkChatAsRead": func() interface{} { return &events.MarkChatAsRead{} },
+	"Receipt":        func() interface{} { return &events.Receipt{} },
+	"Presence":       func() interface{} { return &events.Presence{} },
+	"ChatPresence":   func() interface{} { return &events.ChatPresence{} },
+}
+
+// Events are recorded as received, even when they lack required fields.
+var (
+	protoMarshal   = protojson.MarshalOptions{AllowPartial: true}
+	protoUnmarshal = protojson.UnmarshalOptions{AllowPartial: true, DiscardUnknown: true}
+)
+
+// ── Recorder ──────────────────────────────────────────────────────────────────
+
+// Recorder appends events to a recording. A nil Recorder is valid and does
+// nothing.
+type Recorder struct {
+	logger *Logger.Logger
+	client wa.Client
+
+	mu      sync.Mutex
+	f       *os.File
+	w       *bufio.Writer
+	redact  *redactor // nil writes events verbatim
+	started time.Time
+	header  bool
+}
+
+// Create starts a recording at path, replacing any file there. With redact
+// set, names, numbers, message text and media keys are replaced before
+// anything is written.
+func Create(path string, client wa.Client, redact bool, logger *Logger.Logger) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{logger: logger, client: client, f: f, w: bufio.NewWriter(f)}
+	if redact {
+		r.redact = newRedactor()
+	}
+	return r, nil
+}
+
+// Record appends evt if it is one of the recorded kinds. The header is
+// written with the first event, once the client knows who is logged in.
+func (r *Recorder) Record(evt interface{}) {
+	if r == nil {
+		return
+	}
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", evt), "*events.")
+	if kinds[kind] == nil {
+		return
+	}
+	e, err := encode(evt)
+	if err != nil {
+		r.logger.Warning("Recorder: encoding " + kind + " failed: " + err.Error())
+		return
+	}
+	e.Type = kind
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.header {
+		r.header = true
+		r.started = time.Now()
+		if err := r.writeLine(r.newHeader()); err != nil {
+			r.logger.Warning("Recorder: writing header failed: " + err.Error())
+		}
+	}
+	e.At = time.Since(r.started)
+	if err := r.writeLine(e); err != nil {
+		r.logger.Warning("Recorder: writing " + kind + " failed: " + err.Error())
+	}
+}
+
+func (r *Recorder) newHeader() Header {
+	h := Header{
+		Started:  r.started,
+		Own:      r.client.OwnID(),
+		OwnLID:   r.client.OwnLID(),
+		Redacted: r.redact != nil,
+	}
+	contacts, err := r.client.GetAllContacts(context.Background())
+	if err != nil {
+		r.logger.Warning("Recorder: loading contacts failed: " + err.Error())
+	}
+	h.Contacts = contacts
+	return h
+}
+
+// writeLine writes v as one line, redacted if requested, and flushes so a
+// crash loses at most the event being handled.
+func (r *Recorder) writeLine(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if r.redact != nil {
+		if b, err = r.redact.line(b); err != nil {
+			return err
+		}
+	}
+	if _, err := r.w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return r.w.Flush()
+}
+
+// Close flushes and closes the recording.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Join(r.w.Flush(), r.f.Close())
+}
+
+// encode splits evt into its plain fields and its proto payload, which
+// encoding/json can't round-trip.
+func encode(evt interface{}) (entry, error) {
+	var (
+		e     entry
+		plain interface{} = evt
+		err   error
+	)
+	switch evt := evt.(type) {
+	case *events.Message:
+		msg := evt.RawMessage
+		if msg == nil {
+			msg = evt.Message
+		}
+		if e.Proto, err = protoMarshal.Marshal(msg); err != nil {
+			return e, err
+		}
+		c := *evt
+		c.Message, c.RawMessage, c.SourceWebMsg = nil, nil, nil
+		plain = &c
+	case *events.HistorySync:
+		e.Proto, err = protoMarshal.Marshal(evt.Data)
+		return e, err
+	}
+	e.Event, err = json.Marshal(plain)
+	return e, err
+}
+
+// ── Reading ───────────────────────────────────────────────────────────────────
+
+// Recording is a recording read back from disk.
+type Recording struct {
+	Header
+	// Steps replays the events with their original spacing.
+	Steps []wa.Step
+}
+
+// Open reads the recording at path.
+func Open(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rec := &Recording{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 256<<20) // history sync batches can be large
+	line := 0
+	var last time.Duration
+	for sc.Scan() {
+		line++
+		if line == 1 {
+			if err := json.Unmarshal(sc.Bytes(), &rec.Header); err != nil {
+				return nil, fmt.Errorf("line 1: header: %w", err)
+			}
+			continue
+		}
+		var e entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		evt, err := decode(e)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", line, e.Type, err)
+		}
+		rec.Steps = append(rec.Steps, wa.Step{After: max(0, e.At-last), Event: evt})
+		last = e.At
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if line == 0 {
+		return nil, errors.New("empty recording")
+	}
+	return rec, nil
+}
+
+func decode(e entry) (interface{}, error) {
+	newEvt := kinds[e.Type]
+	if newEvt == nil {
+		return nil, errors.New("unknown event type")
+	}
+	evt := newEvt()
+	if len(e.Event) > 0 {
+		if err := json.Unmarshal(e.Event, evt); err != nil {
+			return nil, err
+		}
+	}
+	switch evt := evt.(type) {
+	case *events.Message:
+		msg := &waE2E.Message{}
+		if err := protoUnmarshal.Unmarshal(e.Proto, msg); err != nil {
+			return nil, err
+		}
+		evt.RawMessage = msg
+		evt.UnwrapRaw()
+	case *events.HistorySync:
+		evt.Data = &waHistorySync.HistorySync{}
+		if err := protoUnmarshal.Unmarshal(e.Proto, evt.Data); err != nil {
+			return nil, err
+		}
+	}
+	return evt, nil
+}
+
+// Scale returns steps with their delays multiplied by factor.
+func Scale(steps []wa.Step, factor float64) []wa.Step {
+	out := make([]wa.Step, len(steps))
+	for i, st := range steps {
+		out[i] = wa.Step{After: time.Duration(float64(st.After) * factor), Event: st.Event}
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+package record
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"DevStarByte/internal/wa"
+)
+
+var (
+	me    = types.NewJID("491700000000", types.DefaultUserServer)
+	alice = types.NewJID("491701111111", types.DefaultUserServer)
+	group = types.NewJID("120363000000000001", types.GroupServer)
+	t0    = time.Unix(1_760_000_000, 0).UTC()
+)
+
+// sample returns one event of each interesting shape.
+func sample() []interface{} {
+	history := &events.HistorySync{Data: &waHistorySync.HistorySync{
+		SyncType: waHistorySync.HistorySync_INITIAL_BOOTSTRAP.Enum(),
+		Conversations: []*waHistorySync.Conversation{{
+			ID:   proto.String(alice.String()),
+			Name: proto.String("Alice Smith"),
+			Messages: []*waHistorySync.HistorySyncMsg{{Message: &waWeb.WebMessageInfo{
+				Key: &waCommon.MessageKey{
+					RemoteJID: proto.String(alice.String()),
+					ID:        proto.String("h1"),
+				},
+				MessageTimestamp: proto.Uint64(uint64(t0.Unix())),
+			}}},
+		}},
+		Pushnames: []*waHistorySync.Pushname{{ID: proto.String(alice.String()), Pushname: proto.String("Ali")}},
+	}}
+	name := "Team 🚀"
+	return []interface{}{
+		wa.TextEvent(alice, alice, "Ali", "m1", "Meet at *Café Zürich* 12:30?", t0),
+		history,
+		&events.Receipt{
+			MessageSource: types.MessageSource{Chat: alice, Sender: alice},
+			MessageIDs:    []types.MessageID{"m0"},
+			Timestamp:     t0,
+			Type:          types.ReceiptTypeRead,
+		},
+		&events.GroupInfo{JID: group, Sender: &alice, Timestamp: t0, Name: &types.GroupName{Name: name}},
+		&events.Pin{JID: group, Timestamp: t0, Action: &waSyncAction.PinAction{Pinned: proto.Bool(true)}},
+		&events.Connected{}, // not handled, so not recorded
+	}
+}
+
+func recordSample(t *testing.T, redact bool) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rec.jsonl")
+	logger, err := Logger.NewLogger(Logger.ERROR, os.DevNull, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := wa.NewFake(me)
+	fake.Contacts[alice] = types.ContactInfo{Found: true, FullName: "Alice Smith", PushName: "Ali"}
+	r, err := Create(path, fake, redact, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, evt := range sample() {
+		r.Record(evt)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+	rec, err := Open(recordSample(t, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Own != me || rec.Redacted || rec.Contacts[alice].FullName != "Alice Smith" {
+		t.Errorf("header = %+v", rec.Header)
+	}
+	if len(rec.Steps) != 5 {
+		t.Fatalf("got %d steps, want 5", len(rec.Steps))
+	}
+
+	msg := rec.Steps[0].Event.(*events.Message)
+	if msg.Info.Chat != alice || msg.Info.PushName != "Ali" || !msg.Info.Timestamp.Equal(t0) ||
+		msg.Message.GetConversation() != "Meet at *Café Zürich* 12:30?" {
+		t.Errorf("message = %+v", msg)
+	}
+	conv := rec.Steps[1].Event.(*events.HistorySync).Data.GetConversations()[0]
+	if conv.GetName() != "Alice Smith" || conv.GetMessages()[0].GetMessage().GetKey().GetID() != "h1" {
+		t.Errorf("history conversation = %v", conv)
+	}
+	receipt := rec.Steps[2].Event.(*events.Receipt)
+	if receipt.Chat != alice || !slices.Equal(receipt.MessageIDs, []types.MessageID{"m0"}) || receipt.Type != types.ReceiptTypeRead {
+		t.Errorf("receipt = %+v", receipt)
+	}
+	gi := rec.Steps[3].Event.(*events.GroupInfo)
+	if gi.Name.Name != "Team 🚀" || *gi.Sender != alice {
+		t.Errorf("group info = %+v", gi)
+	}
+	if !rec.Steps[4].Event.(*events.Pin).Action.GetPinned() {
+		t.Error("pin action lost")
+	}
+}
+
+func TestRedactedRecording(t *testing.T) {
+	path := recordSample(t, true)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{alice.User, me.User, "Alice", "Ali", "Café", "Zürich", "Team"} {
+		if bytes.Contains(raw, []byte(secret)) {
+			t.Errorf("recording still contains %q", secret)
+		}
+	}
+
+	rec, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Redacted {
+		t.Error("header not marked redacted")
+	}
+	// The same person keeps the same stand-in everywhere.
+	msg := rec.Steps[0].Event.(*events.Message)
+	if _, ok := rec.Contacts[msg.Info.Chat]; !ok {
+		t.Errorf("chat %s has no matching contact in %v", msg.Info.Chat, rec.Contacts)
+	}
+	if msg.Info.PushName != rec.Contacts[msg.Info.Chat].PushName {
+		t.Errorf("push name %q differs from contact %q", msg.Info.PushName, rec.Contacts[msg.Info.Chat].PushName)
+	}
+	if got := msg.Message.GetConversation(); got != "Xxxx xx *Xxxx Xxxxxx* 00:00?" {
+		t.Errorf("text redacted to %q", got)
+	}
+	if gi := rec.Steps[3].Event.(*events.GroupInfo); *gi.Sender != msg.Info.Chat || !strings.HasPrefix(gi.Name.Name, "Name ") {
+		t.Errorf("group info = sender %s, name %q", gi.Sender, gi.Name.Name)
+	}
+}
+
+func TestRedactFile(t *testing.T) {
+	raw, err := os.ReadFile(recordSample(t, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Redact(bytes.NewReader(raw), &out); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out.Bytes(), []byte(alice.User)) || bytes.Contains(out.Bytes(), []byte("Alice")) {
+		t.Error("redacted copy still names Alice")
+	}
+	if n := bytes.Count(out.Bytes(), []byte("\n")); n != 6 {
+		t.Errorf("redacted copy has %d lines, want 6", n)
+	}
+	if !bytes.Contains(out.Bytes(), []byte(`"redacted":true`)) {
+		t.Error("header not marked redacted")
+	}
+}
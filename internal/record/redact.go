@@ -0,0 +1,184 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ── Redaction ─────────────────────────────────────────────────────────────────
+//
+// Redaction works on the JSON of each line, so it covers every event type
+// without knowing its fields. Phone numbers and names get stand-ins that
+// stay the same across the whole file, so merges and name resolution still
+// behave as they did; message text keeps its shape and formatting marks but
+// loses its letters and digits.
+
+// jidRe matches the user part of a JID, with optional agent and device.
+var jidRe = regexp.MustCompile(`\b(\d{5,}(?:-\d+)?)((?:[.:]\d+)*@(?:s\.whatsapp\.net|c\.us|lid|g\.us|broadcast|newsletter))\b`)
+
+// nameKeys hold contact, chat and group names.
+var nameKeys = map[string]bool{
+	"name": true, "Name": true, "pushName": true, "PushName": true, "pushname": true,
+	"FullName": true, "FirstName": true, "BusinessName": true, "VerifiedName": true,
+	"displayName": true, "verifiedBizName": true,
+}
+
+// textKeys hold message text.
+var textKeys = map[string]bool{
+	"conversation": true, "text": true, "caption": true, "title": true,
+	"description": true, "body": true, "fileName": true, "contentText": true,
+	"footerText": true, "selectedDisplayText": true, "matchedText": true,
+	"vcard": true, "Topic": true,
+}
+
+// dropKeys hold media keys, hashes, thumbnails and URLs.
+var dropKeys = map[string]bool{
+	"mediaKey": true, "fileSHA256": true, "fileEncSHA256": true, "directPath": true,
+	"url": true, "URL": true, "jpegThumbnail": true, "thumbnail": true,
+	"thumbnailDirectPath": true, "thumbnailSHA256": true, "thumbnailEncSHA256": true,
+	"streamingSidecar": true, "waveform": true, "pngThumbnail": true, "canonicalURL": true,
+}
+
+type redactor struct {
+	users map[string]string // JID user → stand-in number
+	names map[string]string // name → stand-in name
+}
+
+func newRedactor() *redactor {
+	return &redactor{users: make(map[string]string), names: make(map[string]string)}
+}
+
+// Redact copies the recording in r to w with every line redacted.
+func Redact(r io.Reader, w io.Writer) error {
+	red := newRedactor()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 256<<20)
+	bw := bufio.NewWriter(w)
+	for line := 1; sc.Scan(); line++ {
+		b, err := red.line(sc.Bytes())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if line == 1 {
+			if b, err = markRedacted(b); err != nil {
+				return fmt.Errorf("line 1: %w", err)
+			}
+		}
+		bw.Write(b)
+		bw.WriteByte('\n')
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func markRedacted(header []byte) ([]byte, error) {
+	var h map[string]interface{}
+	if err := json.Unmarshal(header, &h); err != nil {
+		return nil, err
+	}
+	h["redacted"] = true
+	return json.Marshal(h)
+}
+
+// line redacts one JSON line. An entry's event and proto are stored as
+// nested objects, so they are covered too.
+func (r *redactor) line(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(r.value("", v))
+}
+
+func (r *redactor) object(obj map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if dropKeys[k] {
+			continue
+		}
+		out[r.jids(k)] = r.value(k, v)
+	}
+	return out
+}
+
+func (r *redactor) value(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return r.object(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, x := range v {
+			out[i] = r.value(key, x)
+		}
+		return out
+	case string:
+		switch {
+		case nameKeys[key]:
+			return r.name(v)
+		case textKeys[key]:
+			return scramble(v)
+		}
+		return r.jids(v)
+	}
+	return v
+}
+
+// jids replaces the user part of every JID in s.
+func (r *redactor) jids(s string) string {
+	return jidRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := jidRe.FindStringSubmatch(m)
+		return r.user(sub[1]) + sub[2]
+	})
+}
+
+func (r *redactor) user(u string) string {
+	if s, ok := r.users[u]; ok {
+		return s
+	}
+	s := fmt.Sprintf("1%011d", len(r.users)+1)
+	r.users[u] = s
+	return s
+}
+
+// name gives each distinct name a stand-in. Names that are just a phone
+// number stay numbers, since the app treats those differently.
+func (r *redactor) name(n string) string {
+	if n == "" {
+		return n
+	}
+	if s, ok := r.names[n]; ok {
+		return s
+	}
+	s := fmt.Sprintf("Name %d", len(r.names)+1)
+	if strings.Trim(n, "+0123456789 -()") == "" {
+		s = scramble(n)
+	}
+	r.names[n] = s
+	return s
+}
+
+// scramble replaces letters with x and digits with 0, keeping case,
+// spacing, punctuation, formatting marks and emoji.
+func scramble(s string) string {
+	return strings.Map(func(c rune) rune {
+		switch {
+		case unicode.IsUpper(c):
+			return 'X'
+		case unicode.IsLetter(c):
+			return 'x'
+		case unicode.IsDigit(c):
+			return '0'
+		}
+		return c
+	}, s)
+}
@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// ErrClosed is returned by calls on a connection that has gone away.
+var ErrClosed = errors.New("daemon connection closed")
+
+// Conn is a client connection to the daemon. It is safe for concurrent use.
+type Conn struct {
+	nc net.Conn
+
+	wmu sync.Mutex // serialises writes
+	enc *json.Encoder
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[string]chan Response
+	onEvent func(Event)
+	err     error
+
+	done chan struct{}
+}
+
+// Dial connects to the daemon listening at path.
+func Dial(path string) (*Conn, error) {
+	nc, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{
+		nc:      nc,
+		enc:     json.NewEncoder(nc),
+		pending: make(map[string]chan Response),
+		done:    make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// Call invokes method and decodes its result into result, which may be nil.
+func (c *Conn) Call(method string, params, result interface{}) error {
+	ch := make(chan Response, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.send(json.RawMessage(id), method, params); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-c.done:
+		return ErrClosed
+	}
+}
+
+// Notify sends method without waiting for, or getting, an answer.
+func (c *Conn) Notify(method string, params interface{}) error {
+	return c.send(nil, method, params)
+}
+
+// Subscribe asks for events and passes each one to fn, in order, on the
+// connection's reader goroutine; fn must not call back into c.
+func (c *Conn) Subscribe(fn func(Event)) error {
+	c.mu.Lock()
+	c.onEvent = fn
+	c.mu.Unlock()
+	return c.Call(MethodSubscribe, nil, nil)
+}
+
+// Done is closed when the connection is lost or closed.
+func (c *Conn) Done() <-chan struct{} { return c.done }
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.nc.Close()
+}
+
+func (c *Conn) send(id json.RawMessage, method string, params interface{}) error {
+	req := Request{JSONRPC: Version, ID: id, Method: method}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = b
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.enc.Encode(req)
+}
+
+// read dispatches responses to their callers and events to onEvent until
+// the connection ends.
+func (c *Conn) read() {
+	sc := bufio.NewScanner(c.nc)
+	sc.Buffer(nil, 64<<20)
+	for sc.Scan() {
+		var msg struct {
+			Response
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Method == MethodEvent {
+			var evt Event
+			if err := json.Unmarshal(msg.Params, &evt); err != nil {
+				continue
+			}
+			c.mu.Lock()
+			fn := c.onEvent
+			c.mu.Unlock()
+			if fn != nil {
+				fn(evt)
+			}
+			continue
+		}
+		c.mu.Lock()
+		ch := c.pending[string(msg.ID)]
+		delete(c.pending, string(msg.ID))
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- msg.Response
+		}
+	}
+	c.mu.Lock()
+	c.err = ErrClosed
+	c.mu.Unlock()
+	close(c.done)
+}
@@ -0,0 +1,214 @@
+// Package rpc is the JSON-RPC 2.0 protocol spoken on the daemon's Unix
+// socket: one JSON object per line in each direction. Requests with an id
+// get a response; the daemon pushes events as "event" notifications to
+// connections that called events.subscribe.
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	apptypes "DevStarByte/internal/types"
+)
+
+// DefaultSocket is where the daemon listens, next to the other files in the
+// working directory.
+const DefaultSocket = "daemon.sock"
+
+// Version is the JSON-RPC version every message carries.
+const Version = "2.0"
+
+// Methods.
+const (
+	MethodSessionInfo  = "session.info"     // → SessionInfo
+	MethodSessionFocus = "session.setFocus" // FocusParams; usually sent as a notification
+
+	MethodChatsList     = "chats.list"        // → []ChatItem, in sidebar order
+	MethodChatsMarkRead = "chats.markRead"    // ChatParams
+	MethodChatsPin      = "chats.setPinned"   // FlagParams
+	MethodChatsArchive  = "chats.setArchived" // FlagParams
+	MethodChatsMute     = "chats.setMuted"    // MuteParams
+	MethodChatsInfo     = "chats.info"        // ChatParams → ChatInfo
+
+	MethodHistory  = "messages.history"  // HistoryParams → []Message, oldest first
+	MethodSend     = "messages.send"     // SendParams
+	MethodSendFile = "messages.sendFile" // SendFileParams
+	MethodReact    = "messages.react"    // ReactParams
+
+	MethodGroupCreate       = "groups.create"             // CreateGroupParams → GroupInfo
+	MethodGroupName         = "groups.setName"            // TextParams
+	MethodGroupDescription  = "groups.setDescription"     // TextParams
+	MethodGroupPhoto        = "groups.setPhoto"           // TextParams (path to an image)
+	MethodGroupParticipants = "groups.updateParticipants" // ParticipantsParams
+	MethodGroupInviteLink   = "groups.inviteLink"         // FlagParams (reset) → string
+
+	MethodContactsGet      = "contacts.get"               // JIDParams → ContactInfo
+	MethodContactsList     = "contacts.list"              // → map of JID to ContactInfo
+	MethodContactsLIDPN    = "contacts.pnForLID"          // JIDParams → JID
+	MethodContactsPresence = "contacts.subscribePresence" // JIDParams
+
+	MethodScheduleWake = "schedule.wake" // scheduled messages in messages.db changed
+
+	MethodSubscribe   = "events.subscribe"   // start "event" notifications
+	MethodUnsubscribe = "events.unsubscribe" // stop them
+
+	// MethodEvent is the notification carrying an Event.
+	MethodEvent = "event"
+)
+
+// Error codes. All but CodeActionFailed are defined by JSON-RPC.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeActionFailed   = -32000 // WhatsApp or the store rejected the action
+)
+
+// ── Messages ──────────────────────────────────────────────────────────────────
+
+// Request is a call, or a notification when ID is absent.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response answers the Request with the same ID.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// ── Params and results ────────────────────────────────────────────────────────
+
+// SessionInfo describes the daemon's WhatsApp session.
+type SessionInfo struct {
+	Own    types.JID `json:"own"`
+	OwnLID types.JID `json:"own_lid"`
+}
+
+// FocusParams names the chat a client shows, "" for none.
+type FocusParams struct {
+	Chat string `json:"chat"`
+}
+
+// ChatParams names a chat.
+type ChatParams struct {
+	Chat types.JID `json:"chat"`
+}
+
+// JIDParams names a user.
+type JIDParams struct {
+	JID types.JID `json:"jid"`
+}
+
+// FlagParams switches something on or off for a chat.
+type FlagParams struct {
+	Chat types.JID `json:"chat"`
+	On   bool      `json:"on"`
+}
+
+// MuteParams mutes a chat for Seconds; 0 unmutes and -1 mutes forever.
+type MuteParams struct {
+	Chat    types.JID `json:"chat"`
+	Seconds int64     `json:"seconds"`
+}
+
+// HistoryParams selects the newest Limit messages of a chat sent before
+// Before. Zero values mean no limit.
+type HistoryParams struct {
+	Chat   types.JID `json:"chat"`
+	Limit  int       `json:"limit,omitempty"`
+	Before time.Time `json:"before,omitzero"`
+}
+
+// SendParams sends a text message.
+type SendParams struct {
+	Chat     types.JID   `json:"chat"`
+	Text     string      `json:"text"`
+	Mentions []types.JID `json:"mentions,omitempty"`
+}
+
+// SendFileParams sends the file at Path, which the daemon must be able to
+// read, with an optional caption.
+type SendFileParams struct {
+	Chat    types.JID `json:"chat"`
+	Path    string    `json:"path"`
+	Caption string    `json:"caption,omitempty"`
+}
+
+// ReactParams reacts to a message; an empty Emoji removes the reaction.
+type ReactParams struct {
+	Chat      types.JID `json:"chat"`
+	MessageID string    `json:"message_id"`
+	Emoji     string    `json:"emoji"`
+}
+
+// CreateGroupParams creates a group.
+type CreateGroupParams struct {
+	Name         string      `json:"name"`
+	Participants []types.JID `json:"participants"`
+}
+
+// TextParams sets a text property of a chat.
+type TextParams struct {
+	Chat types.JID `json:"chat"`
+	Text string    `json:"text"`
+}
+
+// ParticipantsParams adds, removes, promotes or demotes group members.
+type ParticipantsParams struct {
+	Chat   types.JID   `json:"chat"`
+	Users  []types.JID `json:"users"`
+	Action string      `json:"action"`
+}
+
+// ── Events ────────────────────────────────────────────────────────────────────
+
+// Event types.
+const (
+	EventMessage        = "message"         // Message was added to Chat
+	EventMessageUpdated = "message_updated" // Message in Chat was edited
+	EventMessagesMerged = "messages_merged" // Messages arrived for Chat from history sync
+	EventChat           = "chat"            // ChatItem changed
+	EventReceipt        = "receipt"         // MessageIDs in Chat reached Status
+	EventHistorySynced  = "history_synced"  // a history sync batch with Conversations finished
+	EventPresence       = "presence"        // JID changed to Presence, in Chat when typing
+	EventNotify         = "notify"          // show a notification with Title and Body for Chat
+)
+
+// Event is the payload of an "event" notification. Which fields are set
+// depends on Type.
+type Event struct {
+	Type          string                 `json:"type"`
+	Chat          types.JID              `json:"chat,omitzero"`
+	Message       *apptypes.Message      `json:"message,omitempty"`
+	Messages      []apptypes.Message     `json:"messages,omitempty"`
+	ChatItem      *apptypes.ChatItem     `json:"chat_item,omitempty"`
+	MessageIDs    []string               `json:"message_ids,omitempty"`
+	Status        apptypes.MessageStatus `json:"status,omitempty"`
+	Timestamp     time.Time              `json:"timestamp,omitzero"`
+	Conversations int                    `json:"conversations,omitempty"`
+	JID           types.JID              `json:"jid,omitzero"`
+	Presence      string                 `json:"presence,omitempty"`
+	Title         string                 `json:"title,omitempty"`
+	Body          string                 `json:"body,omitempty"`
+}
@@ -0,0 +1,247 @@
+// Package rules holds the auto-reply rules: conditions an incoming message
+// must meet and the actions taken when it does. Rules live in the message
+// database and are read on every message, so edits from any process apply
+// right away.
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db"
+	apptypes "DevStarByte/internal/types"
+)
+
+// Days are the names accepted in Rule.Days, Sunday first like time.Weekday.
+var Days = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Rule is one auto-reply rule. Every condition that is set must hold, and
+// at least one action must be set.
+type Rule struct {
+	ID      int64  `json:"-"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	DryRun  bool   `json:"dry_run,omitempty"` // log the actions instead of taking them
+
+	// Conditions.
+	Chats        []string `json:"chats,omitempty"`    // chat JIDs or phone numbers
+	Contacts     []string `json:"contacts,omitempty"` // sender JIDs or phone numbers
+	Days         []string `json:"days,omitempty"`     // see Days
+	From         string   `json:"from,omitempty"`     // "15:04"; with To, a daily time window
+	To           string   `json:"to,omitempty"`       // may be before From to wrap past midnight
+	Keyword      string   `json:"keyword,omitempty"`  // regular expression on the content
+	FirstInHours int      `json:"first_in_hours,omitempty"`
+
+	// Actions.
+	Reply    string `json:"reply,omitempty"`
+	React    string `json:"react,omitempty"`
+	Forward  string `json:"forward,omitempty"` // chat JID, number or name
+	MarkRead bool   `json:"mark_read,omitempty"`
+}
+
+// Validate reports the first problem with r.
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is empty")
+	}
+	if (r.From == "") != (r.To == "") {
+		return errors.New("from and to go together")
+	}
+	for _, t := range []string{r.From, r.To} {
+		if _, err := parseClock(t); t != "" && err != nil {
+			return fmt.Errorf("invalid time %q, use HH:MM", t)
+		}
+	}
+	for _, d := range r.Days {
+		if !slices.Contains(Days, d) {
+			return fmt.Errorf("invalid day %q, use %s", d, strings.Join(Days, ","))
+		}
+	}
+	if _, err := regexp.Compile(r.Keyword); err != nil {
+		return fmt.Errorf("invalid keyword: %w", err)
+	}
+	if r.FirstInHours < 0 {
+		return errors.New("first in hours is negative")
+	}
+	if r.Reply == "" && r.React == "" && r.Forward == "" && !r.MarkRead {
+		return errors.New("no action")
+	}
+	return nil
+}
+
+// Actions describes what r does, for logs and the editor.
+func (r Rule) Actions() string {
+	var out []string
+	if r.MarkRead {
+		out = append(out, "mark read")
+	}
+	if r.React != "" {
+		out = append(out, "react "+r.React)
+	}
+	if r.Reply != "" {
+		out = append(out, fmt.Sprintf("reply %q", r.Reply))
+	}
+	if r.Forward != "" {
+		out = append(out, "forward to "+r.Forward)
+	}
+	return strings.Join(out, ", ")
+}
+
+// Input is an incoming message as the rules see it.
+type Input struct {
+	Chat    types.JID
+	Sender  types.JID
+	Content string
+	Time    time.Time
+	// Previous is when the chat's last message from someone else before
+	// this one arrived; zero if there is none.
+	Previous time.Time
+}
+
+// matches reports whether in meets r's conditions. keyword is r.Keyword
+// compiled.
+func (r Rule) matches(in Input, keyword *regexp.Regexp) bool {
+	switch {
+	case len(r.Chats) > 0 && !apptypes.MatchJID(r.Chats, in.Chat),
+		len(r.Contacts) > 0 && !apptypes.MatchJID(r.Contacts, in.Sender),
+		!keyword.MatchString(in.Content),
+		!r.inWindow(in.Time.Local()),
+		r.FirstInHours > 0 && !in.Previous.IsZero() && in.Time.Sub(in.Previous) < time.Duration(r.FirstInHours)*time.Hour:
+		return false
+	}
+	return true
+}
+
+// inWindow reports whether t is on one of r's days and within its hours.
+func (r Rule) inWindow(t time.Time) bool {
+	if len(r.Days) > 0 && !slices.Contains(r.Days, Days[t.Weekday()]) {
+		return false
+	}
+	if r.From == "" {
+		return true
+	}
+	from, _ := parseClock(r.From)
+	to, _ := parseClock(r.To)
+	now := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+// parseClock returns the minutes since midnight of "15:04".
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ── Engine ────────────────────────────────────────────────────────────────────
+
+// Options configure an Engine.
+type Options struct {
+	DryRun      bool          // treat every rule as a dry run
+	MinInterval time.Duration // per chat, between two rules firing
+}
+
+// Engine stores the rules and decides which one fires.
+type Engine struct {
+	logger *Logger.Logger
+	store  *db.Store
+	opts   Options
+
+	mu   sync.Mutex
+	last map[types.JID]time.Time // when a rule last fired per chat
+}
+
+// New returns the engine for the rules in store.
+func New(logger *Logger.Logger, store *db.Store, opts Options) *Engine {
+	return &Engine{logger: logger, store: store, opts: opts, last: make(map[types.JID]time.Time)}
+}
+
+// Rules returns all rules in order.
+func (e *Engine) Rules() []Rule {
+	if e == nil {
+		return nil
+	}
+	var out []Rule
+	for _, stored := range e.store.LoadRules() {
+		var r Rule
+		if err := json.Unmarshal(stored.Data, &r); err != nil {
+			e.logger.Warning(fmt.Sprintf("Skipping rule %d: %v", stored.ID, err))
+			continue
+		}
+		r.ID = stored.ID
+		out = append(out, r)
+	}
+	return out
+}
+
+// Save validates and stores r, adding it if its ID is 0, and returns it
+// with its ID.
+func (e *Engine) Save(r Rule) (Rule, error) {
+	if e == nil {
+		return r, errors.New("rules are not available")
+	}
+	if err := r.Validate(); err != nil {
+		return r, err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return r, err
+	}
+	if r.ID, err = e.store.SaveRule(r.ID, data); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// Delete removes the rule with id.
+func (e *Engine) Delete(id int64) {
+	if e == nil {
+		return
+	}
+	e.store.DeleteRule(id)
+}
+
+// DryRun reports whether r's actions are only logged.
+func (e *Engine) DryRun(r Rule) bool {
+	return e.opts.DryRun || r.DryRun
+}
+
+// Match returns the first enabled rule that in meets, unless a rule fired
+// in the same chat less than MinInterval ago. A match counts as firing.
+func (e *Engine) Match(in Input) (Rule, bool) {
+	if e == nil {
+		return Rule{}, false
+	}
+	for _, r := range e.Rules() {
+		if !r.Enabled {
+			continue
+		}
+		keyword, err := regexp.Compile(r.Keyword)
+		if err != nil || !r.matches(in, keyword) {
+			continue
+		}
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if last, ok := e.last[in.Chat]; ok && in.Time.Sub(last) < e.opts.MinInterval {
+			e.logger.Info(fmt.Sprintf("Rule %q matched in %s but a rule fired there %s ago", r.Name, in.Chat, in.Time.Sub(last).Round(time.Second)))
+			return Rule{}, false
+		}
+		e.last[in.Chat] = in.Time
+		return r, true
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,124 @@
+package rules
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db/dbtest"
+)
+
+var (
+	alice = types.NewJID("491701111111", types.DefaultUserServer)
+	bob   = types.NewJID("491702222222", types.DefaultUserServer)
+	// A Monday, 10:00 local time.
+	monday = time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)
+)
+
+func newEngine(t *testing.T, opts Options) *Engine {
+	t.Helper()
+	logger, store := dbtest.New(t)
+	return New(logger, store, opts)
+}
+
+func TestValidate(t *testing.T) {
+	ok := Rule{Name: "away", Reply: "later"}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	for _, r := range []Rule{
+		{Reply: "later"},
+		{Name: "away"},
+		{Name: "away", Reply: "later", From: "18:00"},
+		{Name: "away", Reply: "later", From: "18:00", To: "25:00"},
+		{Name: "away", Reply: "later", Days: []string{"monday"}},
+		{Name: "away", Reply: "later", Keyword: "("},
+		{Name: "away", Reply: "later", FirstInHours: -1},
+	} {
+		if r.Validate() == nil {
+			t.Errorf("Validate(%+v) = nil", r)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	in := Input{Chat: alice, Sender: alice, Content: "Is this URGENT?", Time: monday}
+	for _, tt := range []struct {
+		name string
+		rule Rule
+		in   Input
+		want bool
+	}{
+		{"no conditions", Rule{}, in, true},
+		{"chat by number", Rule{Chats: []string{"+49 170 1111111"}}, in, true},
+		{"other chat", Rule{Chats: []string{bob.String()}}, in, false},
+		{"contact", Rule{Contacts: []string{alice.User}}, in, true},
+		{"other contact", Rule{Contacts: []string{bob.User}}, in, false},
+		{"day", Rule{Days: []string{"mon", "tue"}}, in, true},
+		{"other day", Rule{Days: []string{"sat", "sun"}}, in, false},
+		{"within hours", Rule{From: "09:00", To: "17:00"}, in, true},
+		{"outside hours", Rule{From: "18:00", To: "23:00"}, in, false},
+		{"wraps midnight", Rule{From: "18:00", To: "08:00"}, Input{Chat: alice, Time: monday.Add(13 * time.Hour)}, true},
+		{"wraps midnight, daytime", Rule{From: "18:00", To: "08:00"}, in, false},
+		{"keyword", Rule{Keyword: "(?i)urgent"}, in, true},
+		{"no keyword", Rule{Keyword: "asap"}, in, false},
+		{"first in hours", Rule{FirstInHours: 4}, Input{Chat: alice, Time: monday, Previous: monday.Add(-5 * time.Hour)}, true},
+		{"not first in hours", Rule{FirstInHours: 4}, Input{Chat: alice, Time: monday, Previous: monday.Add(-time.Hour)}, false},
+		{"first ever", Rule{FirstInHours: 4}, in, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.rule
+			if got := r.matches(tt.in, mustCompile(t, r.Keyword)); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveAndMatch(t *testing.T) {
+	e := newEngine(t, Options{MinInterval: time.Minute})
+	if _, err := e.Save(Rule{Name: "bad"}); err == nil {
+		t.Error("Save accepted a rule without an action")
+	}
+	off, err := e.Save(Rule{Name: "off", Reply: "never"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	away, err := e.Save(Rule{Name: "away", Enabled: true, Reply: "later", Contacts: []string{alice.User}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Rules(); len(got) != 2 || got[0].ID != off.ID || got[1].Name != "away" || got[1].Contacts[0] != alice.User {
+		t.Fatalf("Rules() = %+v", got)
+	}
+
+	if r, ok := e.Match(Input{Chat: alice, Sender: alice, Time: monday}); !ok || r.ID != away.ID {
+		t.Errorf("Match = %+v, %v", r, ok)
+	}
+	// Rate limited in the same chat, not in others.
+	if _, ok := e.Match(Input{Chat: alice, Sender: alice, Time: monday.Add(30 * time.Second)}); ok {
+		t.Error("matched again within MinInterval")
+	}
+	if _, ok := e.Match(Input{Chat: alice, Sender: alice, Time: monday.Add(2 * time.Minute)}); !ok {
+		t.Error("no match after MinInterval")
+	}
+	if _, ok := e.Match(Input{Chat: bob, Sender: bob, Time: monday}); ok {
+		t.Error("matched another contact")
+	}
+
+	e.Delete(away.ID)
+	if got := e.Rules(); len(got) != 1 {
+		t.Errorf("Rules() after Delete = %+v", got)
+	}
+}
+
+func mustCompile(t *testing.T, expr string) *regexp.Regexp {
+	t.Helper()
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return re
+}
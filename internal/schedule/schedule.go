@@ -0,0 +1,232 @@
+// Package schedule sends messages at a later time. They are stored in the
+// message database, so they survive restarts and are sent late if the app
+// wasn't running when they were due.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db"
+)
+
+// pollInterval bounds how long the worker sleeps, so it notices messages
+// another process added without calling Wake.
+const pollInterval = 30 * time.Second
+
+// retryDelays are the waits after each failed attempt; after the last the
+// message is marked failed.
+var retryDelays = []time.Duration{
+	30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute,
+	10 * time.Minute, 30 * time.Minute, time.Hour,
+}
+
+// SendFunc sends text to chat.
+type SendFunc func(chat types.JID, text string) error
+
+// Scheduler stores scheduled messages and, given a SendFunc, sends them.
+type Scheduler struct {
+	logger *Logger.Logger
+	store  *db.Store
+	send   SendFunc
+
+	mu      sync.Mutex
+	pending map[string][]db.ScheduledMessage // by chat, as in the database
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New returns a scheduler for the messages in store, or nil if there is no
+// store. Without send it only edits them, e.g. while another process sends.
+func New(logger *Logger.Logger, store *db.Store, send SendFunc) *Scheduler {
+	if store == nil {
+		return nil
+	}
+	s := &Scheduler{
+		logger: logger,
+		store:  store,
+		send:   send,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if send != nil {
+		// Nothing else sends from this database; a claim left over is from
+		// a crash mid-send.
+		store.ResetScheduled()
+	}
+	s.Reload()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.run(ctx)
+	return s
+}
+
+// Pending returns the messages waiting to be sent to chat, failed ones
+// included, in the order they are due.
+func (s *Scheduler) Pending(chat types.JID) []db.ScheduledMessage {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending[chat.String()]
+}
+
+// Reload reads the pending messages from the database again.
+func (s *Scheduler) Reload() {
+	if s == nil {
+		return
+	}
+	pending := make(map[string][]db.ScheduledMessage)
+	for _, m := range s.store.LoadScheduled() {
+		pending[m.Chat] = append(pending[m.Chat], m)
+	}
+	s.mu.Lock()
+	s.pending = pending
+	s.mu.Unlock()
+}
+
+// Add schedules text to be sent to chat at at.
+func (s *Scheduler) Add(chat types.JID, text string, at time.Time) (db.ScheduledMessage, error) {
+	if s == nil {
+		return db.ScheduledMessage{}, errors.New("scheduling needs messages.db")
+	}
+	if strings.TrimSpace(text) == "" {
+		return db.ScheduledMessage{}, errors.New("nothing to send")
+	}
+	id, err := s.store.AddScheduled(chat.String(), text, at)
+	if err != nil {
+		return db.ScheduledMessage{}, err
+	}
+	s.logger.Info(fmt.Sprintf("Scheduled message %d to %s for %s", id, chat, at.Format(time.DateTime)))
+	s.changed()
+	return db.ScheduledMessage{ID: id, Chat: chat.String(), Text: text, SendAt: at, NextTry: at}, nil
+}
+
+// Update changes the text and time of a scheduled message. A failed message
+// is tried again.
+func (s *Scheduler) Update(id int64, text string, at time.Time) error {
+	if s == nil {
+		return errors.New("scheduling needs messages.db")
+	}
+	if strings.TrimSpace(text) == "" {
+		return errors.New("nothing to send")
+	}
+	if err := s.store.UpdateScheduled(id, text, at); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// Cancel deletes a scheduled message.
+func (s *Scheduler) Cancel(id int64) error {
+	if s == nil {
+		return errors.New("scheduling needs messages.db")
+	}
+	if err := s.store.DeleteScheduled(id, false); err != nil {
+		return err
+	}
+	s.logger.Info(fmt.Sprintf("Cancelled scheduled message %d", id))
+	s.changed()
+	return nil
+}
+
+// Wake reloads the messages and looks for due ones now, after another
+// process changed them.
+func (s *Scheduler) Wake() {
+	if s != nil {
+		s.changed()
+	}
+}
+
+// changed reloads the cache and wakes the worker.
+func (s *Scheduler) changed() {
+	s.Reload()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the worker. Unsent messages stay stored for next time.
+func (s *Scheduler) Close() {
+	if s == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+	for {
+		wait := pollInterval
+		if s.send != nil {
+			s.sendDue(ctx)
+			if next, ok := s.store.NextScheduledTime(); ok {
+				wait = min(wait, max(0, time.Until(next)))
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+		s.Reload()
+	}
+}
+
+// sendDue sends the messages that are due, in order.
+func (s *Scheduler) sendDue(ctx context.Context) {
+	for _, m := range s.store.DueScheduled(time.Now()) {
+		if ctx.Err() != nil {
+			return
+		}
+		if !s.store.ClaimScheduled(m.ID) {
+			continue
+		}
+		// A claimed message is no longer pending: drop it from the view
+		// before the sent copy shows up.
+		s.Reload()
+		s.deliver(m)
+	}
+}
+
+func (s *Scheduler) deliver(m db.ScheduledMessage) {
+	chat, err := types.ParseJID(m.Chat)
+	if err == nil {
+		if late := time.Since(m.SendAt); late > time.Minute && m.Attempts == 0 {
+			s.logger.Info(fmt.Sprintf("Sending scheduled message %d %s late", m.ID, late.Round(time.Minute)))
+		}
+		err = s.send(chat, m.Text)
+	}
+	if err == nil {
+		if err := s.store.DeleteScheduled(m.ID, true); err != nil {
+			s.logger.Error("Failed to remove sent scheduled message: " + err.Error())
+		}
+		return
+	}
+	var next time.Time
+	if m.Attempts < len(retryDelays) {
+		next = time.Now().Add(retryDelays[m.Attempts])
+		s.logger.Warning(fmt.Sprintf("Scheduled message %d failed, retrying at %s: %v", m.ID, next.Format(time.TimeOnly), err))
+	} else {
+		s.logger.Error(fmt.Sprintf("Scheduled message %d failed, giving up: %v", m.ID, err))
+	}
+	s.store.RetryScheduled(m.ID, m.Attempts+1, next, err.Error())
+	s.Reload()
+}
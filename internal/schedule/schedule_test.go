@@ -0,0 +1,168 @@
+package schedule
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/db/dbtest"
+)
+
+var (
+	alice = types.NewJID("491701111111", types.DefaultUserServer)
+	bob   = types.NewJID("491702222222", types.DefaultUserServer)
+)
+
+// sender records what a Scheduler sends, failing while err is set.
+type sender struct {
+	mu   sync.Mutex
+	sent []string
+	err  error
+}
+
+func (s *sender) send(chat types.JID, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, chat.User+": "+text)
+	return nil
+}
+
+func (s *sender) wait(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		sent := append([]string(nil), s.sent...)
+		s.mu.Unlock()
+		if len(sent) >= n || time.Now().After(deadline) {
+			return sent
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCatchUpAndOrder(t *testing.T) {
+	logger, store := dbtest.New(t)
+	now := time.Now()
+	// Due while the app wasn't running.
+	store.AddScheduled(bob.String(), "second", now.Add(-time.Hour))
+	store.AddScheduled(alice.String(), "first", now.Add(-2*time.Hour))
+
+	var out sender
+	s := New(logger, store, out.send)
+	defer s.Close()
+	if _, err := s.Add(alice, "soon", time.Now().Add(100*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(alice, "later", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	sent := out.wait(t, 3)
+	want := []string{alice.User + ": first", bob.User + ": second", alice.User + ": soon"}
+	if len(sent) != 3 || sent[0] != want[0] || sent[1] != want[1] || sent[2] != want[2] {
+		t.Fatalf("sent = %q", sent)
+	}
+	if p := s.Pending(alice); len(p) != 1 || p[0].Text != "later" {
+		t.Errorf("Pending = %+v", p)
+	}
+	if p := s.Pending(bob); len(p) != 0 {
+		t.Errorf("Pending(bob) = %+v", p)
+	}
+}
+
+func TestEditAndCancel(t *testing.T) {
+	logger, store := dbtest.New(t)
+	var out sender
+	s := New(logger, store, out.send)
+	defer s.Close()
+
+	a, _ := s.Add(alice, "a", time.Now().Add(time.Hour))
+	b, _ := s.Add(alice, "b", time.Now().Add(2*time.Hour))
+	if err := s.Cancel(b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cancel(b.ID); !errors.Is(err, db.ErrScheduleGone) {
+		t.Errorf("second Cancel = %v", err)
+	}
+	if err := s.Update(a.ID, "", time.Now()); err == nil {
+		t.Error("Update accepted empty text")
+	}
+	if err := s.Update(a.ID, "now", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if sent := out.wait(t, 1); len(sent) != 1 || sent[0] != alice.User+": now" {
+		t.Errorf("sent = %q", sent)
+	}
+	if err := s.Update(a.ID, "again", time.Now()); !errors.Is(err, db.ErrScheduleGone) {
+		t.Errorf("Update after sending = %v", err)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	logger, store := dbtest.New(t)
+	out := sender{err: errors.New("not connected")}
+	id, _ := store.AddScheduled(alice.String(), "hi", time.Now().Add(-time.Minute))
+	// Already tried all but the last time.
+	store.ClaimScheduled(id)
+	store.RetryScheduled(id, len(retryDelays), time.Now(), "earlier")
+
+	s := New(logger, store, out.send)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.Pending(alice)) == 0 || s.Pending(alice)[0].Status != db.ScheduleFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("Pending = %+v", s.Pending(alice))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if p := s.Pending(alice)[0]; p.Error != "not connected" || p.Attempts != len(retryDelays)+1 {
+		t.Errorf("failed message = %+v", p)
+	}
+	s.Close()
+
+	// Editing a failed message tries again.
+	out.err = nil
+	s = New(logger, store, out.send)
+	defer s.Close()
+	if err := s.Update(id, "hi again", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if sent := out.wait(t, 1); len(sent) != 1 || sent[0] != alice.User+": hi again" {
+		t.Errorf("sent = %q", sent)
+	}
+}
+
+func TestEditorOnly(t *testing.T) {
+	logger, store := dbtest.New(t)
+	store.AddScheduled(alice.String(), "due", time.Now().Add(-time.Minute))
+	// A claim left over from another process that is sending it.
+	id, _ := store.AddScheduled(alice.String(), "sending", time.Now().Add(-time.Minute))
+	store.ClaimScheduled(id)
+
+	s := New(logger, store, nil)
+	defer s.Close()
+	if p := s.Pending(alice); len(p) != 1 || p[0].Text != "due" {
+		t.Errorf("Pending = %+v", p)
+	}
+	if err := s.Cancel(id); !errors.Is(err, db.ErrScheduleGone) {
+		t.Errorf("Cancel of a message being sent = %v", err)
+	}
+
+	var none *Scheduler
+	if none.Pending(alice) != nil {
+		t.Error("nil scheduler has messages")
+	}
+	if _, err := none.Add(alice, "x", time.Now()); err == nil {
+		t.Error("nil scheduler accepted a message")
+	}
+	none.Close()
+	if New(logger, nil, nil) != nil {
+		t.Error("New without a store")
+	}
+}
@@ -0,0 +1,186 @@
+package state
+
+import (
+	"sync"
+	"time"
+
+	watypes "go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/types"
+)
+
+// ── Events ────────────────────────────────────────────────────────────────────
+
+// Event is a change to the shared state, published by the WhatsApp event
+// handlers after they updated the global maps. Subscribers apply events as
+// deltas instead of re-reading the maps.
+type Event interface{ event() }
+
+// MessageAdded is a new message appended to a chat.
+type MessageAdded struct {
+	Chat    watypes.JID
+	Message types.Message
+}
+
+// MessageUpdated replaces a message already in a chat.
+type MessageUpdated struct {
+	Chat    watypes.JID
+	Message types.Message
+}
+
+// MessagesMerged is a batch of messages, e.g. from history sync, merged into
+// a chat's history. Messages is sorted by time and may overlap known ones.
+type MessagesMerged struct {
+	Chat     watypes.JID
+	Messages []types.Message
+}
+
+// ChatUpdated carries a snapshot of a chat list entry after it changed.
+type ChatUpdated struct {
+	Chat types.ChatItem
+}
+
+// Receipt reports that messages I sent were delivered or read.
+type Receipt struct {
+	Chat       watypes.JID
+	MessageIDs []string
+	Status     types.MessageStatus
+	Timestamp  time.Time
+}
+
+// HistorySynced is published after a history sync batch was processed.
+type HistorySynced struct {
+	Conversations int
+}
+
+// Notification is a desktop notification for a message in Chat, published
+// when no Notifier showed it, so attached clients show it themselves.
+type Notification struct {
+	Chat  watypes.JID
+	Title string
+	Body  string
+}
+
+// Presence states.
+const (
+	PresenceOnline    = "online"
+	PresenceOffline   = "offline"
+	PresenceTyping    = "typing"
+	PresenceRecording = "recording" // a voice message
+	PresencePaused    = "paused"    // stopped typing or recording
+)
+
+// Presence reports a contact coming online or going offline, or typing in
+// Chat, which is only set for typing updates.
+type Presence struct {
+	Chat     watypes.JID
+	JID      watypes.JID
+	State    string
+	LastSeen time.Time // when going offline, if shared
+}
+
+func (MessageAdded) event()   {}
+func (MessageUpdated) event() {}
+func (MessagesMerged) event() {}
+func (ChatUpdated) event()    {}
+func (Receipt) event()        {}
+func (HistorySynced) event()  {}
+func (Presence) event()       {}
+func (Notification) event()   {}
+
+// ── Event bus ─────────────────────────────────────────────────────────────────
+
+// Bus fans events out to subscribers. Publishing never blocks and never
+// drops events: every subscription has its own unbounded queue.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// NewBus creates an event bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription that receives every event published from
+// now on.
+func (b *Bus) Subscribe() *Subscription {
+	sub := &Subscription{
+		bus:  b,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Publish queues events for all subscribers, in order.
+func (b *Bus) Publish(evts ...Event) {
+	if b == nil || len(evts) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		sub.push(evts)
+	}
+}
+
+// Subscription is one subscriber's queue of events.
+type Subscription struct {
+	bus       *Bus
+	mu        sync.Mutex
+	queue     []Event
+	wake      chan struct{} // signalled when the queue became non-empty
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *Subscription) push(evts []Event) {
+	s.mu.Lock()
+	s.queue = append(s.queue, evts...)
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Next blocks until events are queued and returns all of them. After the
+// first event it waits for window so a burst is delivered as one batch. ok
+// is false once the subscription is closed.
+func (s *Subscription) Next(window time.Duration) (evts []Event, ok bool) {
+	for {
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return nil, false
+		}
+		if window > 0 {
+			select {
+			case <-time.After(window):
+			case <-s.done:
+				return nil, false
+			}
+		}
+		s.mu.Lock()
+		evts, s.queue = s.queue, nil
+		s.mu.Unlock()
+		if len(evts) > 0 {
+			return evts, true
+		}
+		// A previous call already drained what this wake-up announced.
+	}
+}
+
+// Close unsubscribes; pending and blocked Next calls return.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		close(s.done)
+	})
+}
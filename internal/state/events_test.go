@@ -0,0 +1,69 @@
+package state
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBusDeliversEveryEventInOrder(t *testing.T) {
+	b := NewBus()
+	sub := b.Subscribe()
+	defer sub.Close()
+
+	const publishers, perPublisher = 4, 2000
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perPublisher; i++ {
+				b.Publish(HistorySynced{Conversations: p*perPublisher + i})
+			}
+		}()
+	}
+
+	last := make([]int, publishers)
+	for p := range last {
+		last[p] = -1
+	}
+	for got := 0; got < publishers*perPublisher; {
+		evts, ok := sub.Next(time.Millisecond)
+		if !ok {
+			t.Fatal("subscription closed early")
+		}
+		for _, evt := range evts {
+			n := evt.(HistorySynced).Conversations
+			p, i := n/perPublisher, n%perPublisher
+			if i <= last[p] {
+				t.Fatalf("publisher %d: event %d after %d", p, i, last[p])
+			}
+			last[p] = i
+		}
+		got += len(evts)
+	}
+	wg.Wait()
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	b := NewBus()
+	sub := b.Subscribe()
+	other := b.Subscribe()
+	defer other.Close()
+
+	done := make(chan bool)
+	go func() {
+		_, ok := sub.Next(0)
+		done <- ok
+	}()
+	sub.Close()
+	if <-done {
+		t.Error("Next returned events after Close")
+	}
+
+	// Publishing to the remaining subscriber still works.
+	b.Publish(HistorySynced{})
+	if evts, ok := other.Next(0); !ok || len(evts) != 1 {
+		t.Errorf("other subscriber got %v, %v", evts, ok)
+	}
+}
@@ -4,18 +4,46 @@ import (
 	"sync"
 
 	"github.com/StarGames2025/Logger"
-	"go.mau.fi/whatsmeow"
 
+	"DevStarByte/internal/config"
 	"DevStarByte/internal/db"
+	"DevStarByte/internal/hooks"
+	"DevStarByte/internal/notify"
+	"DevStarByte/internal/record"
+	"DevStarByte/internal/rpc"
+	"DevStarByte/internal/rules"
+	"DevStarByte/internal/schedule"
 	"DevStarByte/internal/types"
+	"DevStarByte/internal/wa"
+	"DevStarByte/internal/webhook"
 )
 
 // AppState holds all shared runtime state that is accessed by both the
 // WhatsApp client event handlers and the TUI.
 type AppState struct {
-	Client *whatsmeow.Client
+	Client wa.Client
 	DB     *db.Store
 	Logger *Logger.Logger
+	Config *config.Config
+
+	// Notifier shows desktop notifications; nil disables them.
+	Notifier *notify.Notifier
+
+	// Recorder writes incoming events to a file for replay; nil disables it.
+	Recorder *record.Recorder
+	// Webhooks posts incoming messages to the configured URLs; nil disables
+	// them.
+	Webhooks *webhook.Dispatcher
+	// Hooks runs the scripts in the hooks directory; nil disables them.
+	Hooks *hooks.Runner
+	// Rules are the auto-reply rules; nil disables them.
+	Rules *rules.Engine
+	// Scheduler holds the scheduled messages; nil without messages.db.
+	Scheduler *schedule.Scheduler
+
+	// Daemon is set when this process is a thin client of a running daemon.
+	// Actions are then forwarded to it, and the maps below mirror its state.
+	Daemon *rpc.Conn
 
 	ChatsMu  sync.RWMutex
 	ChatsMap map[string]*types.ChatItem
@@ -23,40 +51,56 @@ type AppState struct {
 	MessagesMu  sync.RWMutex
 	MessagesMap map[string][]types.Message
 
-	IncomingCh chan types.MsgEvent
-	HistoryCh  chan struct{}
+	// Events carries every change to the maps above to the TUI.
+	Events *Bus
+
+	// FocusedChat is the chat the user is looking at, "" while the terminal
+	// is unfocused. Messages there don't trigger notifications.
+	FocusMu     sync.RWMutex
+	FocusedChat string
 
 	ExitCodes map[string]int
 }
 
 // New creates a new AppState with the given dependencies.
-func New(client *whatsmeow.Client, store *db.Store, logger *Logger.Logger) *AppState {
+func New(client wa.Client, store *db.Store, logger *Logger.Logger, cfg *config.Config) *AppState {
 	return &AppState{
 		Client:      client,
 		DB:          store,
 		Logger:      logger,
+		Config:      cfg,
 		ChatsMap:    make(map[string]*types.ChatItem),
 		MessagesMap: make(map[string][]types.Message),
-		IncomingCh:  make(chan types.MsgEvent, 256),
-		HistoryCh:   make(chan struct{}, 8),
-		ExitCodes: map[string]int{
-			"ERROR":                -1,
-			"SUCCESS":              0,
-			"SHUTDOWN":             0,
-			"DB_INIT_ERROR":        10,
-			"DEVICE_STORE_ERROR":   11,
-			"LOGGER_ERROR":         12,
-			"QR_GENERATE_ERROR":    13,
-			"QR_OPEN_ERROR":        14,
-			"QR_DECODE_ERROR":      15,
-			"QR_RESIZE_ERROR":      16,
-			"QR_FILE_CREATE_ERROR": 17,
-			"QR_FILE_ENCODE_ERROR": 18,
-			"QR_RENDER_ERROR":      19,
-			"GROUP_FETCH_ERROR":    20,
-			"CONTACT_FETCH_ERROR":  21,
-			"DATA_MARSHAL_ERROR":   22,
-			"DATA_UNMARSHAL_ERROR": 23,
-		},
+		Events:      NewBus(),
+		ExitCodes:   ExitCodes,
 	}
 }
+
+// ExitCodes maps the reasons the program can stop to its exit status.
+var ExitCodes = map[string]int{
+	"ERROR":                -1,
+	"SUCCESS":              0,
+	"SHUTDOWN":             0,
+	"USAGE_ERROR":          2,
+	"DB_INIT_ERROR":        10,
+	"DEVICE_STORE_ERROR":   11,
+	"LOGGER_ERROR":         12,
+	"QR_GENERATE_ERROR":    13,
+	"QR_OPEN_ERROR":        14,
+	"QR_DECODE_ERROR":      15,
+	"QR_RESIZE_ERROR":      16,
+	"QR_FILE_CREATE_ERROR": 17,
+	"QR_FILE_ENCODE_ERROR": 18,
+	"QR_RENDER_ERROR":      19,
+	"GROUP_FETCH_ERROR":    20,
+	"CONTACT_FETCH_ERROR":  21,
+	"DATA_MARSHAL_ERROR":   22,
+	"DATA_UNMARSHAL_ERROR": 23,
+	"RECORDING_ERROR":      24,
+	"DAEMON_ERROR":         25,
+	"NOT_LOGGED_IN":        26,
+	"CHAT_NOT_FOUND":       27,
+	"SEND_ERROR":           28,
+	"BACKUP_ERROR":         29,
+	"DB_KEY_ERROR":         30,
+}
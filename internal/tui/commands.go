@@ -0,0 +1,384 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/client"
+	"DevStarByte/internal/export"
+	"DevStarByte/internal/state"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Slash commands ────────────────────────────────────────────────────────────
+
+// runCommand executes a "/command args…" line typed into the input bar.
+func (m Model) runCommand(line string) (Model, tea.Cmd) {
+	fields := strings.Fields(strings.TrimPrefix(line, "/"))
+	if len(fields) == 0 {
+		m.inputText, m.inputCursor = line, len([]rune(line))
+		return m, nil
+	}
+	name, args := fields[0], strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "/"), fields[0]))
+
+	switch name {
+	case "group", "g":
+		return m.cmdGroup(args)
+	case "edit":
+		// "/edit [text]" opens $EDITOR seeded with text; "/edit send" sends
+		// the result right away instead of returning it to the composer.
+		send := args == "send"
+		if send {
+			args = ""
+		}
+		m.inputText = args
+		return m, m.openEditor(send)
+	case "emoji":
+		m = m.openPicker()
+		m.picker.query = args
+		return m, nil
+	case "pin", "unpin":
+		return m, m.setPinned(name == "pin")
+	case "archive", "unarchive":
+		return m, m.setArchived(name == "archive")
+	case "mute":
+		d, err := parseMuteDuration(args)
+		if err != nil {
+			return m, statusCmd(err.Error())
+		}
+		return m, m.setMuted(d)
+	case "unmute":
+		return m, m.setMuted(0)
+	case "schedule":
+		if args == "" {
+			return m.openSchedule()
+		}
+		return m.cmdSchedule(args)
+	case "scheduled":
+		return m.openSchedule()
+	case "export":
+		return m, m.cmdExport(args)
+	case "rules":
+		return m.openRules()
+	case "search":
+		return m.cmdSearch(args)
+	case "info":
+		m.showInfo = !m.showInfo
+		if m.showInfo {
+			// Drop the cached entry so the panel shows fresh data.
+			if m.selectedChat >= 0 && m.selectedChat < len(m.chats) {
+				delete(m.chatInfo, m.chats[m.selectedChat].JID.String())
+			}
+			return m, m.loadChatInfo()
+		}
+		return m, nil
+	}
+	// Keep what was typed so a typo can be fixed.
+	m.inputText, m.inputCursor = line, len([]rune(line))
+	return m, statusCmd(fmt.Sprintf("Unknown command /%s (start with // to send text beginning with /)", name))
+}
+
+// cmdGroup implements the /group family of commands.
+func (m Model) cmdGroup(args string) (Model, tea.Cmd) {
+	sub, rest, _ := strings.Cut(args, " ")
+	rest = strings.TrimSpace(rest)
+	s := m.state
+
+	if sub == "create" {
+		if rest == "" {
+			return m, statusCmd("Usage: /group create <name>  (mark contacts with Space first)")
+		}
+		var participants []types.JID
+		for _, c := range m.chats {
+			if m.marked[c.JID.String()] {
+				participants = append(participants, c.JID)
+			}
+		}
+		if len(participants) == 0 {
+			return m, statusCmd("Mark at least one contact with Space in the chat list")
+		}
+		m.marked = make(map[string]bool)
+		return m, func() tea.Msg {
+			info, err := client.CreateGroup(s, rest, participants)
+			if err != nil {
+				return tuiError{err}
+			}
+			return tuiStatus("Created group " + info.Name)
+		}
+	}
+
+	jid, ok := m.currentGroup()
+	if !ok {
+		return m, statusCmd("Open a group chat first")
+	}
+
+	switch sub {
+	case "rename", "subject":
+		if rest == "" {
+			return m, statusCmd("Usage: /group rename <name>")
+		}
+		return m, func() tea.Msg {
+			if err := client.SetGroupName(s, jid, rest); err != nil {
+				return tuiError{err}
+			}
+			return tuiStatus("Group renamed ✓")
+		}
+
+	case "desc", "description":
+		return m, func() tea.Msg {
+			if err := client.SetGroupDescription(s, jid, rest); err != nil {
+				return tuiError{err}
+			}
+			return tuiStatus("Description updated ✓")
+		}
+
+	case "photo":
+		if rest == "" {
+			return m, statusCmd("Usage: /group photo <path>")
+		}
+		return m, func() tea.Msg {
+			if err := client.SetGroupPhoto(s, jid, expandHome(rest)); err != nil {
+				return tuiError{err}
+			}
+			return tuiStatus("Group photo updated ✓")
+		}
+
+	case client.GroupAdd, client.GroupRemove, client.GroupPromote, client.GroupDemote:
+		if rest == "" {
+			return m, statusCmd(fmt.Sprintf("Usage: /group %s <contact>[, <contact>…]", sub))
+		}
+		return m, func() tea.Msg {
+			users, err := resolveContacts(s, rest)
+			if err != nil {
+				return tuiError{err}
+			}
+			if err := client.UpdateGroupParticipants(s, jid, users, sub); err != nil {
+				return tuiError{err}
+			}
+			return tuiStatus(fmt.Sprintf("%s: %d participant(s) ✓", sub, len(users)))
+		}
+
+	case "invite", "revoke":
+		reset := sub == "revoke"
+		return m, func() tea.Msg {
+			link, err := client.GroupInviteLink(s, jid, reset)
+			if err != nil {
+				return tuiError{err}
+			}
+			return tuiStatus("Invite link: " + link)
+		}
+	}
+	return m, statusCmd("Usage: /group create|rename|desc|photo|add|remove|promote|demote|invite|revoke")
+}
+
+// ── Chat settings ─────────────────────────────────────────────────────────────
+
+// defaultMute is how long "m" in the chat list and a bare /mute mute a chat.
+const defaultMute = 8 * time.Hour
+
+// selectedJID returns the JID of the selected chat.
+func (m Model) selectedJID() (types.JID, string, bool) {
+	if m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return types.JID{}, "", false
+	}
+	c := m.chats[m.selectedChat]
+	return c.JID, c.Name, true
+}
+
+// setPinned pins or unpins the selected chat.
+func (m Model) setPinned(pinned bool) tea.Cmd {
+	jid, name, ok := m.selectedJID()
+	if !ok {
+		return nil
+	}
+	s := m.state
+	return func() tea.Msg {
+		if err := client.SetPinned(s, jid, pinned); err != nil {
+			return tuiError{err}
+		}
+		if pinned {
+			return tuiStatus("Pinned " + name)
+		}
+		return tuiStatus("Unpinned " + name)
+	}
+}
+
+// setArchived archives or unarchives the selected chat.
+func (m Model) setArchived(archived bool) tea.Cmd {
+	jid, name, ok := m.selectedJID()
+	if !ok {
+		return nil
+	}
+	s := m.state
+	return func() tea.Msg {
+		if err := client.SetArchived(s, jid, archived); err != nil {
+			return tuiError{err}
+		}
+		if archived {
+			return tuiStatus("Archived " + name)
+		}
+		return tuiStatus("Unarchived " + name)
+	}
+}
+
+// setMuted mutes the selected chat for d (client.MuteForever: always; 0:
+// unmute).
+func (m Model) setMuted(d time.Duration) tea.Cmd {
+	jid, name, ok := m.selectedJID()
+	if !ok {
+		return nil
+	}
+	s := m.state
+	return func() tea.Msg {
+		if err := client.SetMuted(s, jid, d); err != nil {
+			return tuiError{err}
+		}
+		switch {
+		case d == 0:
+			return tuiStatus("Unmuted " + name)
+		case d < 0:
+			return tuiStatus("Muted " + name)
+		}
+		return tuiStatus("Muted " + name + " for " + formatMuteDuration(d))
+	}
+}
+
+// formatMuteDuration renders a mute duration the way /mute accepts it.
+func formatMuteDuration(d time.Duration) string {
+	switch day := 24 * time.Hour; {
+	case d%(7*day) == 0:
+		return strconv.Itoa(int(d/(7*day))) + "w"
+	case d%day == 0:
+		return strconv.Itoa(int(d/day)) + "d"
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(d.String(), "0s"), "0m")
+}
+
+// parseMuteDuration parses /mute's argument: empty (8 hours), "always", a
+// number of days or weeks ("3d", "1w") or a Go duration ("90m", "8h").
+func parseMuteDuration(arg string) (time.Duration, error) {
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	switch arg {
+	case "":
+		return defaultMute, nil
+	case "always", "forever":
+		return client.MuteForever, nil
+	}
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[arg[len(arg)-1]]
+	if unit != 0 {
+		if n, err := strconv.Atoi(arg[:len(arg)-1]); err == nil && n > 0 {
+			return time.Duration(n) * unit, nil
+		}
+	}
+	if d, err := time.ParseDuration(arg); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, errors.New("Usage: /mute [8h|1d|1w|always]")
+}
+
+// cmdExport implements "/export [all] [txt|json|md|html] [FROM [TO]]": the
+// open chat, or all chats, to a new directory in the working directory.
+// FROM and TO are dates; TO is included.
+func (m Model) cmdExport(args string) tea.Cmd {
+	opts := export.Options{Format: export.Text}
+	all := false
+	var dates []time.Time
+	for _, arg := range strings.Fields(args) {
+		if arg == "all" {
+			all = true
+			continue
+		}
+		if f, err := export.ParseFormat(arg); err == nil {
+			opts.Format = f
+			continue
+		}
+		d, err := time.ParseInLocation("2006-01-02", arg, time.Local)
+		if err != nil || len(dates) == 2 {
+			return statusCmd("Usage: /export [all] [txt|json|md|html] [YYYY-MM-DD [YYYY-MM-DD]]")
+		}
+		dates = append(dates, d)
+	}
+	if len(dates) > 0 {
+		opts.From = dates[0]
+	}
+	if len(dates) > 1 {
+		opts.To = dates[1].AddDate(0, 0, 1)
+	}
+
+	chats := m.chats
+	if !all {
+		if m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+			return statusCmd("Open a chat first, or use /export all")
+		}
+		chats = m.chats[m.selectedChat : m.selectedChat+1]
+	}
+	chats = append([]apptypes.ChatItem(nil), chats...)
+	s := m.state
+	dir := "export-" + time.Now().Format("20060102-150405")
+	return func() tea.Msg {
+		res, err := export.Export(s.DB, chats, dir, opts)
+		if err != nil {
+			s.Logger.Error("Export failed: " + err.Error())
+			return tuiError{err}
+		}
+		s.Logger.Info(fmt.Sprintf("Exported %d messages from %d chats to %s", res.Messages, res.Chats, dir))
+		if res.Chats == 0 {
+			return tuiStatus("Nothing to export")
+		}
+		abs, _ := filepath.Abs(dir)
+		return tuiStatus(fmt.Sprintf("Exported %d messages to %s", res.Messages, abs))
+	}
+}
+
+// currentGroup returns the JID of the selected chat if it is a group.
+func (m Model) currentGroup() (types.JID, bool) {
+	if m.selectedChat < 0 || m.selectedChat >= len(m.chats) || !m.chats[m.selectedChat].IsGroup {
+		return types.JID{}, false
+	}
+	return m.chats[m.selectedChat].JID, true
+}
+
+// resolveContacts resolves a comma-separated list of names or numbers.
+func resolveContacts(s *state.AppState, list string) ([]types.JID, error) {
+	var out []types.JID
+	for _, part := range strings.Split(list, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		jid, err := client.ResolveJID(s, part)
+		if err != nil {
+			return nil, err
+		}
+		if jid.Server == types.GroupServer {
+			return nil, errors.New(strings.TrimSpace(part) + " is a group, not a contact")
+		}
+		out = append(out, jid)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no contacts given")
+	}
+	return out, nil
+}
+
+// statusCmd wraps a status flash in a command.
+func statusCmd(text string) tea.Cmd {
+	return func() tea.Msg { return tuiStatus(text) }
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/wa"
+)
+
+func TestSlashInput(t *testing.T) {
+	m := newDemoModel(t, 80, 24)
+	fake := m.state.Client.(*wa.Fake)
+	m = press(m, "enter")
+
+	// An unknown command keeps what was typed.
+	m = press(m, append(strings.Split("/jion", ""), "enter")...)
+	if m.inputText != "/jion" {
+		t.Errorf("input after unknown command = %q", m.inputText)
+	}
+
+	// "//" escapes the command prefix.
+	m.inputText, m.inputCursor = "", 0
+	m = press(m, append(strings.Split("//usr/bin/foo", ""), "enter")...)
+	if m.inputText != "" {
+		t.Errorf("input after sending = %q", m.inputText)
+	}
+	sent := fake.SentMessages()
+	if len(sent) != 1 || sent[0].Message.GetConversation() != "/usr/bin/foo" {
+		t.Errorf("sent = %+v", sent)
+	}
+}
+
+func TestInputCursorRows(t *testing.T) {
+	m := newDemoModel(t, 80, 24)
+	m = press(m, "enter")
+	if m.focus != focusInput {
+		t.Fatalf("focus = %v", m.focus)
+	}
+	// Eight lines, two more than InputMaxLines.
+	const text = "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight"
+	for _, tt := range []struct {
+		cursor int
+		keys   []string
+		want   int
+	}{
+		{39, []string{"up"}, 33},                          // same column in "seven"
+		{39, []string{"up", "up"}, 27},                    // clamped to the end of "six"
+		{39, strings.Fields(strings.Repeat("up ", 7)), 3}, // columns clamp on the way
+		{39, []string{"down"}, 39},                        // last row stays put
+		{0, []string{"up"}, 0},                            // first row stays put
+		{2, strings.Fields(strings.Repeat("down ", 7)), 36},
+	} {
+		m.inputText, m.inputCursor = text, tt.cursor
+		got := press(m, tt.keys...)
+		if got.inputCursor != tt.want {
+			t.Errorf("cursor %d after %v = %d, want %d", tt.cursor, tt.keys, got.inputCursor, tt.want)
+		}
+	}
+
+	m.inputText, m.inputCursor = text, len(text)
+	if h := m.inputHeight(); h != m.state.Config.InputMaxLines {
+		t.Errorf("input height = %d, want %d", h, m.state.Config.InputMaxLines)
+	}
+	// The visible rows follow the cursor.
+	if view := m.renderInput(80); !strings.Contains(view, "eight") || strings.Contains(view, "one") {
+		t.Errorf("input at the end shows:\n%s", view)
+	}
+	top := press(m, strings.Fields(strings.Repeat("up ", 7))...)
+	if view := top.renderInput(80); !strings.Contains(view, "one") || strings.Contains(view, "eight") {
+		t.Errorf("input at the top shows:\n%s", view)
+	}
+
+	// Soft-wrapped rows move the same way.
+	m.inputText, m.inputCursor = strings.Repeat("x", 200), 3
+	rows := m.inputRows(m.inputTextW(m.width))
+	if got := press(m, "down").inputCursor; len(rows) < 2 || got != rows[1].start+3 {
+		t.Errorf("cursor after down in wrapped text = %d, rows %v", got, rows)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	m := newDemoModel(t, 100, 30)
+	if err := m.state.DB.EnableEncryption(db.EncryptionKeyring, bytes.Repeat([]byte{7}, db.KeySize)); err != nil {
+		t.Fatal(err)
+	}
+	search := func(m Model, query string) Model {
+		t.Helper()
+		m.focus, m.inputText, m.inputCursor = focusInput, "", 0
+		return press(m, append(strings.Split("/search "+query, ""), "enter")...)
+	}
+
+	m = search(m, "TOMORROW")
+	if m.search == nil {
+		t.Fatalf("no results, status %q", m.statusMsg)
+	}
+	var ids []string
+	for _, r := range m.search.results {
+		ids = append(ids, r.Message.ID)
+	}
+	if strings.Join(ids, " ") != "t1 a1" {
+		t.Errorf("results = %v, want t1 a1", ids)
+	}
+	if view := m.View(); !strings.Contains(view, "Standup moved") || !strings.Contains(view, "You: Lunch tomorrow?") {
+		t.Errorf("results view:\n%s", view)
+	}
+
+	// Enter opens the chat at the message.
+	m = press(m, "down", "enter")
+	if c, _ := m.selectedItem(); m.search != nil || c.Name != "Alice" || m.selMsgID != "a1" || m.focus != focusMessages {
+		t.Errorf("after enter: search open %v, chat %q, message %q", m.search != nil, c.Name, m.selMsgID)
+	}
+
+	// Archived chats are shown to reach a match.
+	m = press(search(m, "new year"), "enter")
+	if c, _ := m.selectedItem(); c.Name != "Carol" || !m.showArchived {
+		t.Errorf("archived match: chat %q, archived shown %v", c.Name, m.showArchived)
+	}
+
+	m = search(m, "nothing like this")
+	if m.search != nil || !strings.Contains(m.statusMsg, "No messages") {
+		t.Errorf("no match: status %q", m.statusMsg)
+	}
+}
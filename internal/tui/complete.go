@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ── Input completion ──────────────────────────────────────────────────────────
+
+// maxCompletions is the number of candidates shown in the popup.
+const maxCompletions = 6
+
+// minEmojiQuery is how many characters after ":" open the emoji popup.
+const minEmojiQuery = 2
+
+// complKind is what a completion popup completes.
+type complKind int
+
+const (
+	complMention complKind = iota // "@Name" in groups
+	complEmoji                    // ":shortcode:"
+)
+
+// completion tracks an open completion popup in the input bar.
+type completion struct {
+	kind  complKind
+	start int // rune index of the trigger character ("@" or ":")
+	sel   int // selected candidate
+}
+
+// complItem is one completion candidate.
+type complItem struct {
+	label  string    // shown in the popup
+	insert string    // replaces the trigger and query
+	name   string    // mentioned name, empty for emoji
+	jid    types.JID // mentioned user
+}
+
+// completionQuery returns the text typed after the trigger character.
+func (m Model) completionQuery() string {
+	r := []rune(m.inputText)
+	if m.compl == nil || m.compl.start >= len(r) || m.inputCursor <= m.compl.start {
+		return ""
+	}
+	return string(r[m.compl.start+1 : m.inputCursor])
+}
+
+// completionItems returns the candidates matching the current query: the
+// participants of the open group, excluding the logged-in account, or emoji
+// whose shortcode matches.
+func (m Model) completionItems() []complItem {
+	if m.compl != nil && m.compl.kind == complEmoji {
+		q := m.completionQuery()
+		if len([]rune(q)) < minEmojiQuery {
+			return nil
+		}
+		var items []complItem
+		for _, e := range searchEmoji(q, maxCompletions) {
+			items = append(items, complItem{label: e.emoji + "  :" + e.code + ":", insert: e.emoji})
+		}
+		return items
+	}
+	if m.compl == nil || m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return nil
+	}
+	info := m.chatInfo[m.chats[m.selectedChat].JID.String()]
+	if info == nil {
+		return nil
+	}
+	q := strings.ToLower(m.completionQuery())
+	var prefix, contains []complItem
+	for _, p := range info.Participants {
+		if p.Name == "You" {
+			continue
+		}
+		it := complItem{label: "@" + p.Name, insert: "@" + p.Name + " ", name: p.Name, jid: p.JID}
+		name := strings.ToLower(p.Name)
+		switch {
+		case strings.HasPrefix(name, q) || strings.HasPrefix(p.JID.User, q):
+			prefix = append(prefix, it)
+		case strings.Contains(name, q):
+			contains = append(contains, it)
+		}
+	}
+	items := append(prefix, contains...)
+	if len(items) > maxCompletions {
+		items = items[:maxCompletions]
+	}
+	return items
+}
+
+// updateCompletion opens, keeps or closes the completion popup after the
+// input text changed.
+func (m Model) updateCompletion() (Model, tea.Cmd) {
+	r := []rune(m.inputText)
+	if m.compl != nil && m.compl.kind == complEmoji {
+		return m.updateEmojiCompletion(), nil
+	}
+	if m.compl != nil {
+		q := m.completionQuery()
+		// Names may contain spaces, so only give up on a space once nothing matches.
+		if m.inputCursor <= m.compl.start || m.compl.start >= len(r) || r[m.compl.start] != '@' ||
+			len(m.completionItems()) == 0 && strings.Contains(q, " ") {
+			m.compl = nil
+		} else if m.compl.sel >= len(m.completionItems()) {
+			m.compl.sel = 0
+		}
+		return m, nil
+	}
+	if m.inputCursor == 0 {
+		return m, nil
+	}
+	trigger := r[m.inputCursor-1]
+	// Only start a completion at a word boundary (not in e-mail addresses or times).
+	if m.inputCursor >= 2 && r[m.inputCursor-2] != ' ' && r[m.inputCursor-2] != '\n' {
+		return m, nil
+	}
+	switch trigger {
+	case ':':
+		m.compl = &completion{kind: complEmoji, start: m.inputCursor - 1}
+		return m, nil
+	case '@':
+		if _, ok := m.currentGroup(); ok {
+			m.compl = &completion{start: m.inputCursor - 1}
+			return m, m.loadChatInfo()
+		}
+	}
+	return m, nil
+}
+
+// updateEmojiCompletion keeps or closes an emoji completion. Typing the
+// closing ":" of a known shortcode replaces it with the emoji right away.
+func (m Model) updateEmojiCompletion() Model {
+	r := []rune(m.inputText)
+	if m.inputCursor <= m.compl.start || m.compl.start >= len(r) || r[m.compl.start] != ':' {
+		m.compl = nil
+		return m
+	}
+	q := m.completionQuery()
+	if strings.HasSuffix(q, ":") {
+		if e, ok := lookupShortcode(strings.TrimSuffix(q, ":")); ok {
+			return m.acceptCompletion(complItem{insert: e})
+		}
+	}
+	if strings.ContainsAny(q, " :\n") {
+		m.compl = nil
+	} else if m.compl.sel >= len(m.completionItems()) {
+		m.compl.sel = 0
+	}
+	return m
+}
+
+// keyCompletion handles keys while the popup is open. It reports whether the
+// key was consumed.
+func (m Model) keyCompletion(k tea.KeyMsg) (Model, bool) {
+	items := m.completionItems()
+	switch k.String() {
+	case "esc":
+		// An emoji popup is invisible until enough is typed; let Esc through then.
+		consumed := len(items) > 0 || m.compl.kind == complMention
+		m.compl = nil
+		return m, consumed
+	case "up", "ctrl+p":
+		if len(items) == 0 {
+			return m, false
+		}
+		m.compl.sel = (m.compl.sel + len(items) - 1) % len(items)
+		return m, true
+	case "down", "ctrl+n":
+		if len(items) == 0 {
+			return m, false
+		}
+		m.compl.sel = (m.compl.sel + 1) % len(items)
+		return m, true
+	case "tab", "enter":
+		if len(items) == 0 {
+			m.compl = nil
+			return m, k.String() == "tab"
+		}
+		return m.acceptCompletion(items[m.compl.sel]), true
+	}
+	return m, false
+}
+
+// acceptCompletion replaces the trigger and typed query with the chosen item.
+func (m Model) acceptCompletion(it complItem) Model {
+	r := []rune(m.inputText)
+	insert := []rune(it.insert)
+	nr := make([]rune, 0, len(r)+len(insert))
+	nr = append(nr, r[:m.compl.start]...)
+	nr = append(nr, insert...)
+	nr = append(nr, r[m.inputCursor:]...)
+	m.inputText = string(nr)
+	m.inputCursor = m.compl.start + len(insert)
+	if it.name != "" {
+		m.draftMentions[it.name] = it.jid
+	} else {
+		m = m.useEmoji(it.insert)
+	}
+	m.compl = nil
+	return m
+}
+
+// expandMentions rewrites "@Name" mentions in text into WhatsApp's wire
+// format "@<number>" and returns the mentioned JIDs.
+func (m Model) expandMentions(text string) (string, []types.JID) {
+	// Replace longer names first so "Ann Marie" wins over "Ann".
+	names := make([]string, 0, len(m.draftMentions))
+	for name := range m.draftMentions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	var jids []types.JID
+	for _, name := range names {
+		jid := m.draftMentions[name]
+		if !strings.Contains(text, "@"+name) {
+			continue
+		}
+		text = strings.ReplaceAll(text, "@"+name, "@"+jid.User)
+		jids = append(jids, jid)
+	}
+	return text, jids
+}
+
+// renderCompletion renders the popup lines, at most w columns wide.
+func (m Model) renderCompletion(w int) []string {
+	items := m.completionItems()
+	if len(items) == 0 {
+		if m.compl != nil && m.compl.kind == complMention {
+			return []string{sMuted.Render("No matching participants")}
+		}
+		return nil
+	}
+	pw := min(w, 40)
+	lines := make([]string, 0, len(items))
+	for i, it := range items {
+		label := truncateStr(it.label, pw-2)
+		if i == m.compl.sel {
+			lines = append(lines, sChatSel.Width(pw).Render(label))
+		} else {
+			lines = append(lines, sChatNorm.Width(pw).Render(label))
+		}
+	}
+	return lines
+}
+
+// overlayBottom replaces the last lines of a panel's content (padded to h
+// lines) with popup.
+func overlayBottom(content string, popup []string, h int) string {
+	if len(popup) == 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for len(lines) < h {
+		lines = append(lines, "")
+	}
+	lines = lines[:h]
+	start := max(0, h-len(popup))
+	for i := start; i < h; i++ {
+		lines[i] = popup[i-start]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── External editor ───────────────────────────────────────────────────────────
+
+// tuiEditorDone carries the draft back from $EDITOR.
+type tuiEditorDone struct {
+	text string
+	send bool // send right away instead of returning to the composer
+	err  error
+}
+
+// editorCommand returns the user's editor: $VISUAL, then $EDITOR, then vi.
+// The value may contain arguments (e.g. "code --wait").
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if f := strings.Fields(os.Getenv(env)); len(f) > 0 {
+			return f
+		}
+	}
+	return []string{"vi"}
+}
+
+// openEditor suspends the TUI and opens the current draft in $EDITOR through
+// a temp file. The edited text comes back as a tuiEditorDone.
+func (m Model) openEditor(send bool) tea.Cmd {
+	f, err := os.CreateTemp("", "whatsapp-tui-*.txt")
+	if err != nil {
+		return func() tea.Msg { return tuiEditorDone{err: err} }
+	}
+	path := f.Name()
+	_, err = f.WriteString(m.inputText)
+	f.Close()
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return tuiEditorDone{err: err} }
+	}
+
+	args := append(editorCommand(), path)
+	c := exec.Command(args[0], args[1:]...)
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return tuiEditorDone{err: err}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return tuiEditorDone{err: err}
+		}
+		// Editors usually append a final newline.
+		return tuiEditorDone{text: strings.TrimRight(string(data), "\n"), send: send}
+	})
+}
+
+// applyEditorResult puts the edited text into the composer, or sends it.
+func (m Model) applyEditorResult(msg tuiEditorDone) (Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, statusCmd("Editor: " + msg.err.Error())
+	}
+	m.inputText = msg.text
+	m.inputCursor = len([]rune(msg.text))
+	m.focus = focusInput
+	if msg.send {
+		return m.sendDraft()
+	}
+	return m, nil
+}
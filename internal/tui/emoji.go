@@ -0,0 +1,359 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kyokomi/emoji/v2"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/client"
+	apptypes "DevStarByte/internal/types"
+)
+
+// ── Emoji ─────────────────────────────────────────────────────────────────────
+
+// emojiEntry is one shortcode (without colons) and its emoji.
+type emojiEntry struct {
+	code  string
+	emoji string
+}
+
+var (
+	emojiOnce  sync.Once
+	emojiList  []emojiEntry      // sorted by code length, then code
+	emojiCodes map[string]string // code → emoji
+)
+
+// popularEmoji fills the picker before anything has been used.
+var popularEmoji = []string{
+	"👍", "❤️", "😂", "😮", "😢", "🙏", "🎉", "🔥", "👏", "😀",
+	"😊", "😍", "🤔", "👀", "✅", "🙌", "😅", "😎", "💯", "🤣",
+	"😉", "😘", "🥰", "😭", "😡", "👋", "🤝", "💪", "✨", "🥳",
+}
+
+// loadEmoji builds the shortcode index once.
+func loadEmoji() {
+	emojiOnce.Do(func() {
+		emojiCodes = make(map[string]string)
+		for code, e := range emoji.CodeMap() {
+			c := strings.Trim(code, ":")
+			e = strings.TrimSpace(e) // the library pads some emoji with a space
+			emojiCodes[c] = e
+			emojiList = append(emojiList, emojiEntry{code: c, emoji: e})
+		}
+		sort.Slice(emojiList, func(i, j int) bool {
+			a, b := emojiList[i].code, emojiList[j].code
+			if len(a) != len(b) {
+				return len(a) < len(b)
+			}
+			return a < b
+		})
+	})
+}
+
+// lookupShortcode returns the emoji for a shortcode such as "thumbsup".
+func lookupShortcode(code string) (string, bool) {
+	loadEmoji()
+	e, ok := emojiCodes[strings.ToLower(code)]
+	return e, ok
+}
+
+// shortcodeFor returns the shortest word shortcode of an emoji (":thumbsup:"
+// rather than ":+1:"), or "".
+func shortcodeFor(e string) string {
+	loadEmoji()
+	code := ""
+	for _, it := range emojiList {
+		if it.emoji != e {
+			continue
+		}
+		if it.code[0] >= 'a' && it.code[0] <= 'z' {
+			return it.code
+		}
+		if code == "" {
+			code = it.code
+		}
+	}
+	return code
+}
+
+// searchEmoji returns up to limit emoji whose shortcode matches query;
+// prefix matches come first. Each emoji is listed once.
+func searchEmoji(query string, limit int) []emojiEntry {
+	loadEmoji()
+	q := strings.ToLower(query)
+	seen := make(map[string]bool)
+	var prefix, contains []emojiEntry
+	for _, it := range emojiList {
+		if seen[it.emoji] {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(it.code, q):
+			prefix = append(prefix, it)
+		case strings.Contains(it.code, q):
+			contains = append(contains, it)
+		default:
+			continue
+		}
+		seen[it.emoji] = true
+	}
+	out := append(prefix, contains...)
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// ── Emoji picker ──────────────────────────────────────────────────────────────
+
+const (
+	pickerMaxResults = 60
+	pickerRows       = 4 // visible grid rows
+	pickerCellW      = 3 // columns per emoji cell
+)
+
+// emojiPicker is the emoji picker overlay. It either inserts the chosen emoji
+// into the draft or, when react is set, reacts to target with it.
+type emojiPicker struct {
+	query  string
+	sel    int
+	react  bool
+	chat   types.JID
+	target apptypes.Message
+}
+
+// pickerItems returns the emoji offered for the current query: recently used
+// and popular emoji when the query is empty, search results otherwise.
+func (m Model) pickerItems() []emojiEntry {
+	if m.picker.query != "" {
+		return searchEmoji(m.picker.query, pickerMaxResults)
+	}
+	seen := make(map[string]bool)
+	var out []emojiEntry
+	for _, e := range append(append([]string{}, m.recentEmoji...), popularEmoji...) {
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, emojiEntry{code: shortcodeFor(e), emoji: e})
+		}
+	}
+	return out
+}
+
+// pickerCols is the number of emoji per grid row at panel width w.
+func pickerCols(w int) int {
+	return max(1, (min(w, 44)-2)/pickerCellW)
+}
+
+// openPicker opens the picker for inserting into the draft.
+func (m Model) openPicker() Model {
+	m.picker = &emojiPicker{}
+	m.compl = nil
+	return m
+}
+
+// openReactionPicker opens the picker to react to the selected message, or
+// the last incoming one when none is selected.
+func (m Model) openReactionPicker() (Model, tea.Cmd) {
+	if m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return m, nil
+	}
+	chat := m.chats[m.selectedChat].JID
+	target, ok := m.reactionTarget(chat.String())
+	if !ok {
+		return m, statusCmd("No message to react to")
+	}
+	m.picker = &emojiPicker{react: true, chat: chat, target: target}
+	return m, nil
+}
+
+// reactionTarget returns the selected message or the last incoming one.
+func (m Model) reactionTarget(key string) (apptypes.Message, bool) {
+	msgs := m.messages[key]
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if m.selMsgID != "" && msgs[i].ID == m.selMsgID ||
+			m.selMsgID == "" && !msgs[i].FromMe && !msgs[i].System {
+			return msgs[i], true
+		}
+	}
+	return apptypes.Message{}, false
+}
+
+// keyPicker handles all keys while the picker is open.
+func (m Model) keyPicker(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.pickerItems()
+	_, msgW, _ := m.panelWidths()
+	cols := pickerCols(msgW)
+	p := m.picker
+	switch k.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.picker = nil
+	case "left":
+		p.sel = max(0, p.sel-1)
+	case "right":
+		p.sel = min(len(items)-1, p.sel+1)
+	case "up":
+		if p.sel >= cols {
+			p.sel -= cols
+		}
+	case "down":
+		if p.sel+cols < len(items) {
+			p.sel += cols
+		}
+	case "backspace", "ctrl+h":
+		if r := []rune(p.query); len(r) > 0 {
+			p.query = string(r[:len(r)-1])
+			p.sel = 0
+		}
+	case "enter", "tab":
+		if p.sel >= 0 && p.sel < len(items) {
+			return m.pickEmoji(items[p.sel].emoji)
+		}
+		m.picker = nil
+	default:
+		if k.Type == tea.KeyRunes && !k.Paste || k.String() == " " {
+			p.query += string(k.Runes)
+			p.sel = 0
+		}
+	}
+	return m, nil
+}
+
+// pickEmoji applies the chosen emoji and closes the picker.
+func (m Model) pickEmoji(e string) (tea.Model, tea.Cmd) {
+	p := m.picker
+	m.picker = nil
+	m = m.useEmoji(e)
+	if !p.react {
+		m = m.insertText(e)
+		m.focus = focusInput
+		return m, nil
+	}
+	m.selMsgID = ""
+	s := m.state
+	return m, func() tea.Msg {
+		if err := client.SendReaction(s, p.chat, p.target, e); err != nil {
+			return tuiError{err}
+		}
+		return tuiStatus("Reacted " + e)
+	}
+}
+
+// useEmoji moves e to the front of the recently used list and persists it.
+func (m Model) useEmoji(e string) Model {
+	m.state.DB.RecordEmoji(e)
+	recent := []string{e}
+	for _, r := range m.recentEmoji {
+		if r != e && len(recent) < pickerMaxResults {
+			recent = append(recent, r)
+		}
+	}
+	m.recentEmoji = recent
+	return m
+}
+
+// insertText inserts text into the draft at the cursor.
+func (m Model) insertText(text string) Model {
+	r := []rune(m.inputText)
+	ins := []rune(text)
+	nr := make([]rune, 0, len(r)+len(ins))
+	nr = append(nr, r[:m.inputCursor]...)
+	nr = append(nr, ins...)
+	nr = append(nr, r[m.inputCursor:]...)
+	m.inputText = string(nr)
+	m.inputCursor += len(ins)
+	return m
+}
+
+// renderPicker renders the picker popup, at most w columns wide.
+func (m Model) renderPicker(w int) []string {
+	p := m.picker
+	pw := min(w, 44)
+	cols := pickerCols(w)
+	items := m.pickerItems()
+	box := lipgloss.NewStyle().Width(pw).Background(clrPanel)
+
+	title := "Emoji"
+	if p.react {
+		title = "React"
+	}
+	lines := []string{box.Render(sAccent.Bold(true).Render(title) + "  " + p.query + "▏")}
+
+	if len(items) == 0 {
+		lines = append(lines, box.Render(sMuted.Render("No matching emoji")))
+		return lines
+	}
+	// Scroll the grid so the selected row stays visible.
+	selRow := p.sel / cols
+	first := max(0, selRow-pickerRows+1)
+	for row := first; row < first+pickerRows && row*cols < len(items); row++ {
+		var sb strings.Builder
+		for i := row * cols; i < min(len(items), (row+1)*cols); i++ {
+			cell := items[i].emoji + strings.Repeat(" ", max(0, pickerCellW-1-lipgloss.Width(items[i].emoji)))
+			if i == p.sel {
+				sb.WriteString(sChatSel.PaddingLeft(0).Render(cell) + " ")
+			} else {
+				sb.WriteString(cell + " ")
+			}
+		}
+		lines = append(lines, box.Render(sb.String()))
+	}
+	if code := items[min(p.sel, len(items)-1)].code; code != "" {
+		lines = append(lines, box.Render(sMuted.Render(":"+code+":")))
+	}
+	return lines
+}
+
+// ── Message selection ─────────────────────────────────────────────────────────
+
+// moveMsgSel moves the message selection by delta messages and scrolls it
+// into view. Without a selection it starts from the newest message.
+func (m Model) moveMsgSel(delta int) Model {
+	if m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return m
+	}
+	key := m.chats[m.selectedChat].JID.String()
+	msgs := m.messages[key]
+	idx := -1
+	for i, msg := range msgs {
+		if msg.ID == m.selMsgID {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		idx = len(msgs) - delta // no selection yet: start at the newest message
+	}
+	idx = max(0, min(len(msgs)-1, idx+delta))
+	if idx >= 0 {
+		m.selMsgID = msgs[idx].ID
+	}
+	if idx < 0 {
+		return m
+	}
+
+	_, w, _ := m.panelWidths()
+	lines, starts := m.messageLines(key, w)
+	visH := max(1, m.mainInnerH()-2)
+	offset := m.msgScroll
+	if offset < 0 || offset+visH > len(lines) {
+		offset = max(0, len(lines)-visH)
+	}
+	end := len(lines)
+	if idx+1 < len(starts) {
+		end = starts[idx+1]
+	}
+	switch {
+	case starts[idx] < offset:
+		offset = starts[idx]
+	case end > offset+visH:
+		offset = max(starts[idx], end-visH)
+	}
+	m.msgScroll = min(offset, max(0, len(lines)-visH))
+	return m
+}
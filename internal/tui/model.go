@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"sort"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -29,21 +28,58 @@ const (
 // Model is the bubbletea application model.
 type Model struct {
 	state         *state.AppState
+	events        *state.Subscription
 	width, height int
 	focus         focusArea
+	blurred       bool // the terminal window lost focus
 
 	// Chat list state.
 	chats        []apptypes.ChatItem
 	chatScroll   int
 	selectedChat int
+	marked       map[string]bool // contacts marked with Space (e.g. for /group create)
+	showArchived bool            // archived chats are listed below the "Archived" row
 
 	// Message state.
 	messages  map[string][]apptypes.Message
 	msgScroll int
+	selMsgID  string // message selected with J/K (e.g. for reactions)
+
+	// First unread message of the open chat, remembered when it was opened
+	// so the "unread messages" divider stays after the chat is marked read.
+	unreadChat string
+	unreadID   string
+
+	// Info side panel state.
+	showInfo    bool
+	chatInfo    map[string]*apptypes.ChatInfo
+	infoLoading map[string]bool
 
 	// Text input state.
-	inputText   string
-	inputCursor int // rune index
+	inputText     string
+	inputCursor   int                  // rune index
+	compl         *completion          // open completion popup, nil if none
+	draftMentions map[string]types.JID // "@Name" mentions inserted into the draft
+	ctrlX         bool                 // Ctrl+X pressed, waiting for the chord's second key
+
+	// Emoji picker state.
+	picker      *emojiPicker // open picker, nil if none
+	recentEmoji []string
+
+	// Auto-reply rules editor, nil if closed.
+	rules *rulesEditor
+
+	// Scheduled messages of the open chat, nil if closed.
+	schedule *scheduleEditor
+
+	// Matches of /search, nil if closed.
+	search *searchResults
+
+	// Per-chat drafts. The input buffer always belongs to draftChat; other
+	// chats' drafts are stashed in drafts / mentionDrafts.
+	draftChat     string
+	drafts        map[string]string
+	mentionDrafts map[string]map[string]types.JID
 
 	// Sync status.
 	syncCount int
@@ -56,6 +92,9 @@ type Model struct {
 
 // NewModel creates an initialised Model.
 func NewModel(s *state.AppState, chats []apptypes.ChatItem) Model {
+	// Subscribe before copying the messages so nothing published in between
+	// is missed; duplicates are dropped when the events are applied.
+	events := s.Events.Subscribe()
 	msgs := make(map[string][]apptypes.Message)
 	s.MessagesMu.RLock()
 	for k, v := range s.MessagesMap {
@@ -63,22 +102,51 @@ func NewModel(s *state.AppState, chats []apptypes.ChatItem) Model {
 	}
 	s.MessagesMu.RUnlock()
 
-	return Model{
-		state:    s,
-		chats:    chats,
-		messages: msgs,
+	drafts := s.DB.LoadDrafts()
+	if drafts == nil {
+		drafts = make(map[string]string)
+	}
+
+	m := Model{
+		state:     s,
+		events:    events,
+		chats:     chats,
+		messages:  msgs,
 		msgScroll: -1,
+		marked:    make(map[string]bool),
+
+		chatInfo:    make(map[string]*apptypes.ChatInfo),
+		infoLoading: make(map[string]bool),
+
+		draftMentions: make(map[string]types.JID),
+		drafts:        drafts,
+		mentionDrafts: make(map[string]map[string]types.JID),
+
+		recentEmoji: s.DB.RecentEmoji(pickerMaxResults),
+	}
+	// Pick up chats the event handlers changed since chats was loaded.
+	s.ChatsMu.RLock()
+	for _, c := range s.ChatsMap {
+		m = m.upsertChat(*c)
 	}
+	s.ChatsMu.RUnlock()
+	m = m.sortChats().syncDraft()
+	m.publishFocus()
+	return m
 }
 
 // ── Tea message types ─────────────────────────────────────────────────────────
 
-type tuiNewMsg apptypes.MsgEvent
-type tuiHistoryRefresh struct{}
+type tuiEvents []state.Event
 type tuiLoadedMsgs struct {
 	chatJID string
 	msgs    []apptypes.Message
 }
+type tuiChatInfo struct {
+	chatJID string
+	info    *apptypes.ChatInfo
+	err     error
+}
 type tuiStatus string
 type tuiError struct{ err error }
 type tuiSyncCheck int // carries the syncCount at schedule time
@@ -86,7 +154,7 @@ type tuiSyncCheck int // carries the syncCount at schedule time
 // ── Init ──────────────────────────────────────────────────────────────────────
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.listenForMsg(), m.listenForHistory())
+	return m.listenForEvents()
 }
 
 // loadChatMsgs fetches persisted messages for a chat from SQLite.
@@ -97,22 +165,20 @@ func (m Model) loadChatMsgs(chatJID string) tea.Cmd {
 	}
 }
 
-// listenForHistory blocks until a history sync signal arrives, then delivers
-// a tuiHistoryRefresh so the model can rebuild chats and messages from global state.
-func (m Model) listenForHistory() tea.Cmd {
-	ch := m.state.HistoryCh
-	return func() tea.Msg {
-		<-ch
-		return tuiHistoryRefresh{}
-	}
-}
+// eventBatchWindow collects bursts of events, e.g. during history sync, into
+// one UI update.
+const eventBatchWindow = 16 * time.Millisecond
 
-// listenForMsg blocks until a message arrives on incomingCh, then delivers it
-// as a tuiNewMsg so the Update loop can process it.
-func (m Model) listenForMsg() tea.Cmd {
-	ch := m.state.IncomingCh
+// listenForEvents blocks until state events arrive and delivers them as one
+// tuiEvents batch.
+func (m Model) listenForEvents() tea.Cmd {
+	sub := m.events
 	return func() tea.Msg {
-		return tuiNewMsg(<-ch)
+		evts, ok := sub.Next(eventBatchWindow)
+		if !ok {
+			return nil
+		}
+		return tuiEvents(evts)
 	}
 }
 
@@ -124,14 +190,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width, m.height = msg.Width, msg.Height
 		return m, nil
 
-	case tuiHistoryRefresh:
-		m.syncCount++
-		m.syncDone = false
-		snapshot := m.syncCount
-		return m.rebuildFromGlobal(), tea.Batch(
-			m.listenForHistory(),
-			tea.Tick(8*time.Second, func(time.Time) tea.Msg { return tuiSyncCheck(snapshot) }),
-		)
+	case tea.FocusMsg:
+		m.blurred = false
+		m.publishFocus()
+		return m, nil
+
+	case tea.BlurMsg:
+		m.blurred = true
+		m.publishFocus()
+		return m, nil
+
+	case tuiEvents:
+		m, cmd := m.applyEvents(msg)
+		return m, tea.Batch(cmd, m.listenForEvents())
 
 	case tuiLoadedMsgs:
 		m.messages[msg.chatJID] = mergeMessages(m.messages[msg.chatJID], msg.msgs)
@@ -141,8 +212,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case tuiNewMsg:
-		return m.applyNewMsg(apptypes.MsgEvent(msg)), m.listenForMsg()
+	case tuiChatInfo:
+		delete(m.infoLoading, msg.chatJID)
+		if msg.err != nil {
+			m.statusMsg = "Error: " + msg.err.Error()
+			m.statusTime = time.Now()
+			return m, nil
+		}
+		m.chatInfo[msg.chatJID] = msg.info
+		return m, nil
+
+	case tuiEditorDone:
+		return m.applyEditorResult(msg)
+
+	case tuiSearchDone:
+		return m.showSearch(msg)
 
 	case tuiStatus:
 		m.statusMsg = string(msg)
@@ -167,124 +251,234 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m Model) applyNewMsg(evt apptypes.MsgEvent) Model {
-	// Also pull in any new messages that may have been added to the global map
-	// by the history sync handler concurrently.
-	m = m.rebuildMessages()
-	key := evt.ChatJID.String()
-	m.messages[key] = append(m.messages[key], evt.Message)
+// applyEvents applies a batch of state events to the model. The chat list is
+// re-sorted once per batch.
+func (m Model) applyEvents(evts []state.Event) (Model, tea.Cmd) {
+	open := ""
+	if c, ok := m.selectedItem(); ok {
+		open = c.JID.String()
+	}
+	var cmd tea.Cmd
+	chatsChanged := false
+	for _, evt := range evts {
+		switch e := evt.(type) {
+		case state.MessageAdded:
+			key := e.Chat.String()
+			m.messages[key] = appendMessage(m.messages[key], e.Message)
+			if e.Message.System {
+				// Group metadata changed; refetch the info panel on next use.
+				delete(m.chatInfo, key)
+			}
+			if key == open {
+				m.msgScroll = -1 // follow new messages in the open chat
+			}
+			if e.Message.FromMe && m.state.Daemon != nil {
+				// Maybe the daemon sent a scheduled message.
+				m.state.Scheduler.Reload()
+			}
+
+		case state.MessageUpdated:
+			msgs := m.messages[e.Chat.String()]
+			for i := range msgs {
+				if msgs[i].ID == e.Message.ID {
+					msgs[i] = e.Message
+					break
+				}
+			}
+
+		case state.MessagesMerged:
+			key := e.Chat.String()
+			m.messages[key] = mergeMessages(m.messages[key], e.Messages)
 
-	found := false
-	for i := range m.chats {
-		if m.chats[i].JID.String() == key {
-			found = true
-			m.chats[i].LastMsg = evt.Message.Content
-			m.chats[i].LastTime = evt.Message.Timestamp
-			if !evt.Message.FromMe {
-				m.chats[i].Unread++
+		case state.Receipt:
+			ids := make(map[string]bool, len(e.MessageIDs))
+			for _, id := range e.MessageIDs {
+				ids[id] = true
 			}
-			break
+			msgs := m.messages[e.Chat.String()]
+			for i := range msgs {
+				if ids[msgs[i].ID] && msgs[i].Status < e.Status {
+					msgs[i].Status = e.Status
+				}
+			}
+
+		case state.ChatUpdated:
+			m = m.upsertChat(e.Chat)
+			chatsChanged = true
+
+		case state.HistorySynced:
+			m.syncCount++
+			m.syncDone = false
+			snapshot := m.syncCount
+			cmd = tea.Tick(8*time.Second, func(time.Time) tea.Msg { return tuiSyncCheck(snapshot) })
 		}
 	}
+	if chatsChanged {
+		m = m.sortChats().syncDraft()
+		m.publishFocus()
+	}
+	return m, cmd
+}
 
-	if !found {
-		// New conversation – look it up in the global map.
-		m.state.ChatsMu.RLock()
-		var name string
-		var isGroup bool
-		if chat, ok := m.state.ChatsMap[key]; ok {
-			name = chat.Name
-			isGroup = chat.IsGroup
-		} else {
-			name = evt.ChatJID.User
-			isGroup = evt.ChatJID.Server == types.GroupServer
+// upsertChat replaces a chat list entry with a newer snapshot, or adds it.
+func (m Model) upsertChat(chat apptypes.ChatItem) Model {
+	for i := range m.chats {
+		if m.chats[i].JID == chat.JID {
+			m.chats[i] = chat
+			return m
 		}
-		m.state.ChatsMu.RUnlock()
+	}
+	m.chats = append(m.chats, chat)
+	return m
+}
 
-		newChat := apptypes.ChatItem{
-			JID:      evt.ChatJID,
-			Name:     name,
-			LastMsg:  evt.Message.Content,
-			LastTime: evt.Message.Timestamp,
-			IsGroup:  isGroup,
-		}
-		if !evt.Message.FromMe {
-			newChat.Unread = 1
+// appendMessage appends msg unless a message with its ID is already there,
+// e.g. one copied from the global map just after subscribing.
+func appendMessage(msgs []apptypes.Message, msg apptypes.Message) []apptypes.Message {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].ID == msg.ID {
+			msgs[i] = msg
+			return msgs
 		}
-		m.chats = append(m.chats, newChat)
 	}
+	return append(msgs, msg)
+}
 
-	// Auto-scroll to bottom if this chat is open.
-	if m.selectedChat >= 0 && m.selectedChat < len(m.chats) &&
-		m.chats[m.selectedChat].JID.String() == key {
-		m.msgScroll = -1
+// publishFocus tells the event handlers which chat is on screen so they skip
+// notifications for it. Nothing is on screen while the terminal is unfocused.
+func (m Model) publishFocus() {
+	key := ""
+	if !m.blurred && m.selectedChat >= 0 && m.selectedChat < len(m.chats) {
+		key = m.chats[m.selectedChat].JID.String()
 	}
+	client.SetFocus(m.state, key)
+}
+
+// ── Drafts ────────────────────────────────────────────────────────────────────
 
+// syncDraft swaps the input buffer when the selected chat changed: the old
+// chat's draft is stashed and persisted, the new chat's draft is restored.
+func (m Model) syncDraft() Model {
+	key := ""
+	if m.selectedChat >= 0 && m.selectedChat < len(m.chats) {
+		key = m.chats[m.selectedChat].JID.String()
+	}
+	if key == m.draftChat {
+		return m
+	}
+	m.SaveDraft()
+	m.draftChat = key
+	m.inputText = m.drafts[key]
+	m.inputCursor = utf8.RuneCountInString(m.inputText)
+	m.draftMentions = m.mentionDrafts[key]
+	if m.draftMentions == nil {
+		m.draftMentions = make(map[string]types.JID)
+	}
+	m.compl = nil
 	return m
 }
 
-// ── Key handling ──────────────────────────────────────────────────────────────
+// SaveDraft stashes the input buffer as the draft of its chat and persists
+// it. It is also called on shutdown so drafts survive restarts.
+func (m Model) SaveDraft() {
+	if m.draftChat == "" || m.drafts[m.draftChat] == m.inputText {
+		return
+	}
+	if m.inputText == "" {
+		delete(m.drafts, m.draftChat)
+		delete(m.mentionDrafts, m.draftChat)
+	} else {
+		m.drafts[m.draftChat] = m.inputText
+		m.mentionDrafts[m.draftChat] = m.draftMentions
+	}
+	m.state.DB.SaveDraft(m.draftChat, m.inputText)
+}
 
-// rebuildFromGlobal replaces the model's chats and messages with the current
-// contents of the global maps (called after a HistorySync event).
-func (m Model) rebuildFromGlobal() Model {
-	m.state.ChatsMu.RLock()
-	newChats := make([]apptypes.ChatItem, 0, len(m.state.ChatsMap))
-	for _, c := range m.state.ChatsMap {
-		newChats = append(newChats, *c)
+// draftFor returns the unsent text for a chat, including the live buffer.
+func (m Model) draftFor(key string) string {
+	if key == m.draftChat {
+		return m.inputText
 	}
-	m.state.ChatsMu.RUnlock()
+	return m.drafts[key]
+}
 
-	// Sort by last message time descending (most recent first), then name.
-	sort.Slice(newChats, func(i, j int) bool {
-		if !newChats[i].LastTime.Equal(newChats[j].LastTime) {
-			return newChats[i].LastTime.After(newChats[j].LastTime)
-		}
-		return newChats[i].Name < newChats[j].Name
-	})
+// ── Key handling ──────────────────────────────────────────────────────────────
 
-	// Keep selected chat pointed at same JID if possible.
+// sortChats sorts the chat list (pinned first, archived last, then by last
+// message time) and keeps the selection on the same chat.
+func (m Model) sortChats() Model {
 	var selectedJID string
 	if m.selectedChat >= 0 && m.selectedChat < len(m.chats) {
 		selectedJID = m.chats[m.selectedChat].JID.String()
 	}
-	newSelected := 0
-	for i, c := range newChats {
+	client.SortChats(m.chats)
+	for i, c := range m.chats {
 		if c.JID.String() == selectedJID {
-			newSelected = i
+			m.selectedChat = i
 			break
 		}
 	}
-
-	m.chats = newChats
-	m.selectedChat = newSelected
-	m.chatScroll = 0
-	m = m.rebuildMessages()
-	return m
+	return m.fixChatSelection()
 }
 
-// rebuildMessages copies the global message map into the model's local copy.
-func (m Model) rebuildMessages() Model {
-	m.state.MessagesMu.RLock()
-	for k, v := range m.state.MessagesMap {
-		cp := make([]apptypes.Message, len(v))
-		copy(cp, v)
-		m.messages[k] = cp
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var next tea.Model = m
+	var cmd tea.Cmd
+	switch {
+	case m.rules != nil:
+		// The rules editor takes all keys while open.
+		next, cmd = m.keyRules(msg)
+	case m.schedule != nil:
+		// So do the scheduled messages and the picker.
+		next, cmd = m.keySchedule(msg)
+	case m.search != nil:
+		next, cmd = m.keySearch(msg)
+	case m.picker != nil:
+		next, cmd = m.keyPicker(msg)
+	case m.focus == focusChatList:
+		next, cmd = m.keyChatList(msg)
+	case m.focus == focusMessages:
+		next, cmd = m.keyMessages(msg)
+	case m.focus == focusInput:
+		next, cmd = m.keyInput(msg)
 	}
-	m.state.MessagesMu.RUnlock()
-	return m
+	nm, ok := next.(Model)
+	if !ok {
+		return next, cmd
+	}
+	// The input buffer follows the selected chat.
+	nm = nm.syncDraft()
+	nm.publishFocus()
+	// The unread divider belongs to the chat it was opened in.
+	if c, ok := nm.selectedItem(); !ok || c.JID.String() != nm.unreadChat {
+		nm.unreadChat, nm.unreadID = "", ""
+	}
+	// Keep the info panel in sync with the selected chat.
+	if nm.showInfo {
+		if load := nm.loadChatInfo(); load != nil {
+			return nm, tea.Batch(cmd, load)
+		}
+	}
+	return nm, cmd
 }
 
-func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch m.focus {
-	case focusChatList:
-		return m.keyChatList(msg)
-	case focusMessages:
-		return m.keyMessages(msg)
-	case focusInput:
-		return m.keyInput(msg)
+// loadChatInfo fetches info panel data for the selected chat unless it is
+// already cached or being fetched.
+func (m Model) loadChatInfo() tea.Cmd {
+	if m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return nil
+	}
+	jid := m.chats[m.selectedChat].JID
+	key := jid.String()
+	if m.chatInfo[key] != nil || m.infoLoading[key] {
+		return nil
+	}
+	m.infoLoading[key] = true
+	s := m.state
+	return func() tea.Msg {
+		info, err := client.GetChatInfo(s, jid)
+		return tuiChatInfo{chatJID: key, info: info, err: err}
 	}
-	return m, nil
 }
 
 func (m Model) keyChatList(k tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -293,31 +487,64 @@ func (m Model) keyChatList(k tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 
 	case "j", "down":
-		if m.selectedChat < len(m.chats)-1 {
+		if m.selectedChat < m.lastVisibleChat() {
 			m.selectedChat++
 			m.msgScroll = -1
-			vis := m.visibleChatRows()
-			if m.selectedChat >= m.chatScroll+vis {
-				m.chatScroll = m.selectedChat - vis + 1
-			}
+			m.selMsgID = ""
+			m = m.scrollChatIntoView()
 		}
 
 	case "k", "up":
 		if m.selectedChat > 0 {
 			m.selectedChat--
 			m.msgScroll = -1
-			if m.selectedChat < m.chatScroll {
-				m.chatScroll = m.selectedChat
-			}
+			m.selMsgID = ""
+			m = m.scrollChatIntoView()
 		}
 
 	case "enter":
 		if len(m.chats) > 0 {
 			m.focus = focusInput
-			m.chats[m.selectedChat].Unread = 0
 			key := m.chats[m.selectedChat].JID.String()
 			m.msgScroll = -1
-			return m, m.loadChatMsgs(key)
+			m = m.rememberUnread()
+			m.chats[m.selectedChat].Unread = 0
+			return m, tea.Batch(m.loadChatMsgs(key), m.markRead())
+		}
+
+	case " ": // mark / unmark a contact
+		if m.selectedChat >= 0 && m.selectedChat < len(m.chats) && !m.chats[m.selectedChat].IsGroup {
+			key := m.chats[m.selectedChat].JID.String()
+			if m.marked[key] {
+				delete(m.marked, key)
+			} else {
+				m.marked[key] = true
+			}
+		}
+
+	case "I":
+		m.showInfo = !m.showInfo
+
+	case "A": // show / hide archived chats
+		m.showArchived = !m.showArchived
+		m = m.fixChatSelection()
+
+	case "p":
+		if c, ok := m.selectedItem(); ok {
+			return m, m.setPinned(!c.Pinned)
+		}
+
+	case "a":
+		if c, ok := m.selectedItem(); ok {
+			return m, m.setArchived(!c.Archived)
+		}
+
+	case "m":
+		if c, ok := m.selectedItem(); ok {
+			if c.Muted() {
+				return m, m.setMuted(0)
+			}
+			return m, m.setMuted(defaultMute)
 		}
 
 	case "tab":
@@ -328,17 +555,105 @@ func (m Model) keyChatList(k tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// selectedItem returns the selected chat.
+func (m Model) selectedItem() (apptypes.ChatItem, bool) {
+	if m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return apptypes.ChatItem{}, false
+	}
+	return m.chats[m.selectedChat], true
+}
+
+// ── Chat list rows ────────────────────────────────────────────────────────────
+//
+// Chats are sorted with archived ones last. The list shows the unarchived
+// chats, then an "Archived" row and, when expanded with A, the archived chats.
+
+// archivedStart returns the index of the first archived chat.
+func (m Model) archivedStart() int {
+	for i, c := range m.chats {
+		if c.Archived {
+			return i
+		}
+	}
+	return len(m.chats)
+}
+
+// lastVisibleChat returns the index of the last chat shown in the list.
+func (m Model) lastVisibleChat() int {
+	if m.showArchived {
+		return len(m.chats) - 1
+	}
+	return m.archivedStart() - 1
+}
+
+// chatRowCount returns the number of rows in the chat list.
+func (m Model) chatRowCount() int {
+	n := m.archivedStart()
+	if n == len(m.chats) {
+		return n
+	}
+	return m.lastVisibleChat() + 2 // + the "Archived" row
+}
+
+// chatRow maps a chat index to its row in the list.
+func (m Model) chatRow(i int) int {
+	if i >= m.archivedStart() {
+		return i + 1
+	}
+	return i
+}
+
+// fixChatSelection moves the selection off hidden archived chats and keeps
+// it in view. With only archived chats, they are shown.
+func (m Model) fixChatSelection() Model {
+	if m.archivedStart() == 0 && len(m.chats) > 0 {
+		m.showArchived = true
+	}
+	if last := m.lastVisibleChat(); m.selectedChat > last {
+		m.selectedChat = max(0, last)
+		m.msgScroll = -1
+	}
+	return m.scrollChatIntoView()
+}
+
+// scrollChatIntoView adjusts chatScroll so the selected chat is visible.
+func (m Model) scrollChatIntoView() Model {
+	vis := m.visibleChatRows()
+	row := m.chatRow(m.selectedChat)
+	if row >= m.chatScroll+vis {
+		m.chatScroll = row - vis + 1
+	}
+	if row < m.chatScroll {
+		m.chatScroll = row
+	}
+	m.chatScroll = max(0, min(m.chatScroll, m.chatRowCount()-vis))
+	return m
+}
+
 func (m Model) keyMessages(k tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch k.String() {
 	case "ctrl+c":
 		return m, tea.Quit
 
 	case "q", "esc":
+		if m.selMsgID != "" {
+			m.selMsgID = ""
+			break
+		}
 		m.focus = focusChatList
 
 	case "tab", "i":
 		m.focus = focusInput
 
+	case "J":
+		m = m.moveMsgSel(1)
+
+	case "K":
+		m = m.moveMsgSel(-1)
+
+	case "r":
+		return m.openReactionPicker()
+
 	case "j", "down":
 		if m.selectedChat >= 0 && m.selectedChat < len(m.chats) {
 			mx := m.maxMsgScroll(m.chats[m.selectedChat].JID.String())
@@ -357,11 +672,46 @@ func (m Model) keyMessages(k tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case "G":
 		m.msgScroll = -1
+
+	case "u":
+		return m.jumpToUnread()
+
+	case "I":
+		m.showInfo = !m.showInfo
 	}
 	return m, nil
 }
 
 func (m Model) keyInput(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Ctrl+X Ctrl+E opens the draft in $EDITOR.
+	if m.ctrlX {
+		m.ctrlX = false
+		if k.String() == "ctrl+e" {
+			return m, m.openEditor(false)
+		}
+	}
+	if k.String() == "ctrl+x" {
+		m.ctrlX = true
+		return m, nil
+	}
+	if k.String() == "ctrl+o" {
+		return m.openPicker(), nil
+	}
+	if m.compl != nil {
+		if nm, ok := m.keyCompletion(k); ok {
+			return nm, nil
+		}
+	}
+	nm, cmd := m.editInput(k)
+	if model, ok := nm.(Model); ok {
+		model, complCmd := model.updateCompletion()
+		return model, tea.Batch(cmd, complCmd)
+	}
+	return nm, cmd
+}
+
+// editInput applies a key press to the input buffer.
+func (m Model) editInput(k tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch k.String() {
 	case "ctrl+c":
 		return m, tea.Quit
@@ -373,27 +723,47 @@ func (m Model) keyInput(k tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.focus = focusChatList
 
 	case "enter":
-		if strings.TrimSpace(m.inputText) == "" ||
-			m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
-			return m, nil
+		if strings.HasPrefix(m.inputText, "//") {
+			// "//text" sends "/text".
+			m.inputText = m.inputText[1:]
+			m.inputCursor = max(m.inputCursor-1, 0)
+			return m.sendDraft()
 		}
-		text := m.inputText
-		jid := m.chats[m.selectedChat].JID
-		s := m.state
-		m.inputText = ""
-		m.inputCursor = 0
-		return m, func() tea.Msg {
-			if err := client.SendMessage(s, jid, text); err != nil {
-				return tuiError{err}
-			}
-			return tuiStatus("Sent ✓")
+		if strings.HasPrefix(m.inputText, "/") {
+			line := m.inputText
+			m.inputText = ""
+			m.inputCursor = 0
+			return m.runCommand(line)
+		}
+		return m.sendDraft()
+
+	case "alt+enter", "ctrl+j": // newline (many terminals send Ctrl+J for Shift+Enter)
+		r := []rune(m.inputText)
+		nr := make([]rune, 0, len(r)+1)
+		nr = append(nr, r[:m.inputCursor]...)
+		nr = append(nr, '\n')
+		nr = append(nr, r[m.inputCursor:]...)
+		m.inputText = string(nr)
+		m.inputCursor++
+
+	case "up", "down":
+		rows := m.inputRows(m.inputTextW(m.width))
+		cur := cursorRow(rows, m.inputCursor)
+		next := cur - 1
+		if k.String() == "down" {
+			next = cur + 1
+		}
+		if next >= 0 && next < len(rows) {
+			col := m.inputCursor - rows[cur].start
+			m.inputCursor = rows[next].start + min(col, rows[next].end-rows[next].start)
 		}
 
 	case "backspace", "ctrl+h":
 		if m.inputCursor > 0 {
 			r := []rune(m.inputText)
-			m.inputText = string(r[:m.inputCursor-1]) + string(r[m.inputCursor:])
-			m.inputCursor--
+			prev := prevCluster(m.inputText, m.inputCursor)
+			m.inputText = string(r[:prev]) + string(r[m.inputCursor:])
+			m.inputCursor = prev
 		}
 
 	case "ctrl+w": // delete word backwards
@@ -412,12 +782,12 @@ func (m Model) keyInput(k tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case "left":
 		if m.inputCursor > 0 {
-			m.inputCursor--
+			m.inputCursor = prevCluster(m.inputText, m.inputCursor)
 		}
 
 	case "right":
 		if m.inputCursor < utf8.RuneCountInString(m.inputText) {
-			m.inputCursor++
+			m.inputCursor = nextCluster(m.inputText, m.inputCursor)
 		}
 
 	case "ctrl+a":
@@ -446,14 +816,41 @@ func (m Model) keyInput(k tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	default:
 		if k.Type == tea.KeyRunes {
+			runes := k.Runes
+			if k.Paste {
+				// Bracketed paste: keep multi-line text intact, normalising CRLF.
+				runes = []rune(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(string(runes)))
+			}
 			r := []rune(m.inputText)
-			nr := make([]rune, 0, len(r)+len(k.Runes))
+			nr := make([]rune, 0, len(r)+len(runes))
 			nr = append(nr, r[:m.inputCursor]...)
-			nr = append(nr, k.Runes...)
+			nr = append(nr, runes...)
 			nr = append(nr, r[m.inputCursor:]...)
 			m.inputText = string(nr)
-			m.inputCursor += len(k.Runes)
+			m.inputCursor += len(runes)
 		}
 	}
 	return m, nil
 }
+
+// sendDraft sends the input buffer to the selected chat.
+func (m Model) sendDraft() (Model, tea.Cmd) {
+	if strings.TrimSpace(m.inputText) == "" ||
+		m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return m, nil
+	}
+	text, mentions := m.expandMentions(m.inputText)
+	jid := m.chats[m.selectedChat].JID
+	s := m.state
+	m.inputText = ""
+	m.inputCursor = 0
+	m.draftMentions = make(map[string]types.JID)
+	delete(m.drafts, jid.String())
+	return m, func() tea.Msg {
+		s.DB.SaveDraft(jid.String(), "")
+		if err := client.SendMessage(s, jid, text, mentions...); err != nil {
+			return tuiError{err}
+		}
+		return tuiStatus("Sent ✓")
+	}
+}
@@ -0,0 +1,371 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/client"
+)
+
+// ── Rich message text ─────────────────────────────────────────────────────────
+//
+// Message bodies are parsed into spans (runs of text sharing one style),
+// wrapped as spans so that styled words never get split by ANSI codes, and
+// only then rendered with lipgloss. Parsing runs in passes; code, links and
+// mentions are atomic, so later passes never format inside them.
+
+// spanKind is a bit set of text attributes.
+type spanKind int
+
+const (
+	spanBold spanKind = 1 << iota
+	spanItalic
+	spanStrike
+	spanCode      // `inline code`
+	spanCodeBlock // ```block```
+	spanLink
+	spanMention
+	spanMentionMe
+
+	spanPlain  spanKind = 0
+	spanAtomic          = spanCode | spanCodeBlock | spanLink | spanMention | spanMentionMe
+)
+
+type span struct {
+	text string
+	kind spanKind
+	link string // hyperlink target for spanLink
+}
+
+// cell is one grapheme cluster of a span; wrapping operates on cells so
+// emoji sequences are measured and kept whole.
+type cell struct {
+	g    string
+	w    int // display width
+	kind spanKind
+	link string
+}
+
+var (
+	// mentionRe matches WhatsApp's wire format for mentions: "@" followed by
+	// the user part of the mentioned JID.
+	mentionRe = regexp.MustCompile(`@(\d{5,20})\b`)
+
+	codeBlockRe  = regexp.MustCompile("(?s)```(.+?)```")
+	inlineCodeRe = regexp.MustCompile("`([^`\n]+)`")
+
+	// linkRe matches URLs, e-mail addresses and international phone numbers.
+	linkRe = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+` +
+		`|[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}` +
+		`|\+\d[\d \-]{6,}\d`)
+)
+
+// mentionNameCache caches resolved mention names per user so View() does not
+// hit the contact store on every frame. Bare numbers aren't cached, so a
+// name learnt later still shows up.
+var mentionNameCache sync.Map
+
+// mentionName returns the display name for a mentioned user number, looking at
+// the participants of the open chat first and the contact store after that.
+func (m Model) mentionName(user string) string {
+	if m.selectedChat >= 0 && m.selectedChat < len(m.chats) {
+		if info := m.chatInfo[m.chats[m.selectedChat].JID.String()]; info != nil {
+			for _, p := range info.Participants {
+				if p.JID.User == user {
+					return p.Name
+				}
+			}
+		}
+	}
+	if cached, ok := mentionNameCache.Load(user); ok {
+		return cached.(string)
+	}
+	name := user
+	if m.state.Client != nil {
+		name = client.DisplayName(m.state, types.NewJID(user, types.DefaultUserServer))
+		if name == user {
+			name = client.DisplayName(m.state, types.NewJID(user, types.HiddenUserServer))
+		}
+	}
+	if name != user {
+		mentionNameCache.Store(user, name)
+	}
+	return name
+}
+
+// isMe reports whether a mentioned user number is the logged-in account.
+func (m Model) isMe(user string) bool {
+	return m.state.Client != nil && client.IsSelf(m.state, types.NewJID(user, types.DefaultUserServer))
+}
+
+// parseRich parses a message body into styled spans.
+func (m Model) parseRich(text string) []span {
+	spans := []span{{text: text}}
+	spans = splitSpans(spans, codeBlockRe, func(match []string) span {
+		return span{text: strings.Trim(strings.ReplaceAll(match[1], "\t", "    "), "\n"), kind: spanCodeBlock}
+	})
+	spans = splitSpans(spans, inlineCodeRe, func(match []string) span {
+		return span{text: match[1], kind: spanCode}
+	})
+	spans = splitSpans(spans, linkRe, func(match []string) span {
+		return linkSpan(match[0])
+	})
+	spans = splitSpans(spans, mentionRe, func(match []string) span {
+		kind := spanMention
+		if m.isMe(match[1]) {
+			kind = spanMentionMe
+		}
+		return span{text: "@" + m.mentionName(match[1]), kind: kind}
+	})
+	var out []span
+	for _, sp := range spans {
+		if sp.kind&spanAtomic != 0 {
+			out = append(out, sp)
+			continue
+		}
+		out = append(out, parseEmphasis([]rune(sp.text), sp.kind)...)
+	}
+	return out
+}
+
+// splitSpans replaces every match of re inside non-atomic spans with the span
+// built by conv. Text between matches keeps its original span.
+func splitSpans(spans []span, re *regexp.Regexp, conv func(match []string) span) []span {
+	var out []span
+	for _, sp := range spans {
+		if sp.kind&spanAtomic != 0 {
+			out = append(out, sp)
+			continue
+		}
+		last := 0
+		for _, loc := range re.FindAllStringSubmatchIndex(sp.text, -1) {
+			match := make([]string, len(loc)/2)
+			for i := range match {
+				if loc[2*i] >= 0 {
+					match[i] = sp.text[loc[2*i]:loc[2*i+1]]
+				}
+			}
+			// Links keep trailing punctuation outside the link.
+			end := loc[1]
+			if re == linkRe {
+				trimmed := strings.TrimRight(match[0], ".,;:!?)]'\"")
+				end = loc[0] + len(trimmed)
+				match[0] = trimmed
+			}
+			if loc[0] > last {
+				out = append(out, span{text: sp.text[last:loc[0]], kind: sp.kind})
+			}
+			out = append(out, conv(match))
+			last = end
+		}
+		if last < len(sp.text) {
+			out = append(out, span{text: sp.text[last:], kind: sp.kind})
+		}
+	}
+	return out
+}
+
+// linkSpan builds a hyperlink span with the right target scheme.
+func linkSpan(text string) span {
+	target := text
+	switch {
+	case strings.HasPrefix(text, "+"):
+		target = "tel:" + strings.NewReplacer(" ", "", "-", "").Replace(text)
+	case strings.Contains(text, "@") && !strings.Contains(text, "://"):
+		target = "mailto:" + text
+	case strings.HasPrefix(strings.ToLower(text), "www."):
+		target = "https://" + text
+	}
+	return span{text: text, kind: spanLink, link: target}
+}
+
+// emphasis markers and the attribute they toggle.
+var emphasis = map[rune]spanKind{'*': spanBold, '_': spanItalic, '~': spanStrike}
+
+// parseEmphasis applies WhatsApp's *bold*, _italic_ and ~strike~ markup. A
+// marker opens at a word start and must be closed, on the same line, at a
+// word end; otherwise it is literal text. Markup may nest.
+func parseEmphasis(r []rune, kind spanKind) []span {
+	var out []span
+	var buf []rune
+	flush := func() {
+		if len(buf) > 0 {
+			out = append(out, span{text: string(buf), kind: kind})
+			buf = nil
+		}
+	}
+	for i := 0; i < len(r); i++ {
+		flag, ok := emphasis[r[i]]
+		if ok && canOpen(r, i) {
+			if j := findClose(r, i); j > 0 {
+				flush()
+				out = append(out, parseEmphasis(r[i+1:j], kind|flag)...)
+				i = j
+				continue
+			}
+		}
+		buf = append(buf, r[i])
+	}
+	flush()
+	return out
+}
+
+func canOpen(r []rune, i int) bool {
+	return (i == 0 || isMarkupBoundary(r[i-1])) && i+1 < len(r) && !unicode.IsSpace(r[i+1]) && r[i+1] != r[i]
+}
+
+// findClose returns the index of the marker closing the one at i, or -1.
+func findClose(r []rune, i int) int {
+	for j := i + 2; j < len(r); j++ {
+		if r[j] == '\n' {
+			return -1
+		}
+		if r[j] == r[i] && !unicode.IsSpace(r[j-1]) && (j+1 == len(r) || isMarkupBoundary(r[j+1])) {
+			return j
+		}
+	}
+	return -1
+}
+
+func isMarkupBoundary(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// wrapSpans wraps spans to the given display width. Breaks happen at plain
+// spaces only, so multi-word mentions stay on one line where possible; code
+// blocks are hard-wrapped and keep their whitespace. Embedded newlines start
+// a new line.
+func wrapSpans(spans []span, width int) [][]cell {
+	var paragraphs [][]cell
+	var cur []cell
+	for _, sp := range spans {
+		g := uniseg.NewGraphemes(sp.text)
+		for g.Next() {
+			if g.Str() == "\n" || g.Str() == "\r\n" {
+				paragraphs = append(paragraphs, cur)
+				cur = nil
+				continue
+			}
+			cur = append(cur, cell{g: g.Str(), w: g.Width(), kind: sp.kind, link: sp.link})
+		}
+	}
+	paragraphs = append(paragraphs, cur)
+
+	var out [][]cell
+	for _, p := range paragraphs {
+		out = append(out, wrapCells(p, width)...)
+	}
+	return out
+}
+
+// breakable reports whether a line may be broken after c.
+func (c cell) breakable() bool {
+	return c.g == " " && c.kind&spanAtomic == 0
+}
+
+// wrapCells wraps one paragraph of cells, mirroring wrapLine's behaviour.
+func wrapCells(cells []cell, width int) [][]cell {
+	if width <= 0 || cellsWidth(cells) <= width {
+		return [][]cell{cells}
+	}
+	var out [][]cell
+	for len(cells) > 0 {
+		if cellsWidth(cells) <= width {
+			out = append(out, cells)
+			break
+		}
+		// Find the cut point where display width fits.
+		cut, w := 0, 0
+		for cut < len(cells) && w+cells[cut].w <= width {
+			w += cells[cut].w
+			cut++
+		}
+		if cut == 0 {
+			cut = 1 // always consume at least one cell
+		}
+		// Try to break at a plain space.
+		spaceCut := cut
+		for spaceCut > 0 && !cells[spaceCut-1].breakable() {
+			spaceCut--
+		}
+		if spaceCut > 0 {
+			cut = spaceCut
+		}
+		out = append(out, cells[:cut])
+		cells = cells[cut:]
+		for len(cells) > 0 && cells[0].breakable() {
+			cells = cells[1:]
+		}
+	}
+	return out
+}
+
+func cellsWidth(cells []cell) int {
+	w := 0
+	for _, c := range cells {
+		w += c.w
+	}
+	return w
+}
+
+// renderCells renders one wrapped line inside a message bubble. base is the
+// bubble style; each run of cells is rendered inline on top of it so the
+// bubble background is kept across styled runs. Links become OSC 8
+// hyperlinks, which supporting terminals make clickable.
+func renderCells(line []cell, base lipgloss.Style) string {
+	inline := base.Inline(true)
+	var sb strings.Builder
+	sb.WriteString(inline.Render(" "))
+	for i := 0; i < len(line); {
+		j := i
+		var run strings.Builder
+		for j < len(line) && line[j].kind == line[i].kind && line[j].link == line[i].link {
+			run.WriteString(line[j].g)
+			j++
+		}
+		styled := spanStyle(inline, line[i].kind).Render(run.String())
+		if line[i].link != "" {
+			styled = hyperlink(line[i].link, styled)
+		}
+		sb.WriteString(styled)
+		i = j
+	}
+	sb.WriteString(inline.Render(" "))
+	return sb.String()
+}
+
+// hyperlink wraps text in an OSC 8 hyperlink escape sequence.
+func hyperlink(target, text string) string {
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// spanStyle derives the style for a span kind from the bubble style.
+func spanStyle(base lipgloss.Style, kind spanKind) lipgloss.Style {
+	st := base
+	if kind&spanBold != 0 {
+		st = st.Bold(true)
+	}
+	if kind&spanItalic != 0 {
+		st = st.Italic(true)
+	}
+	if kind&spanStrike != 0 {
+		st = st.Strikethrough(true)
+	}
+	switch {
+	case kind&(spanCode|spanCodeBlock) != 0:
+		st = st.Foreground(clrCode).Background(clrCodeBg)
+	case kind&spanLink != 0:
+		st = st.Foreground(clrLink).Underline(true)
+	case kind&spanMentionMe != 0:
+		st = st.Bold(true).Foreground(lipgloss.Color("#000000")).Background(clrMentionMe)
+	case kind&spanMention != 0:
+		st = st.Bold(true).Foreground(clrGreen)
+	}
+	return st
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// spansString renders spans as "[text/kind]" runs for comparison.
+func spansString(spans []span) string {
+	var sb strings.Builder
+	for _, sp := range spans {
+		fmt.Fprintf(&sb, "[%s/%d]", sp.text, sp.kind)
+	}
+	return sb.String()
+}
+
+func TestParseRich(t *testing.T) {
+	const (
+		b  = spanBold
+		i  = spanItalic
+		s  = spanStrike
+		c  = spanCode
+		cb = spanCodeBlock
+	)
+	sp := func(text string, kind spanKind) span { return span{text: text, kind: kind} }
+	for _, tt := range []struct {
+		in   string
+		want []span
+	}{
+		// Emphasis and nesting.
+		{"*bold*", []span{sp("bold", b)}},
+		{"*_bold italic_*", []span{sp("bold italic", b|i)}},
+		{"*bold _both_ bold*", []span{sp("bold ", b), sp("both", b|i), sp(" bold", b)}},
+		{"~*_all_*~", []span{sp("all", b|i|s)}},
+		{"_a_b_", []span{sp("a_b", i)}},
+		// Crossed markers: the first one can't close, so it stays literal.
+		{"*a _b*c_", []span{sp("*a ", 0), sp("b*c", i)}},
+		// Unclosed or misplaced markers are literal text.
+		{"*open", []span{sp("*open", 0)}},
+		{"open*", []span{sp("open*", 0)}},
+		{"**", []span{sp("**", 0)}},
+		{"* not *", []span{sp("* not *", 0)}},
+		{"a*b*c", []span{sp("a*b*c", 0)}},
+		{"*multi\nline*", []span{sp("*multi\nline*", 0)}},
+		// Code spans are atomic.
+		{"`code *x*`", []span{sp("code *x*", c)}},
+		{"`a` and `b`", []span{sp("a", c), sp(" and ", 0), sp("b", c)}},
+		{"*`code`*", []span{sp("*", 0), sp("code", c), sp("*", 0)}},
+		{"`open", []span{sp("`open", 0)}},
+		{"x `` y", []span{sp("x `` y", 0)}},
+		{"```\n*x*\n\tend\n```", []span{sp("*x*\n    end", cb)}},
+	} {
+		if got, want := spansString(Model{}.parseRich(tt.in)), spansString(tt.want); got != want {
+			t.Errorf("parseRich(%q) = %s, want %s", tt.in, got, want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"DevStarByte/internal/rules"
+)
+
+// ── Rules editor ──────────────────────────────────────────────────────────────
+
+// rulesEditor is the auto-reply rules overlay: a list of rules, or the form
+// for one of them.
+type rulesEditor struct {
+	list    []rules.Rule
+	sel     int
+	confirm bool      // "d" was pressed once; a second press deletes
+	form    *ruleForm // nil while the list is shown
+}
+
+// ruleForm edits one rule as text, one value per ruleFields entry.
+type ruleForm struct {
+	rule   rules.Rule // the rule being edited; ID 0 for a new one
+	values []string
+	field  int
+}
+
+// ruleField is one row of the form.
+type ruleField struct {
+	label  string
+	hint   string
+	toggle bool // yes/no, switched with Space
+	get    func(r rules.Rule) string
+	set    func(r *rules.Rule, v string) error
+}
+
+var ruleFields = []ruleField{
+	{label: "Name", hint: "shown in the list and the log",
+		get: func(r rules.Rule) string { return r.Name },
+		set: func(r *rules.Rule, v string) error { r.Name = v; return nil }},
+	{label: "Enabled", toggle: true,
+		get: func(r rules.Rule) string { return yesNo(r.Enabled) },
+		set: func(r *rules.Rule, v string) (err error) { r.Enabled, err = parseYesNo(v); return }},
+	{label: "Chats", hint: "JIDs or phone numbers, comma-separated; empty for all chats",
+		get: func(r rules.Rule) string { return strings.Join(r.Chats, ", ") },
+		set: func(r *rules.Rule, v string) error { r.Chats = splitList(v); return nil }},
+	{label: "Contacts", hint: "senders as JIDs or phone numbers; empty for everyone",
+		get: func(r rules.Rule) string { return strings.Join(r.Contacts, ", ") },
+		set: func(r *rules.Rule, v string) error { r.Contacts = splitList(v); return nil }},
+	{label: "Days", hint: "e.g. mon, tue, wed, thu, fri; empty for every day",
+		get: func(r rules.Rule) string { return strings.Join(r.Days, ", ") },
+		set: func(r *rules.Rule, v string) error { r.Days = splitList(strings.ToLower(v)); return nil }},
+	{label: "From", hint: "HH:MM; with To, only between these times",
+		get: func(r rules.Rule) string { return r.From },
+		set: func(r *rules.Rule, v string) error { r.From = v; return nil }},
+	{label: "To", hint: "HH:MM; before From to wrap past midnight",
+		get: func(r rules.Rule) string { return r.To },
+		set: func(r *rules.Rule, v string) error { r.To = v; return nil }},
+	{label: "Keyword", hint: "regular expression, e.g. (?i)urgent|asap",
+		get: func(r rules.Rule) string { return r.Keyword },
+		set: func(r *rules.Rule, v string) error { r.Keyword = v; return nil }},
+	{label: "First in hours", hint: "only the chat's first message in this many hours",
+		get: func(r rules.Rule) string {
+			if r.FirstInHours == 0 {
+				return ""
+			}
+			return strconv.Itoa(r.FirstInHours)
+		},
+		set: func(r *rules.Rule, v string) error {
+			if v == "" {
+				r.FirstInHours = 0
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.New("first in hours must be a number")
+			}
+			r.FirstInHours = n
+			return nil
+		}},
+	{label: "Reply", hint: "text to send back",
+		get: func(r rules.Rule) string { return r.Reply },
+		set: func(r *rules.Rule, v string) error { r.Reply = v; return nil }},
+	{label: "React", hint: "emoji to react with",
+		get: func(r rules.Rule) string { return r.React },
+		set: func(r *rules.Rule, v string) error { r.React = v; return nil }},
+	{label: "Forward to", hint: "chat to copy the message to: JID, number or name",
+		get: func(r rules.Rule) string { return r.Forward },
+		set: func(r *rules.Rule, v string) error { r.Forward = v; return nil }},
+	{label: "Mark read", toggle: true,
+		get: func(r rules.Rule) string { return yesNo(r.MarkRead) },
+		set: func(r *rules.Rule, v string) (err error) { r.MarkRead, err = parseYesNo(v); return }},
+	{label: "Dry run", hint: "only log what the rule would do", toggle: true,
+		get: func(r rules.Rule) string { return yesNo(r.DryRun) },
+		set: func(r *rules.Rule, v string) (err error) { r.DryRun, err = parseYesNo(v); return }},
+}
+
+const ruleLabelW = 16
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func parseYesNo(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "yes", "y", "true", "on", "1":
+		return true, nil
+	case "no", "n", "false", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not yes or no", v)
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(v string) []string {
+	var out []string
+	for _, f := range strings.Split(v, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func newRuleForm(r rules.Rule) *ruleForm {
+	f := &ruleForm{rule: r}
+	for _, field := range ruleFields {
+		f.values = append(f.values, field.get(r))
+	}
+	return f
+}
+
+// parse returns the rule as edited in the form.
+func (f *ruleForm) parse() (rules.Rule, error) {
+	r := f.rule
+	for i, field := range ruleFields {
+		if err := field.set(&r, strings.TrimSpace(f.values[i])); err != nil {
+			return r, err
+		}
+	}
+	return r, r.Validate()
+}
+
+// openRules opens the rules editor.
+func (m Model) openRules() (Model, tea.Cmd) {
+	if m.state.Rules == nil {
+		return m, statusCmd("Rules need messages.db")
+	}
+	m.rules = &rulesEditor{list: m.state.Rules.Rules()}
+	m.picker, m.compl = nil, nil
+	return m, nil
+}
+
+// keyRules handles all keys while the rules editor is open.
+func (m Model) keyRules(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if k.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if m.rules.form != nil {
+		return m.keyRuleForm(k)
+	}
+	e := m.rules
+	engine := m.state.Rules
+	confirm := e.confirm
+	e.confirm = false
+	switch k.String() {
+	case "esc", "q":
+		m.rules = nil
+	case "up", "k":
+		e.sel = max(0, e.sel-1)
+	case "down", "j":
+		e.sel = min(len(e.list)-1, e.sel+1)
+	case "n":
+		e.form = newRuleForm(rules.Rule{Enabled: true})
+	case "enter", "e":
+		if e.sel < len(e.list) {
+			e.form = newRuleForm(e.list[e.sel])
+		}
+	case " ":
+		if e.sel < len(e.list) {
+			r := e.list[e.sel]
+			r.Enabled = !r.Enabled
+			if _, err := engine.Save(r); err != nil {
+				return m, func() tea.Msg { return tuiError{err} }
+			}
+			e.list = engine.Rules()
+		}
+	case "d":
+		if e.sel >= len(e.list) {
+			break
+		}
+		name := e.list[e.sel].Name
+		if !confirm {
+			e.confirm = true
+			return m, statusCmd("Press d again to delete " + name)
+		}
+		engine.Delete(e.list[e.sel].ID)
+		e.list = engine.Rules()
+		e.sel = max(0, min(e.sel, len(e.list)-1))
+		return m, statusCmd("Deleted rule " + name)
+	}
+	return m, nil
+}
+
+// keyRuleForm handles keys while a rule is edited.
+func (m Model) keyRuleForm(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	e := m.rules
+	f := e.form
+	field := ruleFields[f.field]
+	switch k.String() {
+	case "esc":
+		e.form = nil
+	case "up", "shift+tab":
+		f.field = (f.field + len(ruleFields) - 1) % len(ruleFields)
+	case "down", "tab":
+		f.field = (f.field + 1) % len(ruleFields)
+	case "backspace", "ctrl+h":
+		if r := []rune(f.values[f.field]); len(r) > 0 {
+			f.values[f.field] = string(r[:len(r)-1])
+		}
+	case "ctrl+u":
+		f.values[f.field] = ""
+	case "enter":
+		r, err := f.parse()
+		if err == nil {
+			r, err = m.state.Rules.Save(r)
+		}
+		if err != nil {
+			return m, func() tea.Msg { return tuiError{err} }
+		}
+		e.form = nil
+		e.list = m.state.Rules.Rules()
+		for i, saved := range e.list {
+			if saved.ID == r.ID {
+				e.sel = i
+			}
+		}
+		return m, statusCmd("Saved rule " + r.Name)
+	case " ":
+		if field.toggle {
+			on, _ := parseYesNo(f.values[f.field])
+			f.values[f.field] = yesNo(!on)
+			break
+		}
+		f.values[f.field] += " "
+	default:
+		if k.Type == tea.KeyRunes && !field.toggle {
+			f.values[f.field] += string(k.Runes)
+		}
+	}
+	return m, nil
+}
+
+// renderRules renders the editor in place of the message panel, w×h.
+func (m Model) renderRules(w, h int) string {
+	e := m.rules
+	divider := sDivider.Render(strings.Repeat("─", w))
+	var lines []string
+	if f := e.form; f != nil {
+		title := "Edit rule"
+		if f.rule.ID == 0 {
+			title = "New rule"
+		}
+		lines = append(lines, sAccent.Bold(true).Render(title), divider)
+		for i, field := range ruleFields {
+			label := fmt.Sprintf("%-*s", ruleLabelW, field.label)
+			value := f.values[i]
+			if i == f.field {
+				lines = append(lines, sChatSel.Padding(0).Render(label)+" "+value+"▏")
+			} else {
+				lines = append(lines, sTime.Render(label)+" "+value)
+			}
+		}
+		lines = append(lines, "", sMuted.Render(ruleFields[f.field].hint))
+		lines = fitLines(lines, h-1)
+		lines = append(lines, sMuted.Render("Enter: save  ↑↓/Tab: field  Space: toggle  Esc: cancel"))
+		return clampContent(strings.Join(lines, "\n"), w)
+	}
+
+	title := sAccent.Bold(true).Render("Auto-reply rules")
+	if m.state.Config != nil && m.state.Config.RulesDryRun {
+		title += sMuted.Render("  (dry run)")
+	}
+	lines = append(lines, title, divider)
+	if len(e.list) == 0 {
+		lines = append(lines, sMuted.Render("No rules yet. Press n to add one."))
+	}
+	// Scroll so the selected rule stays above the key help.
+	first := max(0, e.sel-(h-3)+1)
+	for i, r := range e.list {
+		if i < first {
+			continue
+		}
+		mark := sMuted.Render("○ ")
+		if r.Enabled {
+			mark = sUnread.Render("● ")
+		}
+		name := r.Name
+		if r.DryRun {
+			name += " (dry run)"
+		}
+		row := truncateStr(name, w-2) + sMuted.Render("  "+r.Actions())
+		if i == e.sel {
+			row = sChatSel.Padding(0).Render(truncateStr(name, w-2)) + sMuted.Render("  "+r.Actions())
+		}
+		lines = append(lines, mark+row)
+	}
+	lines = fitLines(lines, h-1)
+	lines = append(lines, sMuted.Render("n: new  Enter: edit  Space: on/off  d: delete  Esc: close"))
+	return clampContent(strings.Join(lines, "\n"), w)
+}
+
+// fitLines pads or cuts lines to exactly h.
+func fitLines(lines []string, h int) []string {
+	h = max(h, 0)
+	if len(lines) > h {
+		return lines[:h]
+	}
+	for len(lines) < h {
+		lines = append(lines, "")
+	}
+	return lines
+}
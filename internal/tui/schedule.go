@@ -0,0 +1,285 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/client"
+	"DevStarByte/internal/db"
+)
+
+// ── Scheduled messages ────────────────────────────────────────────────────────
+
+var errScheduleUsage = errors.New("Usage: /schedule <YYYY-MM-DD HH:MM|HH:MM|tomorrow HH:MM|+1h30m> <text>")
+
+// scheduleEditor lists the open chat's scheduled messages, or edits one.
+type scheduleEditor struct {
+	chat    types.JID
+	name    string
+	sel     int
+	confirm bool          // "d" was pressed once; a second press cancels
+	form    *scheduleForm // nil while the list is shown
+}
+
+// scheduleForm edits the time and text of one scheduled message.
+type scheduleForm struct {
+	id     int64
+	values [2]string // when, text
+	field  int
+}
+
+var scheduleLabels = [2]string{"When", "Text"}
+
+const scheduleTimeFormat = "2006-01-02 15:04"
+
+// parseWhen parses a send time: "2006-01-02 15:04", "15:04" (the next time
+// it comes), "tomorrow 15:04" or "+1h30m" from now.
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	if d, ok := strings.CutPrefix(s, "+"); ok {
+		dur, err := time.ParseDuration(d)
+		if err != nil || dur <= 0 {
+			return time.Time{}, fmt.Errorf("invalid duration %q", d)
+		}
+		return now.Add(dur), nil
+	}
+	day := now
+	clock, tomorrow := strings.CutPrefix(s, "tomorrow ")
+	if tomorrow {
+		day = now.AddDate(0, 0, 1)
+	}
+	if t, err := time.ParseInLocation(scheduleTimeFormat, s, now.Location()); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("15:04", clock, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	at := time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !tomorrow && !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
+
+// parseSchedule splits "/schedule" arguments into the send time and the
+// text.
+func parseSchedule(args string, now time.Time) (time.Time, string, error) {
+	fields := strings.Fields(args)
+	for n := min(2, len(fields)-1); n >= 1; n-- {
+		at, err := parseWhen(strings.Join(fields[:n], " "), now)
+		if err != nil {
+			continue
+		}
+		text := args
+		for _, f := range fields[:n] {
+			text = strings.TrimPrefix(strings.TrimSpace(text), f)
+		}
+		if !at.After(now) {
+			return at, "", fmt.Errorf("%s is in the past", at.Format(scheduleTimeFormat))
+		}
+		return at, strings.TrimSpace(text), nil
+	}
+	return time.Time{}, "", errScheduleUsage
+}
+
+// cmdSchedule implements "/schedule <when> <text>" for the open chat.
+func (m Model) cmdSchedule(args string) (Model, tea.Cmd) {
+	jid, name, ok := m.selectedJID()
+	if !ok {
+		return m, statusCmd("Open a chat first")
+	}
+	at, text, err := parseSchedule(args, time.Now())
+	if err != nil {
+		return m, statusCmd(err.Error())
+	}
+	if _, err := m.state.Scheduler.Add(jid, text, at); err != nil {
+		return m, func() tea.Msg { return tuiError{err} }
+	}
+	client.ScheduleChanged(m.state)
+	m.msgScroll = -1
+	return m, statusCmd(fmt.Sprintf("Scheduled for %s in %s", formatSendAt(at, time.Now()), name))
+}
+
+// formatSendAt shows t relative to now where that is shorter.
+func formatSendAt(t, now time.Time) string {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	switch {
+	case y1 == y2 && m1 == m2 && d1 == d2:
+		return "today " + t.Format("15:04")
+	case t.After(now) && t.Before(now.AddDate(0, 0, 6)):
+		return t.Format("Mon 15:04")
+	case y1 == y2:
+		return t.Format("Mon Jan 2 15:04")
+	}
+	return t.Format("Jan 2, 2006 15:04")
+}
+
+// openSchedule opens the list of the open chat's scheduled messages.
+func (m Model) openSchedule() (Model, tea.Cmd) {
+	jid, name, ok := m.selectedJID()
+	if !ok {
+		return m, statusCmd("Open a chat first")
+	}
+	if m.state.Scheduler == nil {
+		return m, statusCmd("Scheduling needs messages.db")
+	}
+	m.schedule = &scheduleEditor{chat: jid, name: name}
+	m.picker, m.compl = nil, nil
+	return m, nil
+}
+
+// keySchedule handles all keys while the scheduled messages are shown.
+func (m Model) keySchedule(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if k.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if m.schedule.form != nil {
+		return m.keyScheduleForm(k)
+	}
+	e := m.schedule
+	list := m.state.Scheduler.Pending(e.chat)
+	e.sel = max(0, min(e.sel, len(list)-1))
+	confirm := e.confirm
+	e.confirm = false
+	switch k.String() {
+	case "esc", "q":
+		m.schedule = nil
+	case "up", "k":
+		e.sel = max(0, e.sel-1)
+	case "down", "j":
+		e.sel = max(0, min(len(list)-1, e.sel+1))
+	case "enter", "e":
+		if e.sel < len(list) {
+			sm := list[e.sel]
+			e.form = &scheduleForm{id: sm.ID, values: [2]string{sm.SendAt.Format(scheduleTimeFormat), sm.Text}}
+		}
+	case "d":
+		if e.sel >= len(list) {
+			break
+		}
+		if !confirm {
+			e.confirm = true
+			return m, statusCmd("Press d again to cancel this message")
+		}
+		if err := m.state.Scheduler.Cancel(list[e.sel].ID); err != nil {
+			return m, func() tea.Msg { return tuiError{err} }
+		}
+		client.ScheduleChanged(m.state)
+		return m, statusCmd("Scheduled message cancelled")
+	}
+	return m, nil
+}
+
+// keyScheduleForm handles keys while a scheduled message is edited.
+func (m Model) keyScheduleForm(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := m.schedule.form
+	switch k.String() {
+	case "esc":
+		m.schedule.form = nil
+	case "up", "down", "tab", "shift+tab":
+		f.field = 1 - f.field
+	case "backspace", "ctrl+h":
+		if r := []rune(f.values[f.field]); len(r) > 0 {
+			f.values[f.field] = string(r[:len(r)-1])
+		}
+	case "ctrl+u":
+		f.values[f.field] = ""
+	case "enter":
+		now := time.Now()
+		at, err := parseWhen(f.values[0], now)
+		if err == nil && !at.After(now) {
+			err = fmt.Errorf("%s is in the past", at.Format(scheduleTimeFormat))
+		}
+		if err == nil {
+			err = m.state.Scheduler.Update(f.id, strings.TrimSpace(f.values[1]), at)
+		}
+		if err != nil {
+			return m, func() tea.Msg { return tuiError{err} }
+		}
+		client.ScheduleChanged(m.state)
+		m.schedule.form = nil
+		return m, statusCmd("Scheduled for " + formatSendAt(at, now))
+	case " ":
+		f.values[f.field] += " "
+	default:
+		if k.Type == tea.KeyRunes {
+			f.values[f.field] += string(k.Runes)
+		}
+	}
+	return m, nil
+}
+
+// renderSchedule renders the list or the form in place of the message
+// panel, w×h.
+func (m Model) renderSchedule(w, h int) string {
+	e := m.schedule
+	divider := sDivider.Render(strings.Repeat("─", w))
+	var lines []string
+	if f := e.form; f != nil {
+		lines = append(lines, sAccent.Bold(true).Render("Edit scheduled message"), divider)
+		for i, label := range scheduleLabels {
+			label := fmt.Sprintf("%-6s", label)
+			if i == f.field {
+				lines = append(lines, sChatSel.Padding(0).Render(label)+" "+f.values[i]+"▏")
+			} else {
+				lines = append(lines, sTime.Render(label)+" "+f.values[i])
+			}
+		}
+		lines = append(lines, "", sMuted.Render("YYYY-MM-DD HH:MM, HH:MM, tomorrow HH:MM or +1h30m"))
+		lines = fitLines(lines, h-1)
+		lines = append(lines, sMuted.Render("Enter: save  ↑↓/Tab: field  Esc: back"))
+		return clampContent(strings.Join(lines, "\n"), w)
+	}
+
+	lines = append(lines, sAccent.Bold(true).Render("Scheduled in "+e.name), divider)
+	list := m.state.Scheduler.Pending(e.chat)
+	if len(list) == 0 {
+		lines = append(lines, sMuted.Render("Nothing scheduled. Type /schedule <when> <text> to add a message."))
+	}
+	sel := max(0, min(e.sel, len(list)-1))
+	now := time.Now()
+	// Scroll so the selected message stays above the key help.
+	first := max(0, sel-(h-3)+1)
+	for i, sm := range list {
+		if i < first {
+			continue
+		}
+		when := fmt.Sprintf("%-20s", formatSendAt(sm.SendAt, now))
+		if i == sel {
+			when = sChatSel.Padding(0).Render(when)
+		} else {
+			when = sTime.Render(when)
+		}
+		text := strings.Join(strings.Fields(sm.Text), " ")
+		if sm.Status == db.ScheduleFailed {
+			text = "⚠ " + text
+		}
+		lines = append(lines, when+" "+truncateStr(text, max(1, w-21)))
+	}
+	lines = fitLines(lines, h-1)
+	lines = append(lines, sMuted.Render("Enter: edit  d: cancel message  Esc: close"))
+	return clampContent(strings.Join(lines, "\n"), w)
+}
+
+// formatScheduled renders a pending message at the end of its chat, like a
+// sent one but dimmed.
+func formatScheduled(sm db.ScheduledMessage, w int, now time.Time) []string {
+	meta := sTime.Render("⏰ " + formatSendAt(sm.SendAt, now))
+	if sm.Status == db.ScheduleFailed {
+		meta = sUnread.Render("⚠ Not sent: " + truncateStr(sm.Error, max(1, w/2)))
+	}
+	lines := []string{strings.Repeat(" ", max(0, w-lipgloss.Width(meta)-1)) + meta}
+	for _, l := range wordWrap(sm.Text, w-6) {
+		styled := sMuted.Render(l)
+		lines = append(lines, strings.Repeat(" ", max(0, w-lipgloss.Width(styled)-1))+styled)
+	}
+	return lines
+}
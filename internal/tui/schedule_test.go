@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// Monday afternoon.
+	now := time.Date(2026, 10, 19, 14, 30, 0, 0, time.Local)
+	at := func(d, h, m int) time.Time { return time.Date(2026, 10, d, h, m, 0, 0, time.Local) }
+	for _, tt := range []struct {
+		args string
+		at   time.Time
+		text string
+	}{
+		{"2026-10-20 09:00 Standup  starts", at(20, 9, 0), "Standup  starts"},
+		{"18:00 Dinner?", at(19, 18, 0), "Dinner?"},
+		{"09:00 tomorrow already", at(20, 9, 0), "tomorrow already"},
+		{"Tomorrow 14:00 Call", at(20, 14, 0), "Call"},
+		{"+1h30m Reminder", at(19, 16, 0), "Reminder"},
+	} {
+		got, text, err := parseSchedule(tt.args, now)
+		if err != nil || !got.Equal(tt.at) || text != tt.text {
+			t.Errorf("parseSchedule(%q) = %v, %q, %v; want %v, %q", tt.args, got, text, err, tt.at, tt.text)
+		}
+	}
+	for _, args := range []string{"", "18:00", "soon hello", "2026-10-19 08:00 too late", "+0s now"} {
+		if _, _, err := parseSchedule(args, now); err == nil {
+			t.Errorf("parseSchedule(%q) succeeded", args)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"DevStarByte/internal/db"
+)
+
+// ── Message search ────────────────────────────────────────────────────────────
+
+// searchLimit is how many matches /search lists.
+const searchLimit = 200
+
+// searchContext is how many earlier messages are loaded with a match, so it
+// can be shown in its chat even if it is older than the loaded history.
+const searchContext = 20
+
+// searchResults lists the messages found by /search.
+type searchResults struct {
+	query   string
+	results []db.SearchResult
+	sel     int
+}
+
+// tuiSearchDone carries the matches of a search back from the store.
+type tuiSearchDone struct {
+	query   string
+	results []db.SearchResult
+	err     error
+}
+
+// cmdSearch implements "/search <text>": it searches every chat in
+// messages.db, imported history included.
+func (m Model) cmdSearch(query string) (Model, tea.Cmd) {
+	if query == "" {
+		return m, statusCmd("Usage: /search <text>")
+	}
+	store := m.state.DB
+	return m, func() tea.Msg {
+		results, err := store.SearchMessages(query, "", searchLimit)
+		return tuiSearchDone{query: query, results: results, err: err}
+	}
+}
+
+// showSearch opens the list of matches.
+func (m Model) showSearch(msg tuiSearchDone) (Model, tea.Cmd) {
+	switch {
+	case msg.err != nil:
+		return m, func() tea.Msg { return tuiError{msg.err} }
+	case len(msg.results) == 0:
+		return m, statusCmd(fmt.Sprintf("No messages contain %q", msg.query))
+	}
+	m.search = &searchResults{query: msg.query, results: msg.results}
+	m.picker, m.compl = nil, nil
+	return m, nil
+}
+
+// keySearch handles all keys while the matches are shown.
+func (m Model) keySearch(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := m.search
+	switch k.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.search = nil
+	case "up", "k":
+		r.sel = max(0, r.sel-1)
+	case "down", "j":
+		r.sel = min(len(r.results)-1, r.sel+1)
+	case "enter":
+		return m.openSearchResult(r.results[r.sel])
+	}
+	return m, nil
+}
+
+// openSearchResult opens the chat of a match and selects the message.
+func (m Model) openSearchResult(res db.SearchResult) (Model, tea.Cmd) {
+	i := m.chatIndex(res.Chat)
+	if i < 0 {
+		return m, statusCmd("That chat isn't in the chat list")
+	}
+	m.search = nil
+	if i >= m.archivedStart() {
+		m.showArchived = true
+	}
+	m.selectedChat = i
+	m = m.scrollChatIntoView()
+	m.focus = focusMessages
+	m.msgScroll = -1
+	m.selMsgID = res.Message.ID
+	window := m.state.DB.LoadMessagesBetween(res.Chat, time.Time{}, res.Message.Timestamp.Add(time.Second), searchContext)
+	m.messages[res.Chat] = mergeMessages(m.messages[res.Chat], append(window, res.Message))
+	return m.moveMsgSel(0), nil
+}
+
+// chatIndex returns the index of the chat with jid in m.chats, or -1.
+func (m Model) chatIndex(jid string) int {
+	for i, c := range m.chats {
+		if c.JID.String() == jid {
+			return i
+		}
+	}
+	return -1
+}
+
+// renderSearch renders the matches in place of the message panel, w×h.
+func (m Model) renderSearch(w, h int) string {
+	r := m.search
+	title := fmt.Sprintf("%d messages contain %q", len(r.results), r.query)
+	switch len(r.results) {
+	case 1:
+		title = fmt.Sprintf("1 message contains %q", r.query)
+	case searchLimit:
+		title = fmt.Sprintf("The newest %d messages containing %q", searchLimit, r.query)
+	}
+	lines := []string{sAccent.Bold(true).Render(title), sDivider.Render(strings.Repeat("─", w))}
+	// Scroll so the selected match stays above the key help.
+	first := max(0, r.sel-(h-3)+1)
+	for i, res := range r.results {
+		if i < first {
+			continue
+		}
+		when := res.Message.Timestamp.Local().Format("2006-01-02 15:04")
+		if i == r.sel {
+			when = sChatSel.Padding(0).Render(when)
+		} else {
+			when = sTime.Render(when)
+		}
+		name := res.Chat
+		if c := m.chatIndex(res.Chat); c >= 0 {
+			name = m.chats[c].Name
+		}
+		sender := res.Message.Sender
+		if res.Message.FromMe {
+			sender = "You"
+		}
+		name = truncateStr(name, 20)
+		text := sender + ": " + strings.Join(strings.Fields(res.Message.Content), " ")
+		text = truncateStr(text, max(1, w-17-lipgloss.Width(name)-2))
+		lines = append(lines, when+" "+sAccent.Render(name)+"  "+text)
+	}
+	lines = fitLines(lines, h-1)
+	lines = append(lines, sMuted.Render("Enter: open in chat  ↑↓: select  Esc: close"))
+	return clampContent(strings.Join(lines, "\n"), w)
+}
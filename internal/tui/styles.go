@@ -5,15 +5,19 @@ import "github.com/charmbracelet/lipgloss"
 // ── Colour palette (WhatsApp dark theme) ─────────────────────────────────────
 
 var (
-	clrGreen    = lipgloss.Color("#25D366")
-	clrPanel    = lipgloss.Color("#111B21")
-	clrBorder   = lipgloss.Color("#2A3942")
-	clrText     = lipgloss.Color("#E9EDEF")
-	clrMuted    = lipgloss.Color("#8696A0")
-	clrMyBg     = lipgloss.Color("#005C4B")
-	clrTheirBg  = lipgloss.Color("#202C33")
-	clrHeaderBg = lipgloss.Color("#202C33")
-	clrUnread   = lipgloss.Color("#00A884")
+	clrGreen     = lipgloss.Color("#25D366")
+	clrPanel     = lipgloss.Color("#111B21")
+	clrBorder    = lipgloss.Color("#2A3942")
+	clrText      = lipgloss.Color("#E9EDEF")
+	clrMuted     = lipgloss.Color("#8696A0")
+	clrMyBg      = lipgloss.Color("#005C4B")
+	clrTheirBg   = lipgloss.Color("#202C33")
+	clrHeaderBg  = lipgloss.Color("#202C33")
+	clrUnread    = lipgloss.Color("#00A884")
+	clrMentionMe = lipgloss.Color("#FFD279")
+	clrCode      = lipgloss.Color("#E2C08D")
+	clrCodeBg    = lipgloss.Color("#0B141A")
+	clrLink      = lipgloss.Color("#53BDEB")
 )
 
 // ── Lipgloss styles ───────────────────────────────────────────────────────────
@@ -83,7 +87,14 @@ var (
 	sDivider = lipgloss.NewStyle().
 			Foreground(clrBorder)
 
+	sDraft = lipgloss.NewStyle().
+		Foreground(clrMentionMe).
+		Italic(true)
+
 	sDateBadge = lipgloss.NewStyle().
 			Foreground(clrMuted).
 			Bold(true)
+
+	sTicksRead = lipgloss.NewStyle().
+			Foreground(clrLink)
 )
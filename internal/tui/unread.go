@@ -0,0 +1,59 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"DevStarByte/internal/client"
+)
+
+// ── Unread messages ───────────────────────────────────────────────────────────
+
+// rememberUnread notes the first unread message of the selected chat before
+// it is marked read, so the divider can be drawn above it.
+func (m Model) rememberUnread() Model {
+	m.unreadChat, m.unreadID = "", ""
+	c, ok := m.selectedItem()
+	if !ok || c.Unread == 0 {
+		return m
+	}
+	key := c.JID.String()
+	msgs := m.messages[key]
+	if i := client.FirstUnread(msgs, c.LastReadID, c.Unread); i >= 0 {
+		m.unreadChat, m.unreadID = key, msgs[i].ID
+	}
+	return m
+}
+
+// markRead marks the selected chat as read on all devices.
+func (m Model) markRead() tea.Cmd {
+	if m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return nil
+	}
+	jid := m.chats[m.selectedChat].JID
+	s := m.state
+	return func() tea.Msg {
+		if err := client.MarkRead(s, jid); err != nil {
+			return tuiError{err}
+		}
+		return nil
+	}
+}
+
+// jumpToUnread scrolls the open chat to the "unread messages" divider.
+func (m Model) jumpToUnread() (Model, tea.Cmd) {
+	c, ok := m.selectedItem()
+	if !ok || m.unreadChat != c.JID.String() {
+		return m, statusCmd("No unread messages")
+	}
+	_, w, _ := m.panelWidths()
+	key := c.JID.String()
+	_, starts := m.messageLines(key, w)
+	msgs := m.messages[key]
+	for i := range msgs {
+		if msgs[i].ID == m.unreadID && i < len(starts) {
+			// Show the divider (two lines above the message) at the top.
+			m.msgScroll = min(max(0, starts[i]-2), m.maxMsgScroll(key))
+		}
+	}
+	return m, nil
+}
@@ -1,12 +1,12 @@
 package tui
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
-	"os"
 	"os/exec"
 	"sort"
 	"strings"
@@ -15,7 +15,10 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/nfnt/resize"
+	"github.com/rivo/uniseg"
+	"go.mau.fi/whatsmeow/types"
 
+	"DevStarByte/internal/db"
 	apptypes "DevStarByte/internal/types"
 )
 
@@ -26,26 +29,9 @@ func (m Model) View() string {
 		return fmt.Sprintf("Terminal too small (%dx%d). Please resize.\n", m.width, m.height)
 	}
 
-	// Dimensions:
-	//   header:    1 line  (no border)
-	//   mainRow:   innerH + 2 lines (border)
-	//   inputBar:  1 + 2  = 3 lines (border)
-	//   statusBar: 1 line  (no border)
-	//   total = 1 + (innerH+2) + 3 + 1 = innerH + 7
-	innerH := m.height - 7
-	if innerH < 3 {
-		innerH = 3
-	}
+	innerH := m.mainInnerH()
 
-	// Panel inner widths.  outer = inner + 2 (border).
-	//   chatOuter + msgOuter = m.width
-	//   (chatInner+2) + (msgInner+2) = m.width
-	//   chatInner + msgInner = m.width - 4
-	chatInner := 28
-	msgInner := m.width - chatInner - 4
-	if msgInner < 10 {
-		msgInner = 10
-	}
+	chatInner, msgInner, infoInner := m.panelWidths()
 
 	// ── Header ────────────────────────────────────────────────────────────────
 	header := sHeader.Width(m.width - 2).
@@ -61,6 +47,21 @@ func (m Model) View() string {
 
 	// ── Message panel ─────────────────────────────────────────────────────────
 	msgContent := m.renderMessages(msgInner, innerH)
+	if m.compl != nil && m.focus == focusInput {
+		msgContent = overlayBottom(msgContent, m.renderCompletion(msgInner), innerH)
+	}
+	if m.picker != nil {
+		msgContent = overlayBottom(msgContent, m.renderPicker(msgInner), innerH)
+	}
+	if m.rules != nil {
+		msgContent = m.renderRules(msgInner, innerH)
+	}
+	if m.schedule != nil {
+		msgContent = m.renderSchedule(msgInner, innerH)
+	}
+	if m.search != nil {
+		msgContent = m.renderSearch(msgInner, innerH)
+	}
 	msgBorder := sIdle
 	if m.focus == focusMessages {
 		msgBorder = sActive
@@ -69,6 +70,13 @@ func (m Model) View() string {
 
 	mainRow := lipgloss.JoinHorizontal(lipgloss.Top, chatBox, msgBox)
 
+	// ── Info panel (optional) ─────────────────────────────────────────────────
+	if infoInner > 0 {
+		infoContent := m.renderInfo(infoInner, innerH)
+		infoBox := sIdle.Width(infoInner).MaxWidth(infoInner + 2).Height(innerH).Render(infoContent)
+		mainRow = lipgloss.JoinHorizontal(lipgloss.Top, chatBox, msgBox, infoBox)
+	}
+
 	// ── Input bar ─────────────────────────────────────────────────────────────
 	inputBar := m.renderInput(m.width)
 
@@ -95,14 +103,45 @@ func (m Model) renderChatList(w, h int) string {
 		visRows = 1
 	}
 
-	end := min(m.chatScroll+visRows, len(m.chats))
-	for i := m.chatScroll; i < end; i++ {
+	archived := m.archivedStart()
+	end := min(m.chatScroll+visRows, m.chatRowCount())
+	for row := m.chatScroll; row < end; row++ {
+		i := row
+		if row == archived {
+			lines = append(lines, m.renderArchivedRow(w))
+			continue
+		} else if row > archived {
+			i = row - 1
+		}
 		c := m.chats[i]
-		name := truncateStr(c.Name, w-6)
+		key := c.JID.String()
+		nameW := w - 6
+		icons := ""
+		if c.Pinned {
+			icons += " 📌"
+		}
+		if c.Muted() {
+			icons += " 🔕"
+		}
+		nameW -= lipgloss.Width(icons)
+		draft := strings.Join(strings.Fields(m.draftFor(key)), " ")
+		if draft != "" {
+			// Leave room for at least "Draft: x…" after the name.
+			nameW = min(nameW, max(8, w-2-lipgloss.Width(draft)-8))
+		}
+		name := truncateStr(c.Name, nameW)
+		if m.marked[key] {
+			name = "✓ " + truncateStr(c.Name, nameW-2)
+		}
+		name += icons
 		badge := ""
 		if c.Unread > 0 {
 			badge = " " + sUnread.Render(fmt.Sprintf("(%d)", c.Unread))
 		}
+		if draft != "" {
+			rest := w - 2 - lipgloss.Width(name) - lipgloss.Width(badge) - 8
+			badge += " " + sDraft.Render("Draft: "+truncateStr(draft, max(1, rest)))
+		}
 		row := name + badge
 		if i == m.selectedChat {
 			lines = append(lines, sChatSel.Width(w).Render(row))
@@ -118,6 +157,24 @@ func (m Model) renderChatList(w, h int) string {
 	return clampContent(strings.Join(lines, "\n"), w)
 }
 
+// renderArchivedRow renders the row that separates archived chats.
+func (m Model) renderArchivedRow(w int) string {
+	n, unread := 0, 0
+	for _, c := range m.chats[m.archivedStart():] {
+		n++
+		unread += c.Unread
+	}
+	arrow := "▸"
+	if m.showArchived {
+		arrow = "▾"
+	}
+	row := sMuted.Render(fmt.Sprintf("%s Archived (%d)", arrow, n))
+	if unread > 0 {
+		row += " " + sUnread.Render(fmt.Sprintf("(%d)", unread))
+	}
+	return sChatNorm.Width(w).Render(row)
+}
+
 // ── Message panel rendering ───────────────────────────────────────────────────
 
 func (m Model) renderMessages(w, h int) string {
@@ -140,30 +197,7 @@ func (m Model) renderMessages(w, h int) string {
 		return strings.Join(append(header, hint), "\n")
 	}
 
-	// Always read from the global map so history-sync'd messages are immediately visible.
-	m.state.MessagesMu.RLock()
-	msgs := make([]apptypes.Message, len(m.state.MessagesMap[key]))
-	copy(msgs, m.state.MessagesMap[key])
-	m.state.MessagesMu.RUnlock()
-
-	var msgLines []string
-	var lastDate string
-	for _, msg := range msgs {
-		// Insert date separator when the day changes.
-		dateStr := msg.Timestamp.Format("Jan 2, 2006")
-		if dateStr != lastDate {
-			lastDate = dateStr
-			label := sDateBadge.Render("── " + dateStr + " ──")
-			pad := (w - lipgloss.Width(label)) / 2
-			if pad < 0 {
-				pad = 0
-			}
-			msgLines = append(msgLines, strings.Repeat(" ", pad)+label)
-			msgLines = append(msgLines, "")
-		}
-		msgLines = append(msgLines, m.formatMsg(msg, w)...)
-		msgLines = append(msgLines, "") // blank separator
-	}
+	msgLines, _ := m.messageLines(key, w)
 
 	visH := h - 2
 	if visH < 1 {
@@ -196,12 +230,22 @@ func (m Model) formatMsg(msg apptypes.Message, w int) []string {
 	ts := sTime.Render(msg.Timestamp.Format("15:04"))
 	var lines []string
 
+	// Group notices are centred, without a bubble.
+	if msg.System {
+		for _, l := range wordWrap(msg.Content, w-4) {
+			label := sMuted.Render(l)
+			pad := max(0, (w-lipgloss.Width(label))/2)
+			lines = append(lines, strings.Repeat(" ", pad)+label)
+		}
+		return lines
+	}
+
 	if msg.FromMe {
-		meta := sTime.Render("You") + "  " + ts
-		wrapped := wordWrap(msg.Content, w-6)
+		meta := sTime.Render("You") + "  " + ts + statusTicks(msg.Status)
+		wrapped := wrapSpans(m.parseRich(msg.Content), w-6)
 		// Right-align: pad lines to push them to the right.
 		for i, l := range wrapped {
-			styled := sMyMsg.Render(l)
+			styled := renderCells(l, sMyMsg)
 			pad := w - lipgloss.Width(styled) - 1
 			if pad < 0 {
 				pad = 0
@@ -218,14 +262,14 @@ func (m Model) formatMsg(msg apptypes.Message, w int) []string {
 	} else {
 		meta := sSender.Render(msg.Sender) + "  " + ts
 		lines = append(lines, clampWidth(meta, w))
-		for _, l := range wordWrap(msg.Content, w-4) {
-			lines = append(lines, clampWidth(sTheirMsg.Render(l), w))
+		for _, l := range wrapSpans(m.parseRich(msg.Content), w-4) {
+			lines = append(lines, clampWidth(renderCells(l, sTheirMsg), w))
 		}
 	}
 
 	// Render image inline if available.
 	if msg.ImagePath != "" {
-		if imgLines := renderImageBlock(msg.ImagePath, w-4); len(imgLines) > 0 {
+		if imgLines := renderImageBlock(m.state.DB, msg.ImagePath, w-4); len(imgLines) > 0 {
 			lines = append(lines, imgLines...)
 		}
 	}
@@ -241,15 +285,24 @@ var imageRenderCache sync.Map
 // first (which uses braille / block characters / sixel depending on the
 // terminal and produces much sharper output), then falls back to the
 // built-in half-block renderer.
-func renderImageBlock(imgPath string, maxCols int) []string {
+func renderImageBlock(store *db.Store, imgPath string, maxCols int) []string {
 	cacheKey := fmt.Sprintf("%s:%d", imgPath, maxCols)
 	if cached, ok := imageRenderCache.Load(cacheKey); ok {
 		return cached.([]string)
 	}
 
-	lines := renderImageWithChafa(imgPath, maxCols)
+	// Decrypted in memory if media_cache is encrypted.
+	data, err := store.ReadMedia(imgPath)
+	if err != nil {
+		return nil
+	}
+	var decrypted []byte
+	if store.Encryption() != db.EncryptionOff {
+		decrypted = data
+	}
+	lines := renderImageWithChafa(imgPath, decrypted, maxCols)
 	if lines == nil {
-		lines = renderImageHalfBlock(imgPath, maxCols)
+		lines = renderImageHalfBlock(data, maxCols)
 	}
 
 	if lines != nil {
@@ -259,16 +312,24 @@ func renderImageBlock(imgPath string, maxCols int) []string {
 }
 
 // renderImageWithChafa shells out to chafa(1) for high-quality terminal
-// image rendering.  Returns nil if chafa is not installed.
-func renderImageWithChafa(imgPath string, maxCols int) []string {
+// image rendering.  Decrypted images are piped in rather than read from
+// imgPath.  Returns nil if chafa is not installed.
+func renderImageWithChafa(imgPath string, decrypted []byte, maxCols int) []string {
 	cols := maxCols
 	rows := cols * 3 / 8 // roughly 3:8 aspect for compact look
-	cmd := exec.Command("chafa",
+	args := []string{
 		"--format", "symbols",
 		"--symbols", "all",
 		"--size", fmt.Sprintf("%dx%d", cols, rows),
 		imgPath,
-	)
+	}
+	if decrypted != nil {
+		args[len(args)-1] = "-"
+	}
+	cmd := exec.Command("chafa", args...)
+	if decrypted != nil {
+		cmd.Stdin = bytes.NewReader(decrypted)
+	}
 	out, err := cmd.Output()
 	if err != nil {
 		return nil
@@ -282,14 +343,8 @@ func renderImageWithChafa(imgPath string, maxCols int) []string {
 
 // renderImageHalfBlock converts an image into ANSI true-color half-block
 // characters (▄) as a fallback when chafa is not available.
-func renderImageHalfBlock(imgPath string, maxCols int) []string {
-	f, err := os.Open(imgPath)
-	if err != nil {
-		return nil
-	}
-	defer f.Close()
-
-	img, _, err := image.Decode(f)
+func renderImageHalfBlock(data []byte, maxCols int) []string {
+	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil
 	}
@@ -336,49 +391,200 @@ func renderImageHalfBlock(imgPath string, maxCols int) []string {
 	return lines
 }
 
+// ── Info panel rendering ──────────────────────────────────────────────────────
+
+func (m Model) renderInfo(w, h int) string {
+	if m.selectedChat < 0 || m.selectedChat >= len(m.chats) {
+		return sMuted.Render("No chat selected")
+	}
+	c := m.chats[m.selectedChat]
+	titleText := "Contact info"
+	if c.IsGroup {
+		titleText = "Group info"
+	}
+	title := sAccent.Bold(true).Render(titleText)
+	divider := sDivider.Render(strings.Repeat("─", w))
+	lines := []string{title, divider}
+
+	info := m.chatInfo[c.JID.String()]
+	if info == nil {
+		lines = append(lines, sMuted.Render("Loading…"))
+		return clampContent(strings.Join(lines, "\n"), w)
+	}
+
+	field := func(label, value string) {
+		if value == "" {
+			return
+		}
+		lines = append(lines, sTime.Render(label))
+		lines = append(lines, wordWrap(value, w)...)
+		lines = append(lines, "")
+	}
+
+	if info.IsGroup {
+		field("Description", orDefault(info.Description, "—"))
+		if !info.Created.IsZero() {
+			field("Created", info.Created.Format("Jan 2, 2006 15:04"))
+		}
+		field("Creator", info.Creator)
+		lines = append(lines, sTime.Render(fmt.Sprintf("%d participants", len(info.Participants))))
+		for _, p := range info.Participants {
+			badge := ""
+			switch {
+			case p.IsSuperAdmin:
+				badge = " " + sUnread.Render("owner")
+			case p.IsAdmin:
+				badge = " " + sUnread.Render("admin")
+			}
+			lines = append(lines, truncateStr(p.Name, w-lipgloss.Width(badge))+badge)
+		}
+	} else {
+		if info.PicturePath != "" {
+			lines = append(lines, renderImageBlock(m.state.DB, info.PicturePath, w)...)
+			lines = append(lines, "")
+		}
+		field("Phone", info.Phone)
+		field("About", orDefault(info.About, "—"))
+		field("Business", info.BusinessName)
+	}
+
+	if len(lines) > h {
+		more := len(lines) - h + 1
+		lines = append(lines[:h-1], sMuted.Render(fmt.Sprintf("… %d more", more)))
+	}
+	return clampContent(strings.Join(lines, "\n"), w)
+}
+
 // ── Input bar rendering ───────────────────────────────────────────────────────
 
+// inputHint is shown at the right of the first input row.
+const inputHint = "[Enter] send  [Alt+Enter] newline  [Esc] back  [Tab] switch"
+
+// inputRow is one visual row of the draft: the rune range [start, end) of
+// m.inputText, excluding the newline that ends it.
+type inputRow struct{ start, end int }
+
+// minInputTextW is the narrowest typing area the hint may leave.
+const minInputTextW = 20
+
+// inputHintFor returns the hint for an input bar totalW wide, or "" when it
+// would squeeze the typed text below minInputTextW.
+func inputHintFor(totalW int) string {
+	if totalW-lipgloss.Width(inputHint)-2-4 < minInputTextW {
+		return ""
+	}
+	return inputHint
+}
+
+// inputTextW returns the width available for typed text in the input bar
+// (inside the border, minus prefix and hint).
+func (m Model) inputTextW(totalW int) int {
+	return max(1, totalW-lipgloss.Width(inputHintFor(totalW))-2-4)
+}
+
+// inputRows splits the draft into visual rows: at newlines and, for long
+// lines, wherever the text would exceed w columns. One column is kept free
+// for the cursor.
+func (m Model) inputRows(w int) []inputRow {
+	var rows []inputRow
+	start, lineW, i := 0, 0, 0
+	g := uniseg.NewGraphemes(m.inputText)
+	for g.Next() {
+		n := len(g.Runes())
+		if g.Str() == "\n" {
+			rows = append(rows, inputRow{start, i})
+			start, lineW = i+1, 0
+			i += n
+			continue
+		}
+		if lineW+g.Width() > w-1 && i > start {
+			rows = append(rows, inputRow{start, i})
+			start, lineW = i, 0
+		}
+		lineW += g.Width()
+		i += n
+	}
+	return append(rows, inputRow{start, i})
+}
+
+// cursorRow returns the index of the visual row holding the cursor.
+func cursorRow(rows []inputRow, cursor int) int {
+	for i, row := range rows {
+		if cursor < row.start {
+			continue
+		}
+		// At the end of a soft-wrapped row the cursor belongs to the next row.
+		if cursor < row.end || cursor == row.end && (i == len(rows)-1 || rows[i+1].start > row.end) {
+			return i
+		}
+	}
+	return len(rows) - 1
+}
+
+// inputHeight returns how many text lines the input bar currently needs.
+func (m Model) inputHeight() int {
+	maxLines := 1
+	if m.state.Config != nil {
+		maxLines = m.state.Config.InputMaxLines
+	}
+	return max(1, min(len(m.inputRows(m.inputTextW(m.width))), maxLines))
+}
+
 func (m Model) renderInput(totalW int) string {
 	active := m.focus == focusInput
 	r := []rune(m.inputText)
-	hint := sTime.Render("[Enter] send  [Esc] back  [Ctrl+W] del-word  [Tab] switch")
+	hint := sTime.Render(inputHintFor(totalW))
 	prefix := sAccent.Render("> ")
-
-	// Available space for the typed text (inside the border, minus prefix and hint).
-	innerW := totalW - lipgloss.Width(hint) - lipgloss.Width(prefix) - 4
-	if innerW < 1 {
-		innerW = 1
+	innerW := m.inputTextW(totalW)
+	h := m.inputHeight()
+
+	var display []string
+	switch {
+	case active:
+		rows := m.inputRows(innerW)
+		cur := cursorRow(rows, m.inputCursor)
+		// Scroll the visible window so the cursor row is always visible.
+		top := max(0, cur-h+1)
+		for i := top; i < min(len(rows), top+h); i++ {
+			row := rows[i]
+			line := string(r[row.start:row.end])
+			if i == cur {
+				idx := m.inputCursor - row.start
+				before := string(r[row.start : row.start+idx])
+				if row.start+idx < row.end {
+					// Highlight the whole cluster under the cursor.
+					next := min(nextCluster(m.inputText, m.inputCursor), row.end)
+					c := lipgloss.NewStyle().Reverse(true).Render(string(r[m.inputCursor:next]))
+					line = before + c + string(r[next:row.end])
+				} else {
+					line = before + lipgloss.NewStyle().Reverse(true).Render(" ")
+				}
+			}
+			display = append(display, line)
+		}
+	case m.inputText == "":
+		display = []string{sMuted.Render(truncateStr("Tab to focus · select a chat first", innerW))}
+	default:
+		for _, row := range m.inputRows(innerW)[:h] {
+			display = append(display, string(r[row.start:row.end]))
+		}
 	}
 
-	var display string
-	if active {
-		// Scroll the visible window so the cursor is always visible.
-		startR := max(0, m.inputCursor-innerW/2)
-		endR := min(len(r), startR+innerW)
-		sub := r[startR:endR]
-		curIdx := m.inputCursor - startR
-
-		if curIdx < len(sub) {
-			before := string(sub[:curIdx])
-			cur := lipgloss.NewStyle().Reverse(true).Render(string(sub[curIdx : curIdx+1]))
-			after := string(sub[curIdx+1:])
-			display = before + cur + after
-		} else {
-			display = string(sub) + lipgloss.NewStyle().Reverse(true).Render(" ")
+	lines := make([]string, len(display))
+	blankHint := strings.Repeat(" ", lipgloss.Width(hint))
+	for i, d := range display {
+		p, hi := prefix, hint
+		if i > 0 {
+			p, hi = "  ", blankHint
 		}
-	} else if m.inputText == "" {
-		display = sMuted.Render("Tab to focus · select a chat first")
-	} else {
-		display = m.inputText
+		lines[i] = p + lipgloss.NewStyle().Width(innerW).Render(d) + hi
 	}
 
-	content := prefix + lipgloss.NewStyle().Width(innerW).Render(display) + hint
-
 	border := sIdle
 	if active {
 		border = sActive
 	}
-	return border.Width(totalW - 4).Height(1).Render(content)
+	return border.Width(totalW - 4).Height(h).Render(strings.Join(lines, "\n"))
 }
 
 // ── Status bar rendering ──────────────────────────────────────────────────────
@@ -400,37 +606,137 @@ func (m Model) renderStatus() string {
 	if m.statusMsg != "" && time.Since(m.statusTime) < 4*time.Second {
 		flash = "   " + lipgloss.NewStyle().Foreground(clrText).Render(m.statusMsg)
 	}
-	keys := sTime.Render("  j/k navigate · g/G top/bottom · i type · q quit")
+	// Drop key hints from the end until the bar fits on one line.
+	hints := []string{"j/k navigate", "g/G top/bottom", "J/K select", "r react", "i type", "q quit"}
+	keys := ""
+	for n := len(hints); n > 0; n-- {
+		keys = sTime.Render("  " + strings.Join(hints[:n], " · "))
+		if lipgloss.Width(conn+syncStatus+flash+keys) <= m.width-sStatus.GetHorizontalFrameSize() {
+			break
+		}
+		keys = ""
+	}
 	return sStatus.Width(m.width).Render(conn + syncStatus + flash + keys)
 }
 
 // ── Dimension helpers ─────────────────────────────────────────────────────────
 
+// panelWidths returns the inner widths of the chat list, message and info
+// panels. infoInner is 0 when the info panel is hidden or does not fit.
+// outer = inner + 2 (border), and all outer widths sum to m.width.
+func (m Model) panelWidths() (chatInner, msgInner, infoInner int) {
+	chatInner = 28
+	msgInner = m.width - chatInner - 4
+	if m.showInfo && msgInner >= 30+32 {
+		infoInner = 30
+		msgInner -= infoInner + 2
+	}
+	if msgInner < 10 {
+		msgInner = 10
+	}
+	return chatInner, msgInner, infoInner
+}
+
+// mainInnerH returns the inner height of the chat list and message panels.
+//
+// Dimensions:
+//
+//	header:    1 line  (no border)
+//	mainRow:   innerH + 2 lines (border)
+//	inputBar:  inputH + 2 lines (border)
+//	statusBar: 1 line  (no border)
+//	total = 1 + (innerH+2) + (inputH+2) + 1 = innerH + inputH + 6
+func (m Model) mainInnerH() int {
+	return max(3, m.height-6-m.inputHeight())
+}
+
 // visibleChatRows returns how many chat items fit in the list panel.
 func (m Model) visibleChatRows() int {
-	innerH := m.height - 7
-	return max(1, innerH-2) // subtract the title + divider rows
+	return max(1, m.mainInnerH()-2) // subtract the title + divider rows
+}
+
+// unreadDivider renders the line above the first unread message; rest are
+// the messages from there on.
+func (m Model) unreadDivider(rest []apptypes.Message, w int) string {
+	n := 0
+	for _, msg := range rest {
+		if !msg.FromMe && !msg.System {
+			n++
+		}
+	}
+	text := "1 unread message"
+	if n != 1 {
+		text = fmt.Sprintf("%d unread messages", n)
+	}
+	label := sUnread.Render("── " + text + " ──")
+	return strings.Repeat(" ", max(0, (w-lipgloss.Width(label))/2)) + label
 }
 
 // maxMsgScroll returns the maximum scroll offset for the given chat.
 func (m Model) maxMsgScroll(key string) int {
-	m.state.MessagesMu.RLock()
-	msgs := make([]apptypes.Message, len(m.state.MessagesMap[key]))
-	copy(msgs, m.state.MessagesMap[key])
-	m.state.MessagesMu.RUnlock()
-	approxW := m.width - 28 - 4 - 4 // rough inner message width
-	var lines []string
-	for _, msg := range msgs {
-		lines = append(lines, m.formatMsg(msg, approxW)...)
-		lines = append(lines, "")
-	}
-	innerH := m.height - 7
-	visH := max(1, innerH-2)
+	_, approxW, _ := m.panelWidths()
+	lines, _ := m.messageLines(key, approxW)
+	visH := max(1, m.mainInnerH()-2)
 	return max(0, len(lines)-visH)
 }
 
+// messageLines lays out the messages of a chat at width w. starts holds the
+// index of each message's first line, parallel to the chat's messages.
+func (m Model) messageLines(key string, w int) (lines []string, starts []int) {
+	msgs := m.messages[key]
+	var lastDate string
+	for i, msg := range msgs {
+		// Insert date separator when the day changes.
+		dateStr := msg.Timestamp.Format("Jan 2, 2006")
+		if dateStr != lastDate {
+			lastDate = dateStr
+			label := sDateBadge.Render("── " + dateStr + " ──")
+			pad := (w - lipgloss.Width(label)) / 2
+			if pad < 0 {
+				pad = 0
+			}
+			lines = append(lines, strings.Repeat(" ", pad)+label)
+			lines = append(lines, "")
+		}
+		if key == m.unreadChat && msg.ID == m.unreadID {
+			lines = append(lines, m.unreadDivider(msgs[i:], w), "")
+		}
+		starts = append(starts, len(lines))
+		msgLines := m.formatMsg(msg, w)
+		if m.selMsgID != "" && msg.ID == m.selMsgID {
+			// Mark the message selected for reactions.
+			for j := range msgLines {
+				msgLines[j] = sAccent.Render("▌") + msgLines[j]
+			}
+		}
+		lines = append(lines, msgLines...)
+		lines = append(lines, "") // blank separator
+	}
+	if jid, err := types.ParseJID(key); err == nil {
+		now := time.Now()
+		for _, sm := range m.state.Scheduler.Pending(jid) {
+			lines = append(lines, formatScheduled(sm, w, now)...)
+			lines = append(lines, "")
+		}
+	}
+	return lines, starts
+}
+
 // ── Text utilities ────────────────────────────────────────────────────────────
 
+// statusTicks renders the delivery status of a sent message.
+func statusTicks(st apptypes.MessageStatus) string {
+	switch st {
+	case apptypes.StatusSent:
+		return " " + sTime.Render("✓")
+	case apptypes.StatusDelivered:
+		return " " + sTime.Render("✓✓")
+	case apptypes.StatusRead:
+		return " " + sTicksRead.Render("✓✓")
+	}
+	return ""
+}
+
 // mergeMessages merges two message slices, deduplicates by ID, and sorts by time.
 func mergeMessages(a, b []apptypes.Message) []apptypes.Message {
 	seen := make(map[string]bool, len(a)+len(b))
@@ -447,16 +753,27 @@ func mergeMessages(a, b []apptypes.Message) []apptypes.Message {
 	return out
 }
 
-// truncateStr truncates s to maxW runes, appending "…" if needed.
+// truncateStr truncates s to maxW display columns, appending "…" if needed.
+// It cuts between grapheme clusters, so emoji ZWJ sequences and flags are
+// never split.
 func truncateStr(s string, maxW int) string {
-	r := []rune(s)
-	if len(r) <= maxW {
+	if uniseg.StringWidth(s) <= maxW {
 		return s
 	}
 	if maxW <= 1 {
 		return "…"
 	}
-	return string(r[:maxW-1]) + "…"
+	var sb strings.Builder
+	w := 0
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		if w+g.Width() > maxW-1 {
+			break
+		}
+		w += g.Width()
+		sb.WriteString(g.Str())
+	}
+	return sb.String() + "…"
 }
 
 // wordWrap splits text into lines of at most width display columns, breaking at spaces.
@@ -474,37 +791,46 @@ func wordWrap(text string, width int) []string {
 }
 
 // wrapLine wraps a single line (no embedded newlines) to the given display width.
+// It works on grapheme clusters so multi-rune emoji are measured and kept whole.
 func wrapLine(text string, width int) []string {
 	if width <= 0 {
 		return []string{text}
 	}
+	var clusters []string
+	var widths []int
+	g := uniseg.NewGraphemes(text)
+	for g.Next() {
+		clusters = append(clusters, g.Str())
+		widths = append(widths, g.Width())
+	}
+
 	var result []string
-	r := []rune(text)
-	for len(r) > 0 {
-		if lipgloss.Width(string(r)) <= width {
-			result = append(result, string(r))
-			break
-		}
+	for len(clusters) > 0 {
 		// Find the cut point where display width fits.
-		cut := 0
-		for cut < len(r) && lipgloss.Width(string(r[:cut+1])) <= width {
+		cut, w := 0, 0
+		for cut < len(clusters) && w+widths[cut] <= width {
+			w += widths[cut]
 			cut++
 		}
+		if cut == len(clusters) {
+			result = append(result, strings.Join(clusters, ""))
+			break
+		}
 		if cut == 0 {
-			cut = 1 // always consume at least one rune
+			cut = 1 // always consume at least one cluster
 		}
 		// Try to break at a space.
 		spaceCut := cut
-		for spaceCut > 0 && r[spaceCut-1] != ' ' {
+		for spaceCut > 0 && clusters[spaceCut-1] != " " {
 			spaceCut--
 		}
 		if spaceCut > 0 {
 			cut = spaceCut
 		}
-		result = append(result, string(r[:cut]))
-		r = r[cut:]
-		for len(r) > 0 && r[0] == ' ' {
-			r = r[1:]
+		result = append(result, strings.Join(clusters[:cut], ""))
+		clusters, widths = clusters[cut:], widths[cut:]
+		for len(clusters) > 0 && clusters[0] == " " {
+			clusters, widths = clusters[1:], widths[1:]
 		}
 	}
 	if len(result) == 0 {
@@ -513,6 +839,42 @@ func wrapLine(text string, width int) []string {
 	return result
 }
 
+// graphemeStarts returns the rune offsets at which the grapheme clusters of
+// s begin, followed by the total rune count.
+func graphemeStarts(s string) []int {
+	var starts []int
+	pos := 0
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		starts = append(starts, pos)
+		pos += len(g.Runes())
+	}
+	return append(starts, pos)
+}
+
+// prevCluster returns the rune offset of the cluster before cursor.
+func prevCluster(s string, cursor int) int {
+	prev := 0
+	for _, st := range graphemeStarts(s) {
+		if st >= cursor {
+			break
+		}
+		prev = st
+	}
+	return prev
+}
+
+// nextCluster returns the rune offset of the cluster after cursor.
+func nextCluster(s string, cursor int) int {
+	starts := graphemeStarts(s)
+	for _, st := range starts {
+		if st > cursor {
+			return st
+		}
+	}
+	return starts[len(starts)-1]
+}
+
 // clampWidth truncates a (possibly styled/ANSI) string to at most maxW display columns.
 func clampWidth(s string, maxW int) string {
 	if lipgloss.Width(s) <= maxW {
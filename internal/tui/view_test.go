@@ -0,0 +1,219 @@
+package tui
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/client"
+	"DevStarByte/internal/config"
+	"DevStarByte/internal/db/dbtest"
+	"DevStarByte/internal/demo"
+	"DevStarByte/internal/rules"
+	"DevStarByte/internal/schedule"
+	"DevStarByte/internal/state"
+	"DevStarByte/internal/wa"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// now is the fixed clock the demo fixture is seeded against.
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func TestMain(m *testing.M) {
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+// newDemoModel builds a model over the demo fixture, sized w×h.
+func newDemoModel(t *testing.T, w, h int) Model {
+	t.Helper()
+	logger, store := dbtest.New(t)
+	demo.Seed(store, now)
+
+	s := state.New(demo.NewClient(now), store, logger, config.Default())
+	s.Rules = rules.New(logger, store, rules.Options{})
+	s.Scheduler = schedule.New(logger, store, nil)
+	t.Cleanup(s.Scheduler.Close)
+	s.Client.AddEventHandler(client.NewEventHandler(s))
+	chats, err := client.LoadChats(s, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.MessagesMap = store.LoadAllMessages()
+
+	m := NewModel(s, chats)
+	t.Cleanup(m.events.Close)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: w, Height: h})
+	return next.(Model)
+}
+
+// keyMsg turns a key name as written in bubbletea's KeyMsg.String into a
+// key message; anything else is typed as runes.
+func keyMsg(key string) tea.KeyMsg {
+	named := map[string]tea.KeyType{
+		"enter": tea.KeyEnter, "tab": tea.KeyTab, "esc": tea.KeyEsc,
+		"up": tea.KeyUp, "down": tea.KeyDown, "backspace": tea.KeyBackspace,
+		"ctrl+o": tea.KeyCtrlO,
+	}
+	if k, ok := named[key]; ok {
+		return tea.KeyMsg{Type: k}
+	}
+	if key == " " {
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// press feeds keys to m one by one and runs the commands they return.
+func press(m Model, keys ...string) Model {
+	for _, k := range keys {
+		next, cmd := m.Update(keyMsg(k))
+		m = run(next.(Model), cmd)
+	}
+	return m
+}
+
+// run executes cmd and feeds its messages back into m. Commands that do
+// not finish quickly (ticks, the event listener) are dropped.
+func run(m Model, cmd tea.Cmd) Model {
+	if cmd == nil {
+		return m
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(200 * time.Millisecond):
+		return m
+	}
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			m = run(m, c)
+		}
+		return m
+	}
+	if msg == nil {
+		return m
+	}
+	next, cmd := m.Update(msg)
+	return run(next.(Model), cmd)
+}
+
+// ── Golden views ──────────────────────────────────────────────────────────────
+
+func TestGoldenViews(t *testing.T) {
+	sizes := [][2]int{{80, 24}, {120, 40}, {60, 20}}
+	scenarios := []struct {
+		name string
+		keys []string
+	}{
+		{"startup", nil},
+		{"open_pinned", []string{"enter"}},
+		{"unread_divider", []string{"j", "j", "enter"}},
+		{"group_info", []string{"j", "enter", "esc", "I"}},
+		{"typing", append([]string{"enter"}, strings.Split("Hello *there*", "")...)},
+		{"archived", []string{"A"}},
+		{"emoji_picker", []string{"enter", "ctrl+o", "t", "a"}},
+		{"scheduled", append(append([]string{"enter"}, strings.Split("/schedule 2030-01-07 09:00 Standup starts", "")...), "enter")},
+		{"rules_editor", append(append([]string{"enter"}, strings.Split("/rules", "")...), "enter", "n", "tab", "tab", "tab", "tab")},
+	}
+	for _, sc := range scenarios {
+		for _, size := range sizes {
+			name := fmt.Sprintf("%s_%dx%d", sc.name, size[0], size[1])
+			t.Run(name, func(t *testing.T) {
+				m := press(newDemoModel(t, size[0], size[1]), sc.keys...)
+				golden(t, name, m.View())
+			})
+		}
+	}
+}
+
+func TestTooSmall(t *testing.T) {
+	m := newDemoModel(t, 20, 5)
+	golden(t, "too_small", m.View())
+}
+
+func TestMentionNameLearntLater(t *testing.T) {
+	m := newDemoModel(t, 80, 24)
+	fake := m.state.Client.(*wa.Fake)
+	const user = "491709999999"
+	if got := m.mentionName(user); got != user {
+		t.Fatalf("unknown mention = %q", got)
+	}
+	fake.Contacts[types.NewJID(user, types.DefaultUserServer)] = types.ContactInfo{FullName: "Carol"}
+	if got := m.mentionName(user); got != "Carol" {
+		t.Errorf("mention after the contact is known = %q", got)
+	}
+}
+
+func TestGraphemeWidths(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		s     string
+		runes int
+		width int
+	}{
+		{"zwj family", "👨\u200d👩\u200d👧", 5, 2},
+		{"flag", "🇩🇪", 2, 2},
+		{"skin tone", "👍🏽", 2, 2},
+		{"emoji presentation", "❤\ufe0f", 2, 2},
+		{"text presentation", "☺\ufe0e", 2, 1},
+		{"combining accent", "e\u0301", 2, 1},
+	} {
+		text := "a" + tt.s + "b"
+		if got, want := fmt.Sprint(graphemeStarts(text)), fmt.Sprint([]int{0, 1, 1 + tt.runes, 2 + tt.runes}); got != want {
+			t.Errorf("%s: graphemeStarts = %s, want %s", tt.name, got, want)
+		}
+		if got := nextCluster(text, 1); got != 1+tt.runes {
+			t.Errorf("%s: nextCluster = %d, want %d", tt.name, got, 1+tt.runes)
+		}
+		if got := prevCluster(text, 1+tt.runes); got != 1 {
+			t.Errorf("%s: prevCluster = %d, want 1", tt.name, got)
+		}
+		// Three clusters, cut to fit two: the cluster is measured and kept whole.
+		line := strings.Repeat(tt.s, 3)
+		if got, want := wrapLine(line, 2*tt.width), []string{tt.s + tt.s, tt.s}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: wrapLine = %q, want %q", tt.name, got, want)
+		}
+		if got, want := truncateStr(line, 2*tt.width), tt.s+"…"; got != want {
+			t.Errorf("%s: truncateStr = %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join(testdataDir, name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./internal/tui -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("view differs from %s:\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+// testdataDir is absolute because newDemoModel changes the working directory.
+var testdataDir = func() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	return filepath.Join(wd, "testdata")
+}()
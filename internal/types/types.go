@@ -1,6 +1,8 @@
 package types
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	watypes "go.mau.fi/whatsmeow/types"
@@ -8,27 +10,83 @@ import (
 
 // ChatItem is a sidebar entry representing one conversation.
 type ChatItem struct {
-	JID      watypes.JID
-	Name     string
-	LastMsg  string
-	LastTime time.Time
-	Unread   int
-	IsGroup  bool
+	JID      watypes.JID `json:"jid"`
+	Name     string      `json:"name"`
+	LastMsg  string      `json:"last_msg"`
+	LastTime time.Time   `json:"last_time"`
+	Unread   int         `json:"unread"`
+	IsGroup  bool        `json:"is_group"`
+
+	// LastReadID is the newest message the user has read ("" if unknown).
+	LastReadID string `json:"last_read_id,omitempty"`
+
+	// Synced from the phone's app state.
+	Pinned     bool      `json:"pinned,omitempty"`
+	Archived   bool      `json:"archived,omitempty"`
+	MutedUntil time.Time `json:"muted_until,omitzero"` // zero if not muted
+}
+
+// Muted reports whether notifications for the chat are currently muted.
+func (c ChatItem) Muted() bool {
+	return c.MutedUntil.After(time.Now())
 }
 
 // Message is a single chat message stored in memory.
 type Message struct {
-	ID        string
-	Sender    string
-	SenderJID watypes.JID
-	Content   string
-	Timestamp time.Time
-	FromMe    bool
-	ImagePath string // path to cached image file (empty if not an image)
+	ID        string        `json:"id"`
+	Sender    string        `json:"sender"`
+	SenderJID watypes.JID   `json:"sender_jid"`
+	Content   string        `json:"content"`
+	Timestamp time.Time     `json:"timestamp"`
+	FromMe    bool          `json:"from_me"`
+	ImagePath string        `json:"image_path,omitempty"` // path to cached image file (empty if not an image)
+	System    bool          `json:"system,omitempty"`     // group notice such as "Alice added Bob" (no sender bubble)
+	Status    MessageStatus `json:"status,omitempty"`
+}
+
+// MessageStatus is how far a message I sent got. Incoming messages keep
+// StatusPending.
+type MessageStatus int
+
+const (
+	StatusPending   MessageStatus = iota // not yet acknowledged by the server
+	StatusSent                           // ✓
+	StatusDelivered                      // ✓✓
+	StatusRead                           // ✓✓ in colour (also played)
+)
+
+// Participant is a group member as shown in the info panel.
+type Participant struct {
+	JID          watypes.JID `json:"jid"`
+	Name         string      `json:"name"`
+	IsAdmin      bool        `json:"is_admin,omitempty"`
+	IsSuperAdmin bool        `json:"is_super_admin,omitempty"`
+}
+
+// ChatInfo holds the metadata shown in the info side panel. Group fields are
+// filled for groups, contact fields for direct chats.
+type ChatInfo struct {
+	JID     watypes.JID `json:"jid"`
+	IsGroup bool        `json:"is_group"`
+
+	// Group metadata.
+	Description  string        `json:"description,omitempty"`
+	Created      time.Time     `json:"created,omitzero"`
+	Creator      string        `json:"creator,omitempty"`
+	Participants []Participant `json:"participants,omitempty"`
+
+	// Contact metadata.
+	About        string `json:"about,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	BusinessName string `json:"business_name,omitempty"`
+	PicturePath  string `json:"picture_path,omitempty"` // cached profile picture (empty if none)
 }
 
-// MsgEvent carries an incoming message event to the TUI goroutine.
-type MsgEvent struct {
-	ChatJID watypes.JID
-	Message Message
+// MatchJID reports whether jid is in list, as a JID or a phone number, as
+// webhook and rule filters are written.
+func MatchJID(list []string, jid watypes.JID) bool {
+	return slices.ContainsFunc(list, func(s string) bool {
+		s = strings.TrimPrefix(strings.ReplaceAll(s, " ", ""), "+")
+		return s == jid.String() || s == jid.User
+	})
 }
@@ -0,0 +1,436 @@
+package wa
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// ── In-memory fake ────────────────────────────────────────────────────────────
+
+// Fake is an in-memory Client for tests and the demo mode. Set up the
+// exported maps before use; calls that reach the network are recorded
+// instead, and events are delivered with Emit or Play.
+type Fake struct {
+	ID  types.JID
+	LID types.JID
+
+	Contacts map[types.JID]types.ContactInfo
+	Users    map[types.JID]types.UserInfo
+	Groups   map[types.JID]*types.GroupInfo
+	LIDs     map[types.JID]types.JID // hidden-user JID → phone number JID
+	Settings map[types.JID]types.LocalChatSettings
+	Media    map[string][]byte // direct path → downloaded bytes
+
+	// Now stamps sent messages; time.Now if nil.
+	Now func() time.Time
+	// Err, if set, is returned by every call that would reach the server.
+	Err error
+
+	mu        sync.Mutex
+	handlers  []whatsmeow.EventHandler
+	nextID    int
+	sent      []Sent
+	patches   []appstate.PatchInfo
+	reads     []Read
+	presences []types.Presence
+}
+
+// Sent is a message sent through the fake.
+type Sent struct {
+	To        types.JID
+	ID        types.MessageID
+	Timestamp time.Time
+	Message   *waE2E.Message
+}
+
+// Read is a read receipt sent through the fake.
+type Read struct {
+	Chat, Sender types.JID
+	IDs          []types.MessageID
+}
+
+// Step is one event of a script, delivered After the previous one.
+type Step struct {
+	After time.Duration
+	Event interface{}
+}
+
+// NewFake returns a fake logged in as own.
+func NewFake(own types.JID) *Fake {
+	return &Fake{
+		ID:       own,
+		Contacts: make(map[types.JID]types.ContactInfo),
+		Users:    make(map[types.JID]types.UserInfo),
+		Groups:   make(map[types.JID]*types.GroupInfo),
+		LIDs:     make(map[types.JID]types.JID),
+		Settings: make(map[types.JID]types.LocalChatSettings),
+		Media:    make(map[string][]byte),
+	}
+}
+
+// Emit delivers events to the registered handlers, in order, on the calling
+// goroutine.
+func (f *Fake) Emit(evts ...interface{}) {
+	f.mu.Lock()
+	handlers := slices.Clone(f.handlers)
+	f.mu.Unlock()
+	for _, evt := range evts {
+		for _, h := range handlers {
+			h(evt)
+		}
+	}
+}
+
+// Play emits a script in the background. The returned channel is closed when
+// the script ended or ctx was cancelled.
+func (f *Fake) Play(ctx context.Context, script []Step) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, step := range script {
+			select {
+			case <-time.After(step.After):
+			case <-ctx.Done():
+				return
+			}
+			f.Emit(step.Event)
+		}
+	}()
+	return done
+}
+
+// SentMessages returns the messages sent so far.
+func (f *Fake) SentMessages() []Sent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.sent)
+}
+
+// Patches returns the app state patches sent so far.
+func (f *Fake) Patches() []appstate.PatchInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.patches)
+}
+
+// Reads returns the read receipts sent so far.
+func (f *Fake) Reads() []Read {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.reads)
+}
+
+// Presences returns the presence updates sent so far.
+func (f *Fake) Presences() []types.Presence {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.presences)
+}
+
+func (f *Fake) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
+
+// TextEvent builds an incoming text message event as whatsmeow emits it.
+func TextEvent(chat, sender types.JID, pushName string, id types.MessageID, text string, ts time.Time) *events.Message {
+	return &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat:    chat,
+				Sender:  sender,
+				IsGroup: chat.Server == types.GroupServer,
+			},
+			ID:        id,
+			PushName:  pushName,
+			Timestamp: ts,
+		},
+		Message: &waE2E.Message{Conversation: proto.String(text)},
+	}
+}
+
+// ── Client implementation ─────────────────────────────────────────────────────
+
+func (f *Fake) OwnID() types.JID  { return f.ID }
+func (f *Fake) OwnLID() types.JID { return f.LID }
+
+func (f *Fake) AddEventHandler(handler whatsmeow.EventHandler) uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers = append(f.handlers, handler)
+	return uint32(len(f.handlers))
+}
+
+func (f *Fake) SendMessage(_ context.Context, to types.JID, message *waE2E.Message, _ ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	if f.Err != nil {
+		return whatsmeow.SendResponse{}, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	resp := whatsmeow.SendResponse{
+		ID:        fmt.Sprintf("FAKE%06d", f.nextID),
+		Timestamp: f.now(),
+		Sender:    f.ID,
+	}
+	f.sent = append(f.sent, Sent{To: to, ID: resp.ID, Timestamp: resp.Timestamp, Message: message})
+	return resp, nil
+}
+
+func (f *Fake) BuildReaction(chat, sender types.JID, id types.MessageID, reaction string) *waE2E.Message {
+	key := &waCommon.MessageKey{
+		FromMe:    proto.Bool(true),
+		ID:        proto.String(id),
+		RemoteJID: proto.String(chat.String()),
+	}
+	if !sender.IsEmpty() && sender.User != f.ID.User && sender.User != f.LID.User {
+		key.FromMe = proto.Bool(false)
+		if chat.Server == types.GroupServer {
+			key.Participant = proto.String(sender.ToNonAD().String())
+		}
+	}
+	return &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{
+		Key:               key,
+		Text:              proto.String(reaction),
+		SenderTimestampMS: proto.Int64(f.now().UnixMilli()),
+	}}
+}
+
+func (f *Fake) SendAppState(_ context.Context, patch appstate.PatchInfo) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.patches = append(f.patches, patch)
+	return nil
+}
+
+func (f *Fake) MarkRead(_ context.Context, ids []types.MessageID, _ time.Time, chat, sender types.JID, _ ...types.ReceiptType) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads = append(f.reads, Read{Chat: chat, Sender: sender, IDs: slices.Clone(ids)})
+	return nil
+}
+
+func (f *Fake) Download(_ context.Context, msg whatsmeow.DownloadableMessage) ([]byte, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	data, ok := f.Media[msg.GetDirectPath()]
+	if !ok {
+		return nil, whatsmeow.ErrNoURLPresent
+	}
+	return data, nil
+}
+
+// Upload stores plaintext in Media under a new direct path, so Download
+// returns it again.
+func (f *Fake) Upload(_ context.Context, plaintext []byte, _ whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	if f.Err != nil {
+		return whatsmeow.UploadResponse{}, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	path := fmt.Sprintf("/fake/%06d", f.nextID)
+	f.Media[path] = slices.Clone(plaintext)
+	sum := sha256.Sum256(plaintext)
+	return whatsmeow.UploadResponse{
+		URL:        "https://mmg.whatsapp.net" + path,
+		DirectPath: path,
+		MediaKey:   make([]byte, 32),
+		FileSHA256: sum[:],
+		FileLength: uint64(len(plaintext)),
+	}, nil
+}
+
+func (f *Fake) GetContact(_ context.Context, jid types.JID) (types.ContactInfo, error) {
+	return f.Contacts[jid.ToNonAD()], nil
+}
+
+func (f *Fake) GetAllContacts(context.Context) (map[types.JID]types.ContactInfo, error) {
+	out := make(map[types.JID]types.ContactInfo, len(f.Contacts))
+	for jid, info := range f.Contacts {
+		info.Found = true
+		out[jid] = info
+	}
+	return out, nil
+}
+
+func (f *Fake) GetPNForLID(_ context.Context, lid types.JID) (types.JID, error) {
+	return f.LIDs[lid.ToNonAD()], nil
+}
+
+func (f *Fake) GetUserInfo(_ context.Context, jids []types.JID) (map[types.JID]types.UserInfo, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	out := make(map[types.JID]types.UserInfo, len(jids))
+	for _, jid := range jids {
+		if info, ok := f.Users[jid]; ok {
+			out[jid] = info
+		}
+	}
+	return out, nil
+}
+
+func (f *Fake) GetProfilePictureInfo(context.Context, types.JID, *whatsmeow.GetProfilePictureParams) (*types.ProfilePictureInfo, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return nil, whatsmeow.ErrProfilePictureNotSet
+}
+
+func (f *Fake) GetChatSettings(_ context.Context, chat types.JID) (types.LocalChatSettings, error) {
+	return f.Settings[chat], nil
+}
+
+func (f *Fake) GetJoinedGroups(context.Context) ([]*types.GroupInfo, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]*types.GroupInfo, 0, len(f.Groups))
+	for _, g := range f.Groups {
+		cp := *g
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (f *Fake) GetGroupInfo(_ context.Context, jid types.JID) (*types.GroupInfo, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g, ok := f.Groups[jid]
+	if !ok {
+		return nil, whatsmeow.ErrGroupNotFound
+	}
+	cp := *g
+	cp.Participants = slices.Clone(g.Participants)
+	return &cp, nil
+}
+
+func (f *Fake) CreateGroup(_ context.Context, req whatsmeow.ReqCreateGroup) (*types.GroupInfo, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	info := &types.GroupInfo{
+		JID:          types.NewJID(fmt.Sprintf("120363%012d", f.nextID), types.GroupServer),
+		OwnerJID:     f.ID,
+		GroupName:    types.GroupName{Name: req.Name},
+		GroupCreated: f.now(),
+		Participants: []types.GroupParticipant{{JID: f.ID, IsAdmin: true, IsSuperAdmin: true}},
+	}
+	for _, p := range req.Participants {
+		info.Participants = append(info.Participants, types.GroupParticipant{JID: p})
+	}
+	info.ParticipantCount = len(info.Participants)
+	f.Groups[info.JID] = info
+	cp := *info
+	return &cp, nil
+}
+
+func (f *Fake) SetGroupName(_ context.Context, jid types.JID, name string) error {
+	return f.updateGroup(jid, func(g *types.GroupInfo) { g.Name = name })
+}
+
+func (f *Fake) SetGroupDescription(_ context.Context, jid types.JID, description string) error {
+	return f.updateGroup(jid, func(g *types.GroupInfo) { g.Topic = description })
+}
+
+func (f *Fake) SetGroupPhoto(_ context.Context, jid types.JID, _ []byte) (string, error) {
+	return "1", f.updateGroup(jid, func(*types.GroupInfo) {})
+}
+
+func (f *Fake) UpdateGroupParticipants(_ context.Context, jid types.JID, changes []types.JID, action whatsmeow.ParticipantChange) ([]types.GroupParticipant, error) {
+	var res []types.GroupParticipant
+	err := f.updateGroup(jid, func(g *types.GroupInfo) {
+		for _, user := range changes {
+			i := slices.IndexFunc(g.Participants, func(p types.GroupParticipant) bool { return p.JID == user })
+			switch {
+			case action == whatsmeow.ParticipantChangeAdd && i < 0:
+				g.Participants = append(g.Participants, types.GroupParticipant{JID: user})
+				i = len(g.Participants) - 1
+			case i < 0:
+				res = append(res, types.GroupParticipant{JID: user, Error: 404})
+				continue
+			case action == whatsmeow.ParticipantChangeRemove:
+				res = append(res, g.Participants[i])
+				g.Participants = slices.Delete(g.Participants, i, i+1)
+				continue
+			case action == whatsmeow.ParticipantChangePromote:
+				g.Participants[i].IsAdmin = true
+			case action == whatsmeow.ParticipantChangeDemote:
+				g.Participants[i].IsAdmin = false
+			}
+			res = append(res, g.Participants[i])
+		}
+		g.ParticipantCount = len(g.Participants)
+	})
+	return res, err
+}
+
+func (f *Fake) GetGroupInviteLink(_ context.Context, jid types.JID, reset bool) (string, error) {
+	err := f.updateGroup(jid, func(*types.GroupInfo) {})
+	code := jid.User
+	if reset {
+		code += "-" + fmt.Sprint(f.now().Unix())
+	}
+	return "https://chat.whatsapp.com/" + code, err
+}
+
+func (f *Fake) SendPresence(_ context.Context, state types.Presence) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.presences = append(f.presences, state)
+	return nil
+}
+
+func (f *Fake) SubscribePresence(context.Context, types.JID) error {
+	return f.Err
+}
+
+// updateGroup applies a change to a known group.
+func (f *Fake) updateGroup(jid types.JID, apply func(g *types.GroupInfo)) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g, ok := f.Groups[jid]
+	if !ok {
+		return whatsmeow.ErrGroupNotFound
+	}
+	apply(g)
+	return nil
+}
+
+var _ Client = (*Fake)(nil)
@@ -0,0 +1,105 @@
+// Package wa narrows the whatsmeow client down to the operations the app
+// uses, so the client code and the TUI can run against an in-memory fake.
+package wa
+
+import (
+	"context"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Client is the subset of *whatsmeow.Client the app talks to. Methods that
+// exist on whatsmeow.Client keep their signatures; store lookups are flattened
+// into methods.
+type Client interface {
+	// Identity of the logged-in account; empty JIDs before pairing.
+	OwnID() types.JID
+	OwnLID() types.JID
+
+	// Events.
+	AddEventHandler(handler whatsmeow.EventHandler) uint32
+
+	// Sending.
+	SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error)
+	BuildReaction(chat, sender types.JID, id types.MessageID, reaction string) *waE2E.Message
+	SendAppState(ctx context.Context, patch appstate.PatchInfo) error
+	MarkRead(ctx context.Context, ids []types.MessageID, timestamp time.Time, chat, sender types.JID, receiptTypeExtra ...types.ReceiptType) error
+
+	// Media.
+	Download(ctx context.Context, msg whatsmeow.DownloadableMessage) ([]byte, error)
+	Upload(ctx context.Context, plaintext []byte, appInfo whatsmeow.MediaType) (whatsmeow.UploadResponse, error)
+
+	// Contacts.
+	GetContact(ctx context.Context, jid types.JID) (types.ContactInfo, error)
+	GetAllContacts(ctx context.Context) (map[types.JID]types.ContactInfo, error)
+	GetPNForLID(ctx context.Context, lid types.JID) (types.JID, error)
+	GetUserInfo(ctx context.Context, jids []types.JID) (map[types.JID]types.UserInfo, error)
+	GetProfilePictureInfo(ctx context.Context, jid types.JID, params *whatsmeow.GetProfilePictureParams) (*types.ProfilePictureInfo, error)
+	GetChatSettings(ctx context.Context, chat types.JID) (types.LocalChatSettings, error)
+
+	// Groups.
+	GetJoinedGroups(ctx context.Context) ([]*types.GroupInfo, error)
+	GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error)
+	CreateGroup(ctx context.Context, req whatsmeow.ReqCreateGroup) (*types.GroupInfo, error)
+	SetGroupName(ctx context.Context, jid types.JID, name string) error
+	SetGroupDescription(ctx context.Context, jid types.JID, description string) error
+	SetGroupPhoto(ctx context.Context, jid types.JID, avatar []byte) (string, error)
+	UpdateGroupParticipants(ctx context.Context, jid types.JID, participantChanges []types.JID, action whatsmeow.ParticipantChange) ([]types.GroupParticipant, error)
+	GetGroupInviteLink(ctx context.Context, jid types.JID, reset bool) (string, error)
+
+	// Presence.
+	SendPresence(ctx context.Context, state types.Presence) error
+	SubscribePresence(ctx context.Context, jid types.JID) error
+}
+
+// ── whatsmeow adapter ─────────────────────────────────────────────────────────
+
+// live adapts a real whatsmeow client; everything not defined here is
+// promoted from the embedded client.
+type live struct {
+	*whatsmeow.Client
+}
+
+// Wrap returns a Client backed by a whatsmeow client.
+func Wrap(c *whatsmeow.Client) Client {
+	return live{c}
+}
+
+func (l live) OwnID() types.JID {
+	if l.Store == nil || l.Store.ID == nil {
+		return types.EmptyJID
+	}
+	return *l.Store.ID
+}
+
+func (l live) OwnLID() types.JID {
+	if l.Store == nil {
+		return types.EmptyJID
+	}
+	return l.Store.LID
+}
+
+func (l live) GetContact(ctx context.Context, jid types.JID) (types.ContactInfo, error) {
+	return l.Store.Contacts.GetContact(ctx, jid)
+}
+
+func (l live) GetAllContacts(ctx context.Context) (map[types.JID]types.ContactInfo, error) {
+	return l.Store.Contacts.GetAllContacts(ctx)
+}
+
+func (l live) GetPNForLID(ctx context.Context, lid types.JID) (types.JID, error) {
+	return l.Store.LIDs.GetPNForLID(ctx, lid)
+}
+
+// GetChatSettings returns the pin / archive / mute state kept from earlier
+// app state syncs. Found is false if the store doesn't track settings.
+func (l live) GetChatSettings(ctx context.Context, chat types.JID) (types.LocalChatSettings, error) {
+	if l.Store.ChatSettings == nil {
+		return types.LocalChatSettings{}, nil
+	}
+	return l.Store.ChatSettings.GetChatSettings(ctx, chat)
+}
@@ -0,0 +1,277 @@
+// Package webhook POSTs incoming messages to configured URLs. Deliveries
+// go through a queue in the message database, so they survive restarts and
+// are retried with backoff while the receiver is down.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/StarGames2025/Logger"
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db"
+	apptypes "DevStarByte/internal/types"
+)
+
+// Headers of every delivery.
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body,
+	// keyed with the hook's secret.
+	SignatureHeader = "X-Webhook-Signature"
+	// DeliveryHeader is the same for every attempt of one delivery, so
+	// receivers can drop duplicates.
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	batchSize      = 20
+)
+
+// retryDelays is the wait after each failed attempt; a delivery that fails
+// once more is dropped.
+var retryDelays = []time.Duration{
+	10 * time.Second, time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 6 * time.Hour,
+}
+
+// Hook is one webhook from the config file. A message must pass every
+// filter that is set.
+type Hook struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+
+	Chats      []string `json:"chats,omitempty"`   // chat JIDs or phone numbers
+	Senders    []string `json:"senders,omitempty"` // sender JIDs or phone numbers
+	Match      string   `json:"match,omitempty"`   // regular expression on the content
+	MentionsMe bool     `json:"mentions_me,omitempty"`
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	ID         string    `json:"id"`
+	Chat       types.JID `json:"chat"`
+	ChatName   string    `json:"chat_name"`
+	IsGroup    bool      `json:"is_group"`
+	Sender     types.JID `json:"sender"`
+	SenderName string    `json:"sender_name"`
+	Content    string    `json:"content"`
+	Timestamp  time.Time `json:"timestamp"`
+	MentionsMe bool      `json:"mentions_me"`
+}
+
+// hook is a Hook ready for matching.
+type hook struct {
+	Hook
+	match *regexp.Regexp
+}
+
+func compile(h Hook) (*hook, error) {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q", h.URL)
+	}
+	if h.Secret == "" {
+		return nil, errors.New("secret is empty")
+	}
+	c := &hook{Hook: h}
+	if h.Match != "" {
+		if c.match, err = regexp.Compile(h.Match); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (h *hook) matches(p Payload) bool {
+	switch {
+	case len(h.Chats) > 0 && !apptypes.MatchJID(h.Chats, p.Chat),
+		len(h.Senders) > 0 && !apptypes.MatchJID(h.Senders, p.Sender),
+		h.match != nil && !h.match.MatchString(p.Content),
+		h.MentionsMe && !p.MentionsMe:
+		return false
+	}
+	return true
+}
+
+// sign returns the SignatureHeader value for body.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ── Dispatcher ────────────────────────────────────────────────────────────────
+
+// Dispatcher queues matching messages and delivers the queue in the
+// background until Close.
+type Dispatcher struct {
+	logger *Logger.Logger
+	store  *db.Store
+	hooks  []*hook
+	client *http.Client
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New starts delivering for the valid hooks; invalid ones are logged and
+// skipped. It returns nil, which disables webhooks, if no hook is left or
+// there is no database for the queue.
+func New(logger *Logger.Logger, store *db.Store, hooks []Hook) *Dispatcher {
+	if len(hooks) == 0 {
+		return nil
+	}
+	if store == nil {
+		logger.Warning("Webhooks disabled: they need messages.db")
+		return nil
+	}
+	d := &Dispatcher{
+		logger: logger,
+		store:  store,
+		client: &http.Client{Timeout: requestTimeout},
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	for i, h := range hooks {
+		c, err := compile(h)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Webhook %d disabled: %v", i+1, err))
+			continue
+		}
+		d.hooks = append(d.hooks, c)
+	}
+	if len(d.hooks) == 0 {
+		return nil
+	}
+	logger.Info(fmt.Sprintf("Webhooks: %d configured", len(d.hooks)))
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go d.run(ctx)
+	return d
+}
+
+// Dispatch queues p for every hook it matches.
+func (d *Dispatcher) Dispatch(p Payload) {
+	if d == nil {
+		return
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		d.logger.Error("Webhook payload: " + err.Error())
+		return
+	}
+	queued := false
+	for _, h := range d.hooks {
+		if h.matches(p) {
+			d.store.EnqueueWebhook(h.URL, body, sign(h.Secret, body))
+			queued = true
+		}
+	}
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close stops delivering. Undelivered messages stay queued for next time.
+func (d *Dispatcher) Close() {
+	if d == nil {
+		return
+	}
+	d.cancel()
+	<-d.done
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	for {
+		d.deliverDue(ctx)
+		wait := time.Hour
+		if next, ok := d.store.NextWebhookTime(); ok {
+			wait = max(0, time.Until(next))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// deliverDue delivers the queue in order until nothing is due.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for {
+		due := d.store.DueWebhooks(time.Now(), batchSize)
+		if len(due) == 0 {
+			return
+		}
+		for _, del := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			d.deliver(ctx, del)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, del db.WebhookDelivery) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.URL, bytes.NewReader(del.Body))
+	if err != nil {
+		d.logger.Warning("Dropping webhook to " + del.URL + ": " + err.Error())
+		d.store.DeleteWebhook(del.ID)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "whatsapp-tui")
+	req.Header.Set(SignatureHeader, del.Signature)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(del.ID, 10))
+
+	resp, err := d.client.Do(req)
+	if err == nil {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			d.logger.Debug(fmt.Sprintf("Webhook %d delivered to %s", del.ID, del.URL))
+			d.store.DeleteWebhook(del.ID)
+			return
+		}
+		err = errors.New(resp.Status)
+		// Other client errors won't go away by retrying.
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			d.logger.Warning(fmt.Sprintf("Dropping webhook %d to %s: %v", del.ID, del.URL, err))
+			d.store.DeleteWebhook(del.ID)
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		return // shutting down; try again next time
+	}
+	if del.Attempts >= len(retryDelays) {
+		d.logger.Warning(fmt.Sprintf("Giving up on webhook %d to %s after %d attempts: %v", del.ID, del.URL, del.Attempts+1, err))
+		d.store.DeleteWebhook(del.ID)
+		return
+	}
+	delay := retryDelays[del.Attempts]
+	d.logger.Warning(fmt.Sprintf("Webhook %d to %s failed, retrying in %s: %v", del.ID, del.URL, delay, err))
+	d.store.RetryWebhook(del.ID, del.Attempts+1, time.Now().Add(delay))
+}
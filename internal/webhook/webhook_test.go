@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"DevStarByte/internal/db"
+	"DevStarByte/internal/db/dbtest"
+)
+
+var (
+	alice = types.NewJID("491701111111", types.DefaultUserServer)
+	group = types.NewJID("120363000000000001", types.GroupServer)
+	t0    = time.Unix(1_760_000_000, 0).UTC()
+)
+
+// receiver records the deliveries it gets and answers with the statuses in
+// order, then 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	got      []*http.Request
+	bodies   [][]byte
+	arrived  chan struct{}
+}
+
+func newReceiver(t *testing.T, statuses ...int) (*receiver, string) {
+	rc := &receiver{statuses: statuses, arrived: make(chan struct{}, 16)}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		rc.got = append(rc.got, r)
+		rc.bodies = append(rc.bodies, body)
+		status := http.StatusOK
+		if len(rc.statuses) > 0 {
+			status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+		}
+		rc.mu.Unlock()
+		w.WriteHeader(status)
+		rc.arrived <- struct{}{}
+	}))
+	t.Cleanup(ts.Close)
+	return rc, ts.URL
+}
+
+func (rc *receiver) wait(t *testing.T, n int) {
+	t.Helper()
+	for range n {
+		select {
+		case <-rc.arrived:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no delivery")
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	p := Payload{Chat: group, Sender: alice, Content: "build #42 failed", MentionsMe: false}
+	for _, tc := range []struct {
+		hook Hook
+		want bool
+	}{
+		{Hook{}, true},
+		{Hook{Chats: []string{group.String()}}, true},
+		{Hook{Chats: []string{"491701111111"}}, false},
+		{Hook{Senders: []string{"+49 170 1111111"}}, true},
+		{Hook{Senders: []string{alice.String()}, Match: `(?i)FAILED`}, true},
+		{Hook{Match: `^deploy`}, false},
+		{Hook{MentionsMe: true}, false},
+	} {
+		tc.hook.URL, tc.hook.Secret = "http://localhost/hook", "s"
+		h, err := compile(tc.hook)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := h.matches(p); got != tc.want {
+			t.Errorf("%+v matches = %v, want %v", tc.hook, got, tc.want)
+		}
+	}
+}
+
+func TestCompileRejects(t *testing.T) {
+	for _, h := range []Hook{
+		{URL: "localhost:8123/hook", Secret: "s"},
+		{URL: "ftp://localhost/hook", Secret: "s"},
+		{URL: "http://localhost/hook"},
+		{URL: "http://localhost/hook", Secret: "s", Match: "("},
+	} {
+		if _, err := compile(h); err == nil {
+			t.Errorf("compile(%+v) succeeded", h)
+		}
+	}
+}
+
+func TestDeliverSigned(t *testing.T) {
+	logger, store := dbtest.New(t)
+	rc, url := newReceiver(t)
+	d := New(logger, store, []Hook{
+		{URL: url, Secret: "topsecret", Match: "hello"},
+		{URL: url + "/other", Secret: "x", Match: "nope"},
+	})
+	defer d.Close()
+
+	p := Payload{ID: "m1", Chat: alice, ChatName: "Alice", Sender: alice, SenderName: "Ali", Content: "hello", Timestamp: t0}
+	d.Dispatch(p)
+	rc.wait(t, 1)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.got) != 1 || rc.got[0].URL.Path != "/" {
+		t.Fatalf("deliveries = %d", len(rc.got))
+	}
+	body := rc.bodies[0]
+	if sig := rc.got[0].Header.Get(SignatureHeader); sig != sign("topsecret", body) {
+		t.Errorf("signature = %q", sig)
+	}
+	if rc.got[0].Header.Get(DeliveryHeader) == "" {
+		t.Error("no delivery ID")
+	}
+	var got Payload
+	if err := json.Unmarshal(body, &got); err != nil || got != p {
+		t.Errorf("payload = %s", body)
+	}
+}
+
+func TestRetryFromQueue(t *testing.T) {
+	saved := retryDelays
+	retryDelays = []time.Duration{10 * time.Millisecond}
+	t.Cleanup(func() { retryDelays = saved })
+
+	logger, store := dbtest.New(t)
+	rc, url := newReceiver(t, http.StatusServiceUnavailable)
+	d := New(logger, store, []Hook{{URL: url, Secret: "s"}})
+	defer d.Close()
+
+	d.Dispatch(Payload{ID: "m1", Content: "hi"})
+	rc.wait(t, 2) // 503, then 200 on the retry
+	rc.mu.Lock()
+	first, second := rc.got[0].Header.Get(DeliveryHeader), rc.got[1].Header.Get(DeliveryHeader)
+	rc.mu.Unlock()
+	if first != second {
+		t.Errorf("delivery IDs %s and %s differ", first, second)
+	}
+	waitEmpty(t, store)
+}
+
+// waitEmpty waits for the dispatcher to finish with the queue.
+func waitEmpty(t *testing.T, store *db.Store) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := store.NextWebhookTime(); !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("delivery still queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDropsAfterClientError(t *testing.T) {
+	logger, store := dbtest.New(t)
+	rc, url := newReceiver(t, http.StatusBadRequest)
+	d := New(logger, store, []Hook{{URL: url, Secret: "s"}})
+	d.Dispatch(Payload{ID: "m1"})
+	defer d.Close()
+	rc.wait(t, 1)
+	waitEmpty(t, store)
+}
+
+func TestQueueSurvivesRestart(t *testing.T) {
+	logger, store := dbtest.New(t)
+	rc, url := newReceiver(t)
+	// Queued by an earlier run that stopped before delivering.
+	store.EnqueueWebhook(url, []byte(`{"id":"m1"}`), sign("s", []byte(`{"id":"m1"}`)))
+
+	d := New(logger, store, []Hook{{URL: url, Secret: "s"}})
+	defer d.Close()
+	rc.wait(t, 1)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if string(rc.bodies[0]) != `{"id":"m1"}` {
+		t.Errorf("body = %s", rc.bodies[0])
+	}
+}
+
+func TestNewWithoutHooks(t *testing.T) {
+	logger, store := dbtest.New(t)
+	if d := New(logger, store, []Hook{{URL: "nope"}}); d != nil {
+		t.Error("New returned a dispatcher without valid hooks")
+	}
+	var d *Dispatcher
+	d.Dispatch(Payload{})
+	d.Close()
+}